@@ -0,0 +1,60 @@
+package groupjson
+
+import (
+	"reflect"
+)
+
+// GroupEncoderAppender 由 cmd/groupjson 生成的编码代码实现：按分组与模式把值的 JSON 追加到 dst 并返回。
+// 反射编码器遇到实现了该接口的类型（包括嵌套在反射编码的父结构体、切片或 map 中的值）时自动调用，
+// 生成的类型因此无需改动调用方即可走快速路径。
+//
+// 生成代码只按分组标签输出默认格式，因此配置了会改变输出的选项（自定义 TagKey、命名策略、
+// 字段排序、路径规则、追踪、HTML 转义、浮点格式、非默认的深度与循环引用处理等）或为该类型注册了
+// 计算字段时，编码器不调用它而回退到反射路径，两者输出一致。
+type GroupEncoderAppender interface {
+	AppendGroupJSON(dst []byte, groups []string, mode GroupMode) ([]byte, error)
+}
+
+var groupAppenderType = reflect.TypeFor[GroupEncoderAppender]()
+
+// hasAppender 判断 t（或 *t）是否实现 GroupEncoderAppender。
+func hasAppender(t reflect.Type) bool {
+	return reflect.PointerTo(t).Implements(groupAppenderType)
+}
+
+// appenderAllowed 当前配置下能否把类型 t 的值交给生成代码编码。
+func (e Encoder) appenderAllowed(ctx *encodeContext, t reflect.Type) bool {
+	o := &e.opts
+	if !ctx.fastPathAllowed() || o.TagKey != DefaultTagKey || o.TagKeyFallback != "" || o.Naming.Fn != nil ||
+		len(o.MergedViews) > 0 || o.EscapeHTML || o.SortKeys || o.SortFields || len(o.PinnedFields) > 0 ||
+		o.Int64AsString || o.FloatFormat != 0 || o.FloatSpecials != FloatSpecialError ||
+		o.NilCollections != NilAsNull || o.DeepOmitEmpty || o.KeyTables || o.TypeDiscriminator != "" ||
+		len(o.TypeDepths) > 0 || len(o.TupleTypes) > 0 || o.DebugLogger != nil ||
+		o.MaxDepth != DefaultMaxDepth || o.DepthPolicy != DepthError || o.CycleHandling != CycleError {
+		return false
+	}
+	_, virtual := virtualFields.Load(t)
+	return !virtual
+}
+
+// asGroupAppender 尝试提取 GroupEncoderAppender 接口
+func asGroupAppender(v reflect.Value) (GroupEncoderAppender, bool) {
+	if !v.IsValid() {
+		return nil, false
+	}
+	// 可寻址时先取指针：指针装入接口不分配，且其方法集包含值接收者的方法
+	if v.CanAddr() {
+		pv := v.Addr()
+		if pv.CanInterface() {
+			if m, ok := pv.Interface().(GroupEncoderAppender); ok {
+				return m, true
+			}
+		}
+	}
+	if v.CanInterface() {
+		if m, ok := v.Interface().(GroupEncoderAppender); ok {
+			return m, true
+		}
+	}
+	return nil, false
+}
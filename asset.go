@@ -0,0 +1,25 @@
+package groupjson
+
+import "reflect"
+
+// AssetTagKey 标记资源字段的结构体标签键，取值为资源类型，如 asset:"image"。
+const AssetTagKey = "asset"
+
+// AssetHook 将资源字段的原始值（二进制、URL 等）替换为派生表示，
+// 如缩略图地址与尺寸；kind 为 asset 标签取值，path 含字段自身。
+type AssetHook func(kind string, path Path, v reflect.Value) (any, error)
+
+// WithAssetHook 设置资源字段钩子，编码带 asset 标签的字段时以钩子返回值代替原值，
+// 无需在序列化后再对响应做一次处理。未设置钩子时按原值输出。
+func (e Encoder) WithAssetHook(fn AssetHook) Encoder { e.opts.AssetHook = fn; return e }
+
+// applyAsset 对资源字段调用钩子，返回替换后的值。
+func (e Encoder) applyAsset(f *fieldInfo, fv reflect.Value, ctx *encodeContext) (reflect.Value, error) {
+	ctx.pushPath(PathSegment{Kind: SegmentField, Name: f.jsonName})
+	nv, err := e.opts.AssetHook(f.asset, ctx.path, fv)
+	ctx.popPath()
+	if err != nil {
+		return fv, err
+	}
+	return reflect.ValueOf(nv), nil
+}
@@ -0,0 +1,86 @@
+package groupjson
+
+import (
+	"reflect"
+	"strings"
+)
+
+// FindingKind 审计发现的类别。
+type FindingKind string
+
+const (
+	// FindingUntagged 导出字段没有分组标签，请求任何分组时都不会输出（多为遗漏）。
+	FindingUntagged FindingKind = "untagged"
+	// FindingSuspicious 字段同时属于 SuspiciousGroupPairs 中的一对分组，如 internal 与 public。
+	FindingSuspicious FindingKind = "suspicious-groups"
+)
+
+// SuspiciousGroupPairs Audit 视为可疑的分组组合：字段同时声明两者时报告 FindingSuspicious，
+// 通常意味着内部字段被误加了公开分组。可在程序初始化时按团队约定修改。
+var SuspiciousGroupPairs = [][2]string{
+	{"internal", "public"},
+	{"private", "public"},
+	{"secret", "public"},
+}
+
+// Finding 描述 Audit 发现的一个问题字段。
+type Finding struct {
+	// Type 字段所属结构体类型
+	Type string
+	// Field Go 字段名
+	Field string
+	// Key 输出使用的 JSON 键名
+	Key string
+	// Kind 问题类别
+	Kind FindingKind
+	// Detail 问题说明
+	Detail string
+}
+
+func (f Finding) String() string {
+	return f.Type + "." + f.Field + " (" + f.Key + "): " + string(f.Kind) + ": " + f.Detail
+}
+
+// Audit 使用默认配置审计类型的分组标签，见 Encoder.Audit。
+func Audit(types ...any) []Finding {
+	return NewEncoder().Audit(types...)
+}
+
+// Audit 检查样本值的类型（递归包含嵌套结构体）的分组标签，报告没有分组标签而永远不会输出的导出字段，
+// 以及同时属于 SuspiciousGroupPairs 中一对分组的字段；显式标记为 "-" 的字段视为有意隐藏，不报告。
+// 结果按类型遍历顺序与字段声明顺序排列，可作为安全检查的门禁。
+func (e Encoder) Audit(types ...any) []Finding {
+	var out []Finding
+	e.walkSchemas(types, func(t reflect.Type, sch *schema) {
+		for _, f := range sch.fields {
+			if f.never {
+				continue
+			}
+			finding := Finding{Type: t.String(), Field: f.name, Key: f.jsonName}
+			groups := map[string]struct{}{}
+			for _, entry := range f.groups {
+				for _, g := range strings.Split(entry, "+") {
+					if g != "" {
+						groups[g] = struct{}{}
+					}
+				}
+			}
+			if len(groups) == 0 {
+				finding.Kind = FindingUntagged
+				finding.Detail = "no " + e.opts.TagKey + " tag; the field is never emitted when groups are requested"
+				out = append(out, finding)
+				continue
+			}
+			for _, pair := range SuspiciousGroupPairs {
+				_, ok1 := groups[pair[0]]
+				_, ok2 := groups[pair[1]]
+				if ok1 && ok2 {
+					finding.Kind = FindingSuspicious
+					finding.Detail = "field is in both " + pair[0] + " and " + pair[1]
+					out = append(out, finding)
+				}
+			}
+		}
+	})
+	return out
+}
@@ -0,0 +1,112 @@
+package groupjson
+
+import (
+	"bytes"
+	"reflect"
+)
+
+// Backend 可替换的 JSON 编码后端，如 bytedance/sonic 或 goccy/go-json。
+// sonic 的 sonic.ConfigStd 等配置可直接使用；只提供包级函数的库用 BackendFunc 包装：
+//
+//	enc := groupjson.NewEncoder().WithBackend(sonic.ConfigStd)
+//	enc := groupjson.NewEncoder().WithBackend(groupjson.BackendFunc(gojson.Marshal))
+//
+// groupjson 本身不依赖这些库，反射实现始终是默认路径。
+type Backend interface {
+	Marshal(v any) ([]byte, error)
+}
+
+// BackendFunc 将函数适配为 Backend。
+type BackendFunc func(v any) ([]byte, error)
+
+// Marshal 调用 f(v)。
+func (f BackendFunc) Marshal(v any) ([]byte, error) { return f(v) }
+
+// backendStringMin 交给后端的字符串的最小长度：短字符串逐个调用后端的开销（装箱、额外的函数调用）
+// 大于其 SIMD 转义带来的收益。
+const backendStringMin = 256
+
+// WithBackend 设置编码后端：与分组无关的子树——元素不含结构体与接口的切片、数组、map
+// （如 []float64、map[string][]string）以及长字符串——整体交给 b 编码，结构体仍由 groupjson 按分组筛选。
+// 以下情况不委托，保持原有输出：配置了路径规则、追踪、非 ErrorFail 策略或 WithMaxBytes，
+// 以及 Int64AsString、WithFloatFormat、WithFloatSpecials、NilAsEmpty 等会改变输出格式的选项。
+// 委托部分的 HTML 转义、map 键顺序与浮点格式以后端为准（均为等价的 JSON）。
+func (e Encoder) WithBackend(b Backend) Encoder { e.opts.Backend = b; return e }
+
+// backendTypes 类型 -> 是否为与分组无关、可整体交给后端的类型。
+var backendTypes cache[reflect.Type, bool]
+
+// backendPlain 判断类型 t 的值能否整体交给后端：由基本类型（及其指针）、[]byte 与它们组成的
+// 切片、数组、字符串键 map 构成，不含结构体、接口或自定义序列化。
+func backendPlain(t reflect.Type) bool {
+	if ok, found := backendTypes.Load(t); found {
+		return ok
+	}
+	ok := backendPlainType(t, 0)
+	backendTypes.Store(t, ok)
+	return ok
+}
+
+func backendPlainType(t reflect.Type, depth int) bool {
+	if depth > 16 {
+		// 如 type T []T
+		return false
+	}
+	if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+		// []byte：后端同样编码为 base64
+		return !hasMarshaler(t) && !hasMarshaler(t.Elem())
+	}
+	if dynamicType(t) {
+		return false
+	}
+	switch t.Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.String:
+		return true
+	case reflect.Pointer, reflect.Slice, reflect.Array:
+		return backendPlainType(t.Elem(), depth+1)
+	case reflect.Map:
+		return t.Key().Kind() == reflect.String && !dynamicType(t.Key()) && backendPlainType(t.Elem(), depth+1)
+	}
+	return false
+}
+
+// backendAllowed 当前值能否委托给后端：配置了后端、不需要逐值路径，且输出格式与标准库一致。
+// 读取 e.opts 而非 ctx.opts，使 gjprec 等字段级覆盖同样生效。
+func (e Encoder) backendAllowed(ctx *encodeContext) bool {
+	o := &e.opts
+	return o.Backend != nil && ctx.fastPathAllowed() && !o.Int64AsString && o.FloatFormat == 0 &&
+		o.FloatSpecials == FloatSpecialError && o.NilCollections == NilAsNull
+}
+
+// withBackend 包装类型 t 的编码函数：允许时整体交给后端，否则调用 enc。
+// 不可导出的值（经由未导出字段取得）无法装箱，同样回退到 enc。
+func withBackend(enc encoderFunc) encoderFunc {
+	return func(e Encoder, buf *bytes.Buffer, v reflect.Value, ctx *encodeContext) error {
+		if !e.backendAllowed(ctx) || !v.CanInterface() {
+			return enc(e, buf, v, ctx)
+		}
+		b, err := e.opts.Backend.Marshal(v.Interface())
+		if err != nil {
+			return err
+		}
+		buf.Write(b)
+		return nil
+	}
+}
+
+// withBackendString 包装字符串类型的编码函数，只有长字符串交给后端。
+func withBackendString(enc encoderFunc) encoderFunc {
+	return func(e Encoder, buf *bytes.Buffer, v reflect.Value, ctx *encodeContext) error {
+		if v.Len() < backendStringMin || !e.backendAllowed(ctx) || !v.CanInterface() {
+			return enc(e, buf, v, ctx)
+		}
+		b, err := e.opts.Backend.Marshal(v.Interface())
+		if err != nil {
+			return err
+		}
+		buf.Write(b)
+		return nil
+	}
+}
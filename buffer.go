@@ -0,0 +1,51 @@
+package groupjson
+
+import (
+	"bytes"
+	"sync"
+)
+
+// bufferTiers 缓冲池分档：第 i 档存放容量不小于 bufferTiers[i]（且小于下一档）的缓冲，
+// 小响应取不到为大响应扩容过的缓冲，大响应也不必从小缓冲反复扩容。
+var bufferTiers = [...]int{0, 4 << 10, 64 << 10}
+
+// maxPooledBuffer 归还时容量超过此值的缓冲直接交给 GC，避免偶发的超大响应长期占用池内存。
+const maxPooledBuffer = 1 << 20
+
+var bufPools [len(bufferTiers)]sync.Pool
+
+// WithBufferSize 设置输出缓冲的初始容量提示（字节），通常取该接口响应的典型大小：
+// 从对应分档的池中取缓冲，不足时一次性扩容到 n，避免编码过程中反复扩容。<= 0 表示不提示。
+func (e Encoder) WithBufferSize(n int) Encoder { e.opts.BufferSize = n; return e }
+
+// bufferTier 返回容量 n 所属的分档。
+func bufferTier(n int) int {
+	i := len(bufferTiers) - 1
+	for i > 0 && n < bufferTiers[i] {
+		i--
+	}
+	return i
+}
+
+// getBuffer 取一个已清空、容量至少为 hint 的缓冲。
+func getBuffer(hint int) *bytes.Buffer {
+	var buf *bytes.Buffer
+	if b, ok := bufPools[bufferTier(hint)].Get().(*bytes.Buffer); ok {
+		buf = b
+		buf.Reset()
+	} else {
+		buf = new(bytes.Buffer)
+	}
+	if hint > 0 {
+		buf.Grow(hint)
+	}
+	return buf
+}
+
+// putBuffer 按容量把缓冲归还到对应分档，超过 maxPooledBuffer 的丢弃。
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBuffer {
+		return
+	}
+	bufPools[bufferTier(buf.Cap())].Put(buf)
+}
@@ -0,0 +1,31 @@
+//go:build !tinygo
+
+package groupjson
+
+import "sync"
+
+// cache 编码路径使用的并发安全缓存（schema、计划、模式等），读多写少。
+// 默认基于 sync.Map；TinyGo 构建见 cache_tinygo.go。
+type cache[K comparable, V any] struct {
+	m sync.Map
+}
+
+func (c *cache[K, V]) Load(k K) (V, bool) {
+	v, ok := c.m.Load(k)
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	return v.(V), true
+}
+
+func (c *cache[K, V]) Store(k K, v V) { c.m.Store(k, v) }
+
+// LoadOrStore 返回已有值（loaded 为 true），否则存入 v。
+func (c *cache[K, V]) LoadOrStore(k K, v V) (V, bool) {
+	actual, loaded := c.m.LoadOrStore(k, v)
+	return actual.(V), loaded
+}
+
+// Clear 删除全部条目。
+func (c *cache[K, V]) Clear() { c.m.Clear() }
@@ -0,0 +1,49 @@
+//go:build tinygo
+
+package groupjson
+
+import "sync"
+
+// cache 在 TinyGo/wasm 受限构建中以互斥锁保护的普通 map 实现，
+// 不依赖 sync.Map 的无锁内部结构，行为可预期且代码体积更小；边缘运行时多为单线程，锁几乎无竞争。
+type cache[K comparable, V any] struct {
+	mu sync.Mutex
+	m  map[K]V
+}
+
+func (c *cache[K, V]) Load(k K) (V, bool) {
+	c.mu.Lock()
+	v, ok := c.m[k]
+	c.mu.Unlock()
+	return v, ok
+}
+
+func (c *cache[K, V]) Store(k K, v V) {
+	c.mu.Lock()
+	if c.m == nil {
+		c.m = make(map[K]V)
+	}
+	c.m[k] = v
+	c.mu.Unlock()
+}
+
+// LoadOrStore 返回已有值（loaded 为 true），否则存入 v。
+func (c *cache[K, V]) LoadOrStore(k K, v V) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if old, ok := c.m[k]; ok {
+		return old, true
+	}
+	if c.m == nil {
+		c.m = make(map[K]V)
+	}
+	c.m[k] = v
+	return v, false
+}
+
+// Clear 删除全部条目。
+func (c *cache[K, V]) Clear() {
+	c.mu.Lock()
+	clear(c.m)
+	c.mu.Unlock()
+}
@@ -0,0 +1,73 @@
+// Package cachecodec 提供按分组视图存储缓存内容的编解码器。
+//
+// Codec 的 Marshal/Unmarshal 签名与 go-redis/cache 等缓存库的编解码钩子一致，
+// 可直接赋值使用；同一份数据的不同视图通过键后缀区分，便于统一生成与失效。
+package cachecodec
+
+import (
+	"encoding/json"
+
+	"github.com/JieBaiYou/groupjson"
+)
+
+// KeySeparator 缓存基础键与视图名之间的分隔符。
+const KeySeparator = ":"
+
+// View 描述一个缓存视图：Name 作为键后缀，Groups 决定输出字段。
+type View struct {
+	// Name 视图名称，如 "public"、"admin"
+	Name string
+	// Groups 该视图序列化时使用的分组
+	Groups []string
+}
+
+// Codec 以固定视图对值进行分组过滤后编码。
+type Codec struct {
+	// enc 共享的编码器配置
+	enc groupjson.Encoder
+	// view 当前视图
+	view View
+}
+
+// New 创建绑定到指定视图的 Codec。
+func New(enc groupjson.Encoder, view View) Codec {
+	return Codec{enc: enc, view: view}
+}
+
+// Marshal 按视图分组序列化 v。
+func (c Codec) Marshal(v any) ([]byte, error) {
+	return c.enc.MarshalGroups(v, c.view.Groups...)
+}
+
+// Unmarshal 将缓存内容解码到 v；过滤后的字段在 v 中保持零值。
+func (c Codec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+// Key 返回该视图下的缓存键。
+func (c Codec) Key(base string) string {
+	return base + KeySeparator + c.view.Name
+}
+
+// Keys 返回 base 在所有视图下的缓存键，用于统一失效。
+func Keys(base string, views ...View) []string {
+	out := make([]string, len(views))
+	for i, v := range views {
+		out[i] = base + KeySeparator + v.Name
+	}
+	return out
+}
+
+// MarshalViews 一次性生成 v 在所有视图下的缓存内容，返回 缓存键 -> JSON。
+func MarshalViews(enc groupjson.Encoder, base string, v any, views ...View) (map[string][]byte, error) {
+	out := make(map[string][]byte, len(views))
+	for _, view := range views {
+		c := New(enc, view)
+		b, err := c.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		out[c.Key(base)] = b
+	}
+	return out, nil
+}
@@ -0,0 +1,37 @@
+package cachecodec
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/JieBaiYou/groupjson"
+)
+
+type User struct {
+	ID    int    `json:"id" groups:"public,admin"`
+	Email string `json:"email" groups:"admin"`
+}
+
+func TestMarshalViews(t *testing.T) {
+	views := []View{{Name: "public", Groups: []string{"public"}}, {Name: "admin", Groups: []string{"admin"}}}
+	out, err := MarshalViews(groupjson.NewEncoder(), "user:1", User{ID: 1, Email: "a@x"}, views...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(out["user:1:public"]), "email") {
+		t.Fatalf("public view leaked email: %s", out["user:1:public"])
+	}
+	if !strings.Contains(string(out["user:1:admin"]), "email") {
+		t.Fatalf("admin view missing email: %s", out["user:1:admin"])
+	}
+
+	keys := Keys("user:1", views...)
+	if len(keys) != 2 || keys[0] != "user:1:public" || keys[1] != "user:1:admin" {
+		t.Fatalf("unexpected keys: %v", keys)
+	}
+
+	var u User
+	if err := New(groupjson.NewEncoder(), views[0]).Unmarshal(out["user:1:public"], &u); err != nil || u.ID != 1 {
+		t.Fatalf("unmarshal failed: %v %+v", err, u)
+	}
+}
@@ -0,0 +1,83 @@
+package groupjson
+
+import (
+	"reflect"
+	"sync/atomic"
+)
+
+// CacheMetrics 编码缓存的统计快照，见 CacheStats。条目数与字节数为近似值。
+type CacheMetrics struct {
+	// Entries 缓存的 schema 数，每个（类型, TagKey 等配置）组合一项
+	Entries int64
+	// Plans 缓存的字段计划数，每个（类型, 分组, 模式）组合一项
+	Plans int64
+	// Hits schema 缓存命中次数（自进程启动累计，ResetCaches 不清零）
+	Hits uint64
+	// Misses schema 缓存未命中即构建 schema 的次数（同样累计）
+	Misses uint64
+	// Bytes schema 与字段计划占用内存的估算值
+	Bytes int64
+}
+
+// schemaStats schema 缓存的计数器。
+var schemaStats struct {
+	entries, bytes atomic.Int64
+	hits, misses   atomic.Uint64
+}
+
+// planBytes planCache 中计划占用内存的估算值。
+var planBytes atomic.Int64
+
+// MaxCachedSchemas schema 缓存的条目上限，达到上限时调用 ResetCaches 整体清空、按需重建；
+// <= 0（默认）表示不限制。大量动态生成类型（如泛型实例化）的长期运行服务可据此限制内存。
+var MaxCachedSchemas = 0
+
+// CacheStats 返回 schema 与字段计划缓存的统计快照，可定期上报监控。
+func CacheStats() CacheMetrics {
+	return CacheMetrics{
+		Entries: schemaStats.entries.Load(),
+		Plans:   planCount.Load(),
+		Hits:    schemaStats.hits.Load(),
+		Misses:  schemaStats.misses.Load(),
+		Bytes:   schemaStats.bytes.Load() + planBytes.Load(),
+	}
+}
+
+// ResetCaches 清空全部编码缓存（schema、字段计划、编码函数、已编译的路径模式等），之后按需重建。
+// 正在进行的编码不受影响；RegisterType、RegisterGroups 等注册信息不会被清除。
+// 已生成的 Compiled 仍可继续使用，所需的缓存同样按需重建。
+func ResetCaches() {
+	schemaCache.Clear()
+	schemaStats.entries.Store(0)
+	schemaStats.bytes.Store(0)
+	planCache.Clear()
+	planCount.Store(0)
+	planBytes.Store(0)
+	encoderCache.Clear()
+	exportedSchemas.Clear()
+	debugSeen.Clear()
+}
+
+var (
+	fieldInfoSize = int64(reflect.TypeFor[fieldInfo]().Size())
+	schemaSize    = int64(reflect.TypeFor[schema]().Size())
+	planSize      = int64(reflect.TypeFor[plan]().Size())
+)
+
+// schemaFootprint 估算 schema 占用的内存：结构体本身、字段表与其中的字符串和切片。
+func schemaFootprint(s *schema) int64 {
+	n := schemaSize + int64(len(s.fields))*(fieldInfoSize+8)
+	for i := range s.fields {
+		f := &s.fields[i]
+		n += int64(len(f.name) + len(f.jsonName) + len(f.leadBytes) + 8*len(f.index) + 8*len(f.groupBits))
+		for _, g := range f.groups {
+			n += int64(len(g)) + 16
+		}
+	}
+	return n
+}
+
+// planFootprint 估算计划占用的内存。
+func planFootprint(p *plan) int64 {
+	return planSize + 8*int64(cap(p.fields))
+}
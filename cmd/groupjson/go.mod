@@ -0,0 +1,18 @@
+module github.com/JieBaiYou/groupjson/cmd/groupjson
+
+go 1.24.0
+
+require github.com/JieBaiYou/groupjson/codegen v0.0.0
+
+require (
+	github.com/JieBaiYou/groupjson v0.0.0 // indirect
+	golang.org/x/mod v0.31.0 // indirect
+	golang.org/x/sync v0.19.0 // indirect
+	golang.org/x/tools v0.40.0 // indirect
+)
+
+// 与库、代码生成器在同一仓库中开发，始终使用相邻目录中的版本
+replace (
+	github.com/JieBaiYou/groupjson => ../../
+	github.com/JieBaiYou/groupjson/codegen => ../../codegen
+)
@@ -0,0 +1,585 @@
+// Command groupjson 是 groupjson 的命令行工具。
+//
+// 用法:
+//
+//	groupjson audit [-tag groups] [packages]
+//	groupjson list [-type User,Order] [-tag groups] [packages]
+//	groupjson gen-fixtures -pkg example.com/app/model -types User,Order [-groups public,admin] [-out testdata/fixtures] [-seed 1] [-count 1]
+//	groupjson gen-openapi -pkg example.com/app/model -types User,Order [-groups public,admin] [-out openapi.json]
+//	groupjson gen-groups -pkg example.com/app/model -types User,Order [-package model] [-out groups_gen.go]
+//	groupjson gen-ts -pkg example.com/app/model -types User,Order [-groups public,admin] [-out web/src/types.gen.ts]
+//	groupjson gen-encoders [-type User,Order] [-instantiate Page[User],Pair[int,string]] [-out model_groupjson.go] [-check] [packages]
+//
+// audit 审计包中导出结构体类型的分组标签（默认 ./...），报告没有分组标签的字段与可疑的分组组合，
+// 存在发现时以状态码 1 退出，可作为 CI 门禁。
+// list 为包中的导出结构体类型（默认 ./...，可用 -type 筛选）打印 JSON 字段与分组的对照表及 omitempty 等修饰，
+// 便于审查各视图暴露了哪些字段。
+// gen-fixtures 为指定包中的类型生成伪随机实例，并按每个分组视图写出 JSON 文件。
+// gen-openapi 为每个（类型, 分组）组合生成 OpenAPI 3.1 组件 schema，如 UserPublic、UserAdmin。
+// gen-groups 扫描类型的分组标签，生成分组名常量（如 GroupPublic）并在 init 中注册到 groupjson.RegisterGroups。
+// gen-ts 为每个（类型, 分组）组合生成 TypeScript 接口，使前端类型与 Go 标签保持同步。
+// gen-encoders 为包中的结构体生成免反射的分组编码方法（见 codegen 包，默认当前目录的包），
+// 通过 go/packages 加载类型信息，不需要驱动程序；泛型结构体生成泛型方法，-instantiate 列出的实例另外生成专用代码。
+// -check 只在内存中重新生成，提交的生成文件过时时输出 diff 并以状态码 1 退出，可作为 CI 门禁。
+// Go 无法在运行时按名称加载其他包的类型，因此命令会在当前模块内生成一个临时驱动程序，
+// 通过 go run 执行后删除；需在能够导入目标包的模块目录下运行。
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/JieBaiYou/groupjson/codegen"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	var err error
+	switch os.Args[1] {
+	case "audit":
+		err = audit(os.Args[2:])
+	case "list":
+		err = list(os.Args[2:])
+	case "gen-fixtures":
+		err = genFixtures(os.Args[2:])
+	case "gen-openapi":
+		err = genView("gen-openapi", os.Args[2:])
+	case "gen-groups":
+		err = genGroups(os.Args[2:])
+	case "gen-ts":
+		err = genView("gen-ts", os.Args[2:])
+	case "gen-encoders":
+		err = genEncoders(os.Args[2:])
+	case "-h", "-help", "--help", "help":
+		usage()
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "groupjson: unknown command %q\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+	// 驱动程序已将错误写到 stderr，只需沿用其退出码
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		os.Exit(exitErr.ExitCode())
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "groupjson:", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, `usage: groupjson <command> [flags]
+
+commands:
+  audit          report fields without groups tags and suspicious group combinations
+  list           print a table of JSON fields versus groups for struct types
+  gen-fixtures   render pseudo-random per-group JSON fixtures for model types
+  gen-openapi    emit OpenAPI 3.1 component schemas for each type/group view
+  gen-groups     emit typed group-name constants scanned from struct tags
+  gen-ts         emit TypeScript interfaces for each type/group view
+  gen-encoders   emit reflection-free group encoders for struct types`)
+}
+
+// driverTmpl 临时驱动程序模板，导入目标包并调用 fixtures 包完成生成与渲染。
+var driverTmpl = template.Must(template.New("driver").Parse(`// Code generated by groupjson gen-fixtures. DO NOT EDIT.
+package main
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+
+	"github.com/JieBaiYou/groupjson"
+	"github.com/JieBaiYou/groupjson/fixtures"
+
+	target {{printf "%q" .Pkg}}
+)
+
+func main() {
+	enc := groupjson.NewEncoder(){{if .TagKey}}.WithTagKey({{printf "%q" .TagKey}}){{end}}
+	groups := []string{ {{- range .Groups}}{{printf "%q" .}}, {{end -}} }
+	types := map[string]reflect.Type{
+{{- range .Types}}
+		{{printf "%q" .}}: reflect.TypeFor[target.{{.}}](),
+{{- end}}
+	}
+	for _, name := range []string{ {{- range .Types}}{{printf "%q" .}}, {{end -}} } {
+		for i := 0; i < {{.Count}}; i++ {
+			file := name
+			if {{.Count}} > 1 {
+				file = fmt.Sprintf("%s_%d", name, i+1)
+			}
+			v := fixtures.Generate(types[name], {{.Seed}}+int64(i))
+			files, err := fixtures.Render(enc, {{printf "%q" .Out}}, file, v, groups...)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			for _, f := range files {
+				fmt.Println(f)
+			}
+		}
+	}
+}
+`))
+
+// genConfig 生成类子命令的命令行参数。
+type genConfig struct {
+	// Cmd 子命令名
+	Cmd string
+	// Func 驱动程序调用的 Encoder 方法（仅视图类子命令）
+	Func string
+	// Pkg 目标包导入路径
+	Pkg string
+	// Types 需要生成的类型名
+	Types []string
+	// Groups 渲染的分组，为空时使用类型标签中出现的全部分组
+	Groups []string
+	// Out 输出目录
+	Out string
+	// Seed 随机种子
+	Seed int64
+	// Count 每个类型生成的实例数
+	Count int
+	// TagKey 分组标签名，为空时使用默认值
+	TagKey string
+	// Package 生成文件的包名（仅 gen-groups）
+	Package string
+}
+
+func genFixtures(args []string) error {
+	fs := flag.NewFlagSet("gen-fixtures", flag.ExitOnError)
+	var cfg genConfig
+	var types, groups string
+	fs.StringVar(&cfg.Pkg, "pkg", "", "import path of the package declaring the types (required)")
+	fs.StringVar(&types, "types", "", "comma-separated type names (required)")
+	fs.StringVar(&groups, "groups", "", "comma-separated groups to render (default: all groups found in tags)")
+	fs.StringVar(&cfg.Out, "out", "testdata/fixtures", "output directory")
+	fs.Int64Var(&cfg.Seed, "seed", 1, "random seed")
+	fs.IntVar(&cfg.Count, "count", 1, "instances per type")
+	fs.StringVar(&cfg.TagKey, "tag", "", "group tag key (default \"groups\")")
+	fs.Parse(args)
+
+	cfg.Types = splitList(types)
+	cfg.Groups = splitList(groups)
+	if cfg.Pkg == "" || len(cfg.Types) == 0 {
+		fs.Usage()
+		return fmt.Errorf("gen-fixtures: -pkg and -types are required")
+	}
+	if cfg.Count < 1 {
+		cfg.Count = 1
+	}
+
+	return runDriver(driverTmpl, cfg)
+}
+
+// viewTmpl gen-openapi 与 gen-ts 的临时驱动程序模板，按视图调用 Encoder 的 Func 方法。
+var viewTmpl = template.Must(template.New("view").Parse(`// Code generated by groupjson {{.Cmd}}. DO NOT EDIT.
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/JieBaiYou/groupjson"
+
+	target {{printf "%q" .Pkg}}
+)
+
+func main() {
+	enc := groupjson.NewEncoder(){{if .TagKey}}.WithTagKey({{printf "%q" .TagKey}}){{end}}
+	var views []groupjson.View
+{{- range .Groups}}
+	views = append(views, groupjson.View{Name: {{printf "%q" .}}, Groups: []string{ {{- printf "%q" . -}} }})
+{{- end}}
+	b, err := enc.{{.Func}}([]any{ {{- range .Types}}target.{{.}}{}, {{end -}} }, views...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+{{- if eq .Cmd "gen-ts"}}
+	b = append([]byte("// Code generated by groupjson gen-ts. DO NOT EDIT.\n\n"), b...)
+{{- else}}
+	b = append(b, '\n')
+{{- end}}
+{{- if .Out}}
+	if err := os.WriteFile({{printf "%q" .Out}}, b, 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+{{- else}}
+	os.Stdout.Write(b)
+{{- end}}
+}
+`))
+
+// viewFuncs 各视图类子命令调用的 Encoder 方法。
+var viewFuncs = map[string]string{
+	"gen-openapi": "OpenAPIComponents",
+	"gen-ts":      "TypeScript",
+}
+
+// genView 执行 gen-openapi 或 gen-ts：为每个（类型, 分组）视图生成文档或类型定义。
+func genView(cmd string, args []string) error {
+	fs := flag.NewFlagSet(cmd, flag.ExitOnError)
+	cfg := genConfig{Cmd: cmd, Func: viewFuncs[cmd]}
+	var types, groups string
+	fs.StringVar(&cfg.Pkg, "pkg", "", "import path of the package declaring the types (required)")
+	fs.StringVar(&types, "types", "", "comma-separated type names (required)")
+	fs.StringVar(&groups, "groups", "", "comma-separated groups, one view each (default: all groups found in tags)")
+	fs.StringVar(&cfg.Out, "out", "", "output file (default: stdout)")
+	fs.StringVar(&cfg.TagKey, "tag", "", "group tag key (default \"groups\")")
+	fs.Parse(args)
+
+	cfg.Types = splitList(types)
+	cfg.Groups = splitList(groups)
+	if cfg.Pkg == "" || len(cfg.Types) == 0 {
+		fs.Usage()
+		return fmt.Errorf("%s: -pkg and -types are required", cmd)
+	}
+	return runDriver(viewTmpl, cfg)
+}
+
+// groupsTmpl gen-groups 的临时驱动程序模板。
+var groupsTmpl = template.Must(template.New("groups").Parse(`// Code generated by groupjson gen-groups. DO NOT EDIT.
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/JieBaiYou/groupjson"
+
+	target {{printf "%q" .Pkg}}
+)
+
+func main() {
+	enc := groupjson.NewEncoder(){{if .TagKey}}.WithTagKey({{printf "%q" .TagKey}}){{end}}
+	b, err := enc.GroupConstants({{printf "%q" .Package}}, {{range .Types}}target.{{.}}{}, {{end}})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+{{- if .Out}}
+	if err := os.WriteFile({{printf "%q" .Out}}, b, 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+{{- else}}
+	os.Stdout.Write(b)
+{{- end}}
+}
+`))
+
+// genGroups 执行 gen-groups：扫描类型标签中的分组并生成常量文件。
+func genGroups(args []string) error {
+	fs := flag.NewFlagSet("gen-groups", flag.ExitOnError)
+	var cfg genConfig
+	var types string
+	fs.StringVar(&cfg.Pkg, "pkg", "", "import path of the package declaring the types (required)")
+	fs.StringVar(&types, "types", "", "comma-separated type names (required)")
+	fs.StringVar(&cfg.Package, "package", "", "package name of the generated file (default: last element of -pkg)")
+	fs.StringVar(&cfg.Out, "out", "", "output file (default: stdout)")
+	fs.StringVar(&cfg.TagKey, "tag", "", "group tag key (default \"groups\")")
+	fs.Parse(args)
+
+	cfg.Types = splitList(types)
+	if cfg.Pkg == "" || len(cfg.Types) == 0 {
+		fs.Usage()
+		return fmt.Errorf("gen-groups: -pkg and -types are required")
+	}
+	if cfg.Package == "" {
+		cfg.Package = path.Base(cfg.Pkg)
+	}
+	return runDriver(groupsTmpl, cfg)
+}
+
+// genEncoders 执行 gen-encoders：为包中的结构体生成免反射的分组编码方法，每个包写出一个 <包名>_groupjson.go。
+func genEncoders(args []string) error {
+	fs := flag.NewFlagSet("gen-encoders", flag.ExitOnError)
+	var types, insts, out string
+	fs.StringVar(&types, "type", "", "comma-separated type names (default: all exported structs)")
+	fs.StringVar(&insts, "instantiate", "", "comma-separated generic instances to specialize, e.g. Page[User],Pair[int,string]")
+	fs.StringVar(&out, "out", "", "output file when a single package is generated (default: <package>_groupjson.go in the package directory)")
+	check := fs.Bool("check", false, "do not write files; print a diff and fail if the generated files are out of date")
+	fs.Parse(args)
+
+	g := codegen.Generator{Types: splitList(types), Instantiate: splitTypeList(insts)}
+	if err := g.Load(fs.Args()...); err != nil {
+		return err
+	}
+	files, err := g.Generate()
+	if err != nil {
+		return err
+	}
+	if out != "" {
+		if len(files) != 1 {
+			return fmt.Errorf("gen-encoders: -out requires exactly one generated package, got %d", len(files))
+		}
+		files[0].Path = out
+	}
+	if *check {
+		diff, err := g.Check(files)
+		if err != nil {
+			return err
+		}
+		if len(diff) > 0 {
+			os.Stdout.Write(diff)
+			return errors.New("gen-encoders: generated files are out of date; run gen-encoders to update them")
+		}
+		return nil
+	}
+	for _, f := range files {
+		if err := os.WriteFile(f.Path, f.Source, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// auditTmpl audit 的临时驱动程序模板，导入各目标包并审计其中的导出结构体类型。
+var auditTmpl = template.Must(template.New("audit").Parse(`// Code generated by groupjson audit. DO NOT EDIT.
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/JieBaiYou/groupjson"
+{{range $i, $p := .Pkgs}}
+	p{{$i}} {{printf "%q" $p.Path}}
+{{- end}}
+)
+
+func main() {
+	enc := groupjson.NewEncoder(){{if .TagKey}}.WithTagKey({{printf "%q" .TagKey}}){{end}}
+	findings := enc.Audit(
+{{- range $i, $p := .Pkgs}}{{range $p.Types}}
+		p{{$i}}.{{.}}{},
+{{- end}}{{end}}
+	)
+	for _, f := range findings {
+		fmt.Println(f)
+	}
+	if len(findings) > 0 {
+		os.Exit(1)
+	}
+}
+`))
+
+// listTmpl list 的临时驱动程序模板，为各目标包中选中的类型打印字段与分组对照表。
+var listTmpl = template.Must(template.New("list").Parse(`// Code generated by groupjson list. DO NOT EDIT.
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/JieBaiYou/groupjson"
+{{range $i, $p := .Pkgs}}
+	p{{$i}} {{printf "%q" $p.Path}}
+{{- end}}
+)
+
+func main() {
+	enc := groupjson.NewEncoder(){{if .TagKey}}.WithTagKey({{printf "%q" .TagKey}}){{end}}
+	err := enc.FieldTable(os.Stdout,
+{{- range $i, $p := .Pkgs}}{{range $p.Types}}
+		p{{$i}}.{{.}}{},
+{{- end}}{{end}}
+	)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "groupjson list:", err)
+		os.Exit(1)
+	}
+}
+`))
+
+// auditPkg 待审计的包及其中的导出结构体类型。
+type auditPkg struct {
+	// Path 导入路径
+	Path string
+	// Types 导出的非泛型结构体类型名
+	Types []string
+}
+
+func audit(args []string) error {
+	fs := flag.NewFlagSet("audit", flag.ExitOnError)
+	tagKey := fs.String("tag", "", "group tag key (default \"groups\")")
+	fs.Parse(args)
+	patterns := fs.Args()
+	if len(patterns) == 0 {
+		patterns = []string{"./..."}
+	}
+
+	pkgs, err := structTypes(patterns)
+	if err != nil {
+		return err
+	}
+	if len(pkgs) == 0 {
+		return nil
+	}
+	return runDriver(auditTmpl, struct {
+		Pkgs   []auditPkg
+		TagKey string
+	}{pkgs, *tagKey})
+}
+
+// list 执行 list：打印包中导出结构体类型的字段与分组对照表，-type 只列出指定的类型。
+func list(args []string) error {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	types := fs.String("type", "", "comma-separated type names (default: all exported structs)")
+	tagKey := fs.String("tag", "", "group tag key (default \"groups\")")
+	fs.Parse(args)
+	patterns := fs.Args()
+	if len(patterns) == 0 {
+		patterns = []string{"./..."}
+	}
+
+	pkgs, err := structTypes(patterns)
+	if err != nil {
+		return err
+	}
+	if names := splitList(*types); len(names) > 0 {
+		found := map[string]bool{}
+		var selected []auditPkg
+		for _, p := range pkgs {
+			var keep []string
+			for _, t := range p.Types {
+				if slices.Contains(names, t) {
+					keep, found[t] = append(keep, t), true
+				}
+			}
+			if len(keep) > 0 {
+				selected = append(selected, auditPkg{Path: p.Path, Types: keep})
+			}
+		}
+		for _, n := range names {
+			if !found[n] {
+				return fmt.Errorf("list: type %s not found", n)
+			}
+		}
+		pkgs = selected
+	}
+	if len(pkgs) == 0 {
+		return nil
+	}
+	return runDriver(listTmpl, struct {
+		Pkgs   []auditPkg
+		TagKey string
+	}{pkgs, *tagKey})
+}
+
+// structTypes 通过 go list 解析包模式，并从源码中找出各包导出的非泛型结构体类型（跳过 main 包）。
+func structTypes(patterns []string) ([]auditPkg, error) {
+	cmd := exec.Command("go", append([]string{"list", "-f", "{{.Name}}\t{{.ImportPath}}\t{{.Dir}}\t{{join .GoFiles \",\"}}"}, patterns...)...)
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+	var pkgs []auditPkg
+	fset := token.NewFileSet()
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) != 4 || fields[0] == "main" || fields[3] == "" {
+			continue
+		}
+		p := auditPkg{Path: fields[1]}
+		for _, name := range strings.Split(fields[3], ",") {
+			file, err := parser.ParseFile(fset, filepath.Join(fields[2], name), nil, parser.SkipObjectResolution)
+			if err != nil {
+				return nil, err
+			}
+			for _, decl := range file.Decls {
+				gd, ok := decl.(*ast.GenDecl)
+				if !ok || gd.Tok != token.TYPE {
+					continue
+				}
+				for _, spec := range gd.Specs {
+					ts := spec.(*ast.TypeSpec)
+					if _, ok := ts.Type.(*ast.StructType); ok && ts.Name.IsExported() && ts.TypeParams == nil {
+						p.Types = append(p.Types, ts.Name.Name)
+					}
+				}
+			}
+		}
+		if len(p.Types) > 0 {
+			pkgs = append(pkgs, p)
+		}
+	}
+	return pkgs, nil
+}
+
+// runDriver 在当前模块内生成临时驱动程序并通过 go run 执行，结束后删除。
+// 驱动程序必须位于当前模块内，才能按模块依赖解析目标包。
+func runDriver(tmpl *template.Template, data any) error {
+	dir, err := os.MkdirTemp(".", ".groupjson-gen-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	f, err := os.Create(dir + "/main.go")
+	if err != nil {
+		return err
+	}
+	if err := tmpl.Execute(f, data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	cmd := exec.Command("go", "run", "./"+dir)
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	return cmd.Run()
+}
+
+// splitList 拆分逗号分隔列表，去除空白与空项。
+func splitList(s string) []string {
+	var out []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// splitTypeList 同 splitList，但方括号内的逗号（如 Pair[int,string]）不作分隔。
+func splitTypeList(s string) []string {
+	var out []string
+	depth, start := 0, 0
+	for i := 0; i <= len(s); i++ {
+		switch {
+		case i < len(s) && s[i] == '[':
+			depth++
+		case i < len(s) && s[i] == ']':
+			depth--
+		case i == len(s) || s[i] == ',' && depth == 0:
+			if p := strings.TrimSpace(s[start:i]); p != "" {
+				out = append(out, p)
+			}
+			start = i + 1
+		}
+	}
+	return out
+}
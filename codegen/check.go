@@ -0,0 +1,177 @@
+package codegen
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// diffContext diff 中改动前后保留的上下文行数。
+const diffContext = 3
+
+// Check 把 Generate 的结果 files 与磁盘上的文件比较，返回过时文件的统一格式 diff，全部一致时返回空。
+// 过时包括内容不同、尚未生成，以及包中已没有待生成的类型却仍留有生成文件（应删除）。
+// 供 CI 检查提交的生成代码是否与类型定义同步。
+func (g *Generator) Check(files []File) ([]byte, error) {
+	var out bytes.Buffer
+	done := map[string]bool{}
+	for _, f := range files {
+		done[f.pkgPath] = true
+		old, err := readGenerated(f.Path, false)
+		if err != nil {
+			return nil, err
+		}
+		out.Write(unifiedDiff(f.Path, old, f.Source))
+	}
+	for _, p := range g.pkgs {
+		if done[p.PkgPath] {
+			continue
+		}
+		path := outputPath(p)
+		old, err := readGenerated(path, true)
+		if err != nil {
+			return nil, err
+		}
+		out.Write(unifiedDiff(path, old, nil))
+	}
+	return out.Bytes(), nil
+}
+
+// readGenerated 读取 path，文件不存在时返回空；headerOnly 时只返回以生成文件首行开头的内容，
+// 避免把用户编写的同名文件当作遗留的生成文件。
+func readGenerated(path string, headerOnly bool) ([]byte, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("codegen: %w", err)
+	}
+	if headerOnly && !bytes.HasPrefix(b, []byte(header)) {
+		return nil, nil
+	}
+	return b, nil
+}
+
+// diffOp diff 中的一行，kind 为 ' '（不变）、'-'（删除）或 '+'（新增）。
+type diffOp struct {
+	kind byte
+	line string
+}
+
+// unifiedDiff 返回从 old 到 new 的统一格式 diff，内容相同时返回 nil。
+func unifiedDiff(path string, old, new []byte) []byte {
+	if bytes.Equal(old, new) {
+		return nil
+	}
+	ops := diffLines(splitLines(string(old)), splitLines(string(new)))
+	// an[k]、bn[k] 为 ops[:k] 中旧、新文件的行数
+	an, bn := make([]int, len(ops)+1), make([]int, len(ops)+1)
+	for k, op := range ops {
+		an[k+1], bn[k+1] = an[k], bn[k]
+		if op.kind != '+' {
+			an[k+1]++
+		}
+		if op.kind != '-' {
+			bn[k+1]++
+		}
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "--- %s (committed)\n+++ %s (generated)\n", path, path)
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+		// 间隔不超过两倍上下文的改动合并为一个 hunk
+		end := i
+		for j := i; j < len(ops); j++ {
+			if ops[j].kind != ' ' {
+				end = j + 1
+			} else if j-end >= 2*diffContext {
+				break
+			}
+		}
+		start, stop := max(i-diffContext, 0), min(end+diffContext, len(ops))
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(an[start], an[stop]), hunkRange(bn[start], bn[stop]))
+		for _, op := range ops[start:stop] {
+			out.WriteByte(op.kind)
+			out.WriteString(op.line)
+			if !strings.HasSuffix(op.line, "\n") {
+				out.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		i = stop
+	}
+	return out.Bytes()
+}
+
+// hunkRange 返回 hunk 头中的行范围，from、to 为范围前后的行数；范围为空时起始行为其前一行。
+func hunkRange(from, to int) string {
+	if from == to {
+		return fmt.Sprintf("%d,0", from)
+	}
+	return fmt.Sprintf("%d,%d", from+1, to-from)
+}
+
+// splitLines 按行拆分 s，每行保留换行符。
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines 以最长公共子序列计算从 a 到 b 的逐行改动。生成文件的改动通常集中在局部，
+// 先去掉相同的首尾部分，只对中间部分做动态规划。
+func diffLines(a, b []string) []diffOp {
+	var prefix, suffix int
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	ops := make([]diffOp, 0, len(a)+len(b))
+	for _, l := range a[:prefix] {
+		ops = append(ops, diffOp{' ', l})
+	}
+
+	ma, mb := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	// lcs[i][j] 为 ma[i:] 与 mb[j:] 的最长公共子序列长度
+	w := len(mb) + 1
+	lcs := make([]int32, (len(ma)+1)*w)
+	for i := len(ma) - 1; i >= 0; i-- {
+		for j := len(mb) - 1; j >= 0; j-- {
+			if ma[i] == mb[j] {
+				lcs[i*w+j] = lcs[(i+1)*w+j+1] + 1
+			} else {
+				lcs[i*w+j] = max(lcs[(i+1)*w+j], lcs[i*w+j+1])
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(ma) || j < len(mb) {
+		switch {
+		case i < len(ma) && j < len(mb) && ma[i] == mb[j]:
+			ops = append(ops, diffOp{' ', ma[i]})
+			i, j = i+1, j+1
+		case i < len(ma) && (j == len(mb) || lcs[(i+1)*w+j] >= lcs[i*w+j+1]):
+			ops = append(ops, diffOp{'-', ma[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', mb[j]})
+			j++
+		}
+	}
+
+	for _, l := range a[len(a)-suffix:] {
+		ops = append(ops, diffOp{' ', l})
+	}
+	return ops
+}
@@ -0,0 +1,1042 @@
+// Package codegen 为结构体类型生成免反射的分组编码代码，cmd/groupjson 的 gen-encoders 子命令基于此实现。
+//
+// 对每个类型 T 生成两个方法：
+//
+//	func (v *T) AppendGroupJSON(dst []byte, groups []string, mode groupjson.GroupMode) ([]byte, error)
+//	func (v *T) MarshalWithGroups(groups ...string) ([]byte, error)
+//
+// 类型通过 go/packages 按包加载，结构体可以分布在多个文件中，匿名嵌入与 ,inline 字段（包括其他包的类型）
+// 在生成时展开。分组判断展开为按分组名的 switch 与位运算；基本类型（包括没有自定义序列化方法的命名类型）
+// 及其指针、切片、数组与字符串键 map 直接写出，omitempty、omitzero 与 ,string 在生成时确定。
+// 字段引用的本包结构体（包括未在 Types 中列出的、未导出的）递归生成并直接调用；
+// 其余值（其他包的结构体、接口、自定义序列化的类型等）交给 groupjson.AppendJSON。
+// 泛型结构体生成泛型方法，类型形参的值在运行时按实参调用生成代码或交给 groupjson.AppendJSON；
+// Generator.Instantiate 列出的实例另外生成专用函数，由泛型方法按类型分派。
+// 输出与反射编码器的默认配置逐字节一致，嵌套层数同样以 groupjson.DefaultMaxDepth 为上限，
+// 循环引用因此以 groupjson.ErrMaxDepth 结束（反射编码器报告 ErrCircularReference）。
+// AppendGroupJSON 实现 groupjson.GroupEncoderAppender，反射编码器遇到这些类型时也会自动调用。
+package codegen
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/tools/go/packages"
+
+	"github.com/JieBaiYou/groupjson"
+)
+
+// Generator 加载 Go 包并为其中的结构体类型生成编码方法。
+type Generator struct {
+	// Dir 解析包模式时的工作目录，为空时使用当前目录
+	Dir string
+	// Types 需要生成的类型名，为空时生成每个包中全部导出的结构体；泛型结构体生成泛型方法
+	Types []string
+	// Instantiate 额外生成专用代码的泛型实例，如 Page[User]，类型实参须为本包或预声明的类型
+	Instantiate []string
+
+	// pkgs Load 加载的包
+	pkgs []*packages.Package
+}
+
+// File 一个包的生成结果。
+type File struct {
+	// Path 输出路径，为包目录下的 <包名>_groupjson.go
+	Path string
+	// Source 生成的源码（已 gofmt）
+	Source []byte
+
+	// pkgPath 所属包的导入路径
+	pkgPath string
+}
+
+// Generate 为已加载的每个包生成一个文件，没有待生成类型的包不产生文件。类型使用了生成代码无法
+// 保持一致的特性（如脱敏、条件字段或自定义序列化方法）或 Types 中的类型未找到时返回错误。
+func (g *Generator) Generate() ([]File, error) {
+	if len(g.pkgs) == 0 {
+		return nil, errors.New("codegen: no packages loaded")
+	}
+	found := map[string]bool{}
+	var files []File
+	for _, p := range g.pkgs {
+		names := g.selectTypes(p)
+		insts, err := g.instances(p, found)
+		if err != nil {
+			return nil, err
+		}
+		if len(names) == 0 && len(insts) == 0 {
+			continue
+		}
+		src, err := generatePackage(p, names, insts)
+		if err != nil {
+			return nil, err
+		}
+		for _, n := range names {
+			found[n.Obj().Name()] = true
+		}
+		files = append(files, File{Path: outputPath(p), Source: src, pkgPath: p.PkgPath})
+	}
+	for _, name := range append(slices.Clip(g.Types), g.Instantiate...) {
+		if !found[name] {
+			return nil, fmt.Errorf("codegen: struct type %s not found", name)
+		}
+	}
+	return files, nil
+}
+
+// selectTypes 按 Types 选出包中要生成的结构体，Types 为空时取全部导出的结构体。
+func (g *Generator) selectTypes(p *packages.Package) []*types.Named {
+	var out []*types.Named
+	for _, tn := range packageStructs(p) {
+		if len(g.Types) == 0 && tn.Exported() || slices.Contains(g.Types, tn.Name()) {
+			out = append(out, tn.Type().(*types.Named))
+		}
+	}
+	return out
+}
+
+// instances 解析 Instantiate 中泛型类型声明在包 p 中的实例，解析成功的表达式记入 found。
+func (g *Generator) instances(p *packages.Package, found map[string]bool) ([]*types.Named, error) {
+	var out []*types.Named
+	for _, expr := range g.Instantiate {
+		x, err := parser.ParseExpr(expr)
+		if err != nil {
+			return nil, fmt.Errorf("codegen: -instantiate %s: %w", expr, err)
+		}
+		var base ast.Expr
+		switch x := x.(type) {
+		case *ast.IndexExpr:
+			base = x.X
+		case *ast.IndexListExpr:
+			base = x.X
+		}
+		id, ok := base.(*ast.Ident)
+		if !ok {
+			return nil, fmt.Errorf("codegen: -instantiate %s: want a generic type instance such as Page[User]", expr)
+		}
+		if tn, ok := p.Types.Scope().Lookup(id.Name).(*types.TypeName); !ok || !isStruct(tn.Type()) {
+			continue
+		}
+		tv, err := types.Eval(p.Fset, p.Types, token.NoPos, expr)
+		if err != nil {
+			return nil, fmt.Errorf("codegen: -instantiate %s: %w", expr, err)
+		}
+		n, ok := tv.Type.(*types.Named)
+		if !ok || n.TypeArgs().Len() == 0 {
+			return nil, fmt.Errorf("codegen: -instantiate %s: not a generic type instance", expr)
+		}
+		found[expr] = true
+		out = append(out, n)
+	}
+	return out, nil
+}
+
+// isStruct 判断 t 的底层类型是否为结构体。
+func isStruct(t types.Type) bool {
+	_, ok := t.Underlying().(*types.Struct)
+	return ok
+}
+
+// generatePackage 生成包 p 中类型 roots 的编码方法与泛型实例 insts 的专用代码，并递归生成它们的字段
+// 引用的本包结构体，使嵌套的结构体（包括经由指针、切片、数组与 map）直接调用生成代码。
+// 引用的类型无法生成时交给运行时，只有 roots、insts 及实例的泛型类型报告错误。
+func generatePackage(p *packages.Package, roots, insts []*types.Named) ([]byte, error) {
+	// 实例的专用代码由泛型类型的 groupjsonAppend 分派，泛型类型本身同样须要生成
+	for _, n := range insts {
+		if !slices.Contains(roots, n.Origin()) {
+			roots = append(roots, n.Origin())
+		}
+	}
+	queue := slices.Concat(roots, insts)
+	required := len(queue)
+	queued := map[*types.Named]bool{}
+	for _, n := range roots {
+		queued[n] = true
+	}
+	// generated 生成了方法的类型（泛型类型为其声明） -> 定义
+	generated := map[*types.Named]*structDef{}
+	var instDefs []*structDef
+	for i := 0; i < len(queue); i++ {
+		def, err := newStructDef(queue[i])
+		if err != nil {
+			if i >= required {
+				continue
+			}
+			if terr := typeError(p); terr != nil && errors.Is(err, errInvalidType) {
+				return nil, fmt.Errorf("%w: %v", err, terr)
+			}
+			return nil, err
+		}
+		if queue[i].Origin() == queue[i] {
+			generated[queue[i]] = def
+		} else {
+			instDefs = append(instDefs, def)
+		}
+		for _, nested := range def.nested() {
+			if !queued[nested] {
+				queued[nested] = true
+				queue = append(queue, nested)
+			}
+		}
+	}
+	used := map[string]bool{}
+	for _, def := range instDefs {
+		def.fn = instanceFunc(def.typ, used)
+		origin := generated[def.named.Origin()]
+		origin.insts = append(origin.insts, def)
+	}
+	return emitPackage(p, generated)
+}
+
+// instanceFunc 返回泛型实例 typ（如 Page[User]）的专用编码函数名，used 记录已用的名称。
+func instanceFunc(typ string, used map[string]bool) string {
+	name := "groupjsonAppend" + strings.Map(func(r rune) rune {
+		if r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, typ)
+	for i, base := 2, name; used[name]; i++ {
+		name = base + strconv.Itoa(i)
+	}
+	used[name] = true
+	return name
+}
+
+// emitPackage 按类型在源码中的声明顺序写出 generated 的编码代码。
+func emitPackage(p *packages.Package, generated map[*types.Named]*structDef) ([]byte, error) {
+	var defs []*structDef
+	for _, tn := range packageStructs(p) {
+		if def := generated[tn.Type().(*types.Named)]; def != nil {
+			defs = append(defs, def)
+		}
+	}
+	var body bytes.Buffer
+	w := &emitter{buf: &body, generated: generated}
+	for _, def := range defs {
+		w.structType(def)
+	}
+
+	var out bytes.Buffer
+	out.WriteString(header + "\n")
+	fmt.Fprintf(&out, "package %s\n\nimport (\n", p.Name)
+	if w.usesStrconv {
+		out.WriteString("\t\"strconv\"\n")
+	}
+	out.WriteString("\n\t\"github.com/JieBaiYou/groupjson\"\n)\n")
+	out.Write(body.Bytes())
+	if w.usesCovered {
+		out.WriteString(coveredFunc)
+	}
+	if w.usesAny {
+		out.WriteString(appendAnyFunc)
+	}
+	return format.Source(out.Bytes())
+}
+
+// errInvalidType 字段类型无法解析（包中存在类型错误）。
+var errInvalidType = errors.New("invalid field type")
+
+// customMethods 会让反射编码器改变输出的方法，生成代码无法与之保持一致。
+var customMethods = []string{"MarshalJSONGroups", "MarshalJSON", "MarshalText"}
+
+// structDef 一个待生成的结构体类型或泛型实例。
+type structDef struct {
+	name  string
+	named *types.Named
+	// typ 类型表达式：泛型类型带类型形参（如 Page[T]），实例带类型实参（如 Page[User]）
+	typ string
+	// fn 实例的专用编码函数名，非实例为空
+	fn string
+	// insts 泛型类型的实例中生成了专用代码的部分
+	insts []*structDef
+	// groups 位序号 -> 分组名
+	groups []string
+	// fields 按输出顺序排列的字段
+	fields []fieldDef
+}
+
+// nested 返回字段（包括其元素）引用的本包结构体类型。
+func (def *structDef) nested() []*types.Named {
+	var out []*types.Named
+	for _, f := range def.fields {
+		for s := f.shape; s != nil; s = s.elem {
+			if s.kind == shapeStruct {
+				out = append(out, s.named.Origin())
+			}
+		}
+	}
+	return out
+}
+
+// fieldDef 一个输出字段。
+type fieldDef struct {
+	// x 字段的选择器表达式，如 v.Base.ID
+	x string
+	// guards 经由的嵌入指针非 nil 的条件，任一不成立时字段不输出
+	guards []string
+	// key JSON 编码后的键名，含引号
+	key string
+	// shape 字段类型的形态
+	shape     *shape
+	omitEmpty bool
+	omitZero  bool
+	asString  bool
+	// never 分组标签含 "-"：不输出，但仍占用键名
+	never bool
+	// groups 分组标签中的分组项
+	groups []string
+	// entries 各分组项的位集合，不含空项
+	entries []uint64
+	// order order 标签的取值，hasOrder 为 false 时未设置
+	order    int
+	hasOrder bool
+}
+
+// newStructDef 按反射编码器（buildSchema）的规则解析结构体 n 的字段：广度优先展开匿名嵌入与 ,inline 字段
+// （包括其他包的类型），同名键保留先出现的较浅字段。n 为泛型实例时字段取实例化后的类型。
+func newStructDef(n *types.Named) (*structDef, error) {
+	def := &structDef{name: n.Obj().Name(), named: n, typ: typeExpr(n)}
+	if m := methodOf(n, customMethods...); m != "" {
+		return nil, fmt.Errorf("codegen: %s implements %s; generated code would bypass it", def.name, m)
+	}
+	type queueItem struct {
+		st *types.Struct
+		// x 该层结构体的选择器表达式
+		x      string
+		guards []string
+		// prefix 提升字段的键名前缀，来自 ,inline=prefix
+		prefix string
+	}
+	q := []queueItem{{st: n.Underlying().(*types.Struct), x: "v"}}
+	seen := map[string]bool{}
+	for len(q) > 0 {
+		it := q[0]
+		q = q[1:]
+		for i := 0; i < it.st.NumFields(); i++ {
+			sf := it.st.Field(i)
+			tag := reflect.StructTag(it.st.Tag(i))
+			if !sf.Exported() || tag.Get("json") == "-" || isLock(sf.Type()) {
+				continue
+			}
+			if !validType(sf.Type()) {
+				return nil, fmt.Errorf("codegen: %s.%s: %w", def.name, sf.Name(), errInvalidType)
+			}
+			name, opts, _ := strings.Cut(tag.Get("json"), ",")
+			inline, prefix := false, ""
+			for _, o := range strings.Split(opts, ",") {
+				if o == "inline" {
+					inline = true
+				}
+				if rest, ok := strings.CutPrefix(o, "inline="); ok {
+					inline, prefix = true, rest
+				}
+			}
+			x := it.x + "." + sf.Name()
+			if st, ptr := structOf(sf.Type()); st != nil && ((sf.Embedded() && name == "") || inline) {
+				guards := it.guards
+				if ptr {
+					guards = append(slices.Clip(guards), x+" != nil")
+				}
+				q = append(q, queueItem{st: st, x: x, guards: guards, prefix: it.prefix + prefix})
+				continue
+			}
+			f, err := newFieldDef(sf, tag, it.prefix, n.Obj().Pkg())
+			if err != nil {
+				return nil, fmt.Errorf("codegen: %s.%s: %w", def.name, sf.Name(), err)
+			}
+			// 同名键保留先出现的字段，分组为 "-" 的字段同样占用键名
+			if seen[f.key] {
+				continue
+			}
+			seen[f.key] = true
+			if !f.never {
+				f.x, f.guards = x, it.guards
+				def.fields = append(def.fields, f)
+			}
+		}
+	}
+	if err := def.assignBits(); err != nil {
+		return nil, err
+	}
+	// 与 sortByOrderTag 一致：带 order 标签的字段按取值在前，其余保持原顺序
+	slices.SortStableFunc(def.fields, func(a, b fieldDef) int {
+		switch {
+		case a.hasOrder && b.hasOrder:
+			return a.order - b.order
+		case a.hasOrder:
+			return -1
+		case b.hasOrder:
+			return 1
+		}
+		return 0
+	})
+	return def, nil
+}
+
+// typeExpr 返回 n 在其所在包中的类型表达式，泛型类型的类型形参按声明写出（如 Page[T]）。
+func typeExpr(n *types.Named) string {
+	if n.TypeParams().Len() == 0 || n.TypeArgs().Len() > 0 {
+		return types.TypeString(n, types.RelativeTo(n.Obj().Pkg()))
+	}
+	params := make([]string, n.TypeParams().Len())
+	for i := range params {
+		params[i] = n.TypeParams().At(i).Obj().Name()
+	}
+	return n.Obj().Name() + "[" + strings.Join(params, ", ") + "]"
+}
+
+// assignBits 按首次出现的顺序为输出字段的分组分配位，并计算各字段分组项的位集合。
+func (def *structDef) assignBits() error {
+	bits := map[string]int{}
+	for i := range def.fields {
+		f := &def.fields[i]
+		for _, entry := range f.groups {
+			var m uint64
+			for _, g := range strings.Split(entry, "+") {
+				if g == "" {
+					continue
+				}
+				id, found := bits[g]
+				if !found {
+					id = len(def.groups)
+					if id == 64 {
+						return fmt.Errorf("codegen: %s: more than 64 groups", def.name)
+					}
+					bits[g] = id
+					def.groups = append(def.groups, g)
+				}
+				m |= 1 << id
+			}
+			if m != 0 {
+				f.entries = append(f.entries, m)
+			}
+		}
+	}
+	return nil
+}
+
+// newFieldDef 解析单个字段的标签，prefix 为 ,inline=prefix 累加的键名前缀，pkg 为生成代码所在的包。
+func newFieldDef(sf *types.Var, tag reflect.StructTag, prefix string, pkg *types.Package) (f fieldDef, err error) {
+	parts := strings.Split(tag.Get("json"), ",")
+	key := sf.Name()
+	if parts[0] != "" {
+		key = parts[0]
+	}
+	for _, p := range parts[1:] {
+		switch p {
+		case "omitempty":
+			f.omitEmpty = true
+		case "omitzero":
+			f.omitZero = true
+		case "string":
+			f.asString = true
+		}
+	}
+
+	groupTag, mods, _ := strings.Cut(tag.Get(groupjson.DefaultTagKey), ";")
+	for _, mod := range strings.Split(mods, ";") {
+		k, _, _ := strings.Cut(strings.TrimSpace(mod), "=")
+		if k == "mask" || k == "unmask" || k == "if" {
+			return f, fmt.Errorf("%s modifier is not supported", k)
+		}
+	}
+	if _, ok := tag.Lookup(groupjson.NullAsTagKey); ok {
+		return f, errors.New("nullas tag is not supported")
+	}
+	if n, err := strconv.Atoi(tag.Get(groupjson.DepthTagKey)); err == nil && n > 0 {
+		return f, errors.New("gjdepth tag is not supported")
+	}
+	if n, err := strconv.Atoi(tag.Get(groupjson.PrecTagKey)); err == nil && n >= 0 {
+		return f, errors.New("gjprec tag is not supported")
+	}
+	if s, ok := tag.Lookup(groupjson.OrderTagKey); ok {
+		if n, err := strconv.Atoi(s); err == nil {
+			f.order, f.hasOrder = n, true
+		}
+	}
+
+	kb, _ := json.Marshal(prefix + key)
+	f.key = string(kb)
+	f.groups = strings.Split(groupTag, ",")
+	if slices.Contains(f.groups, groupjson.NeverGroup) {
+		f.never = true
+		return f, nil
+	}
+	f.shape = shapeOf(sf.Type(), pkg)
+	if f.asString {
+		s := f.shape
+		if s.kind == shapePointer {
+			s = s.elem
+		}
+		if s.kind == shapeOther || s.kind == shapeStruct || s.kind == shapeTypeParam {
+			return f, errors.New(",string on a type with custom encoding or a type parameter is not supported")
+		}
+	}
+	return f, nil
+}
+
+// structOf 返回 t 或 *t 的结构体定义，ptr 表示 t 为指针；t 不是（指向）结构体时返回 nil。
+func structOf(t types.Type) (st *types.Struct, ptr bool) {
+	if p, ok := t.Underlying().(*types.Pointer); ok {
+		t, ptr = p.Elem(), true
+	}
+	st, _ = t.Underlying().(*types.Struct)
+	return st, ptr
+}
+
+// validType 判断 t 及其元素类型是否都已解析。
+func validType(t types.Type) bool {
+	switch u := types.Unalias(t).(type) {
+	case *types.Basic:
+		return u.Kind() != types.Invalid
+	case *types.Pointer:
+		return validType(u.Elem())
+	case *types.Slice:
+		return validType(u.Elem())
+	case *types.Array:
+		return validType(u.Elem())
+	case *types.Map:
+		return validType(u.Key()) && validType(u.Elem())
+	}
+	return true
+}
+
+// methodOf 返回 *t 的方法集中 names 里第一个存在的方法名，都不存在时返回空串。
+func methodOf(t types.Type, names ...string) string {
+	ms := types.NewMethodSet(types.NewPointer(t))
+	for _, name := range names {
+		if ms.Lookup(nil, name) != nil {
+			return name
+		}
+	}
+	return ""
+}
+
+// isLock 与反射编码器的 isLockType 一致：判断 t 是否为锁（*t 实现 sync.Locker）或
+// 只含未导出字段且其中有锁的类型（如 sync.WaitGroup、atomic.Int64），反射编码器跳过此类字段。
+func isLock(t types.Type) bool {
+	for {
+		p, ok := t.Underlying().(*types.Pointer)
+		if !ok {
+			break
+		}
+		t = p.Elem()
+	}
+	st, ok := t.Underlying().(*types.Struct)
+	if !ok || isNamed(t, "sync", "Map") {
+		return false
+	}
+	if methodOf(t, "Lock") != "" && methodOf(t, "Unlock") != "" {
+		return true
+	}
+	hasLock := false
+	for i := 0; i < st.NumFields(); i++ {
+		f := st.Field(i)
+		if f.Exported() {
+			return false
+		}
+		if _, ptr := f.Type().Underlying().(*types.Pointer); !ptr && isLock(f.Type()) {
+			hasLock = true
+		}
+	}
+	return hasLock
+}
+
+// isNamed 判断 t 是否为包 pkg 中名为 name 的类型。
+func isNamed(t types.Type, pkg, name string) bool {
+	n, ok := types.Unalias(t).(*types.Named)
+	return ok && n.Obj().Pkg() != nil && n.Obj().Pkg().Path() == pkg && n.Obj().Name() == name
+}
+
+// shapeKind 生成代码能直接写出的类型形态。
+type shapeKind int
+
+const (
+	// shapeOther 交给 groupjson.AppendJSON
+	shapeOther shapeKind = iota
+	shapeString
+	shapeBool
+	shapeInt
+	shapeUint
+	shapeFloat32
+	shapeFloat64
+	shapeInterface
+	shapePointer
+	shapeSlice
+	shapeArray
+	shapeMap
+	// shapeStruct 本包的结构体（包括泛型实例），调用其生成代码
+	shapeStruct
+	// shapeTypeParam 类型形参，运行时按实参类型调用生成代码或交给 groupjson.AppendJSON
+	shapeTypeParam
+)
+
+// shape 字段类型的形态。
+type shape struct {
+	kind shapeKind
+	// elem 指针、切片、数组或 map 的元素
+	elem *shape
+	// name 未命名的基本类型的类型名（如 int64），命名类型为空，写出时需要转换
+	name string
+	// keyName map 键的类型名，含义同 name
+	keyName string
+	// named shapeStruct 的类型
+	named *types.Named
+	// zeroer 类型声明了 IsZero 方法，omitzero 交给运行时判断
+	zeroer bool
+	// never omitempty 永不成立（结构体）
+	neverEmpty bool
+}
+
+// fieldMethods 字段类型声明了这些方法时由运行时编码：自定义序列化，或已有（生成的）分组编码方法。
+var fieldMethods = append(slices.Clip(customMethods), "AppendGroupJSON")
+
+// shapeOf 按类型信息判断形态：基本类型（包括没有自定义序列化方法的命名类型，如 type Status string）
+// 与由它们组成的指针、切片、数组、字符串键 map 可以展开，包 pkg 中的结构体调用其生成代码，
+// 类型形参在运行时按实参分派，其余（其他包的结构体、接口、自定义序列化的类型等）交给运行时。
+func shapeOf(t types.Type, pkg *types.Package) *shape {
+	return shapeOfType(t, pkg, map[*types.Named]bool{})
+}
+
+func shapeOfType(t types.Type, pkg *types.Package, visiting map[*types.Named]bool) *shape {
+	t = types.Unalias(t)
+	if _, ok := t.(*types.TypeParam); ok {
+		return &shape{kind: shapeTypeParam}
+	}
+	s := &shape{}
+	if n, ok := t.(*types.Named); ok {
+		if _, iface := n.Underlying().(*types.Interface); !iface {
+			if visiting[n] || methodOf(n, fieldMethods...) != "" || (n.Obj().Pkg() != nil && n.Obj().Pkg().Path() == groupjsonPath) {
+				// 自定义编码、递归类型（如 type T []T）或 groupjson 自身的类型（如按键分组的 map）
+				_, st := n.Underlying().(*types.Struct)
+				return &shape{kind: shapeOther, neverEmpty: st}
+			}
+			visiting[n] = true
+			defer delete(visiting, n)
+		}
+		s.zeroer = methodOf(n, "IsZero") != ""
+		if _, st := n.Underlying().(*types.Struct); st && n.Obj().Pkg() == pkg {
+			s.kind, s.named, s.neverEmpty = shapeStruct, n, true
+			return s
+		}
+	}
+	if b, ok := t.(*types.Basic); ok {
+		s.name = b.Name()
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		info := u.Info()
+		switch {
+		case info&types.IsString != 0:
+			s.kind = shapeString
+		case info&types.IsBoolean != 0:
+			s.kind = shapeBool
+		case info&types.IsInteger != 0 && info&types.IsUnsigned != 0:
+			s.kind = shapeUint
+		case info&types.IsInteger != 0:
+			s.kind = shapeInt
+		case u.Kind() == types.Float32:
+			s.kind = shapeFloat32
+		case u.Kind() == types.Float64:
+			s.kind = shapeFloat64
+		}
+	case *types.Interface:
+		s.kind = shapeInterface
+	case *types.Pointer:
+		s.kind, s.elem = shapePointer, shapeOfType(u.Elem(), pkg, visiting)
+	case *types.Slice:
+		if b, ok := u.Elem().Underlying().(*types.Basic); ok && b.Kind() == types.Uint8 {
+			// []byte 编码为 base64
+			break
+		}
+		s.kind, s.elem = shapeSlice, shapeOfType(u.Elem(), pkg, visiting)
+	case *types.Array:
+		s.kind, s.elem = shapeArray, shapeOfType(u.Elem(), pkg, visiting)
+	case *types.Map:
+		key := shapeOfType(u.Key(), pkg, visiting)
+		if key.kind == shapeString {
+			s.kind, s.elem, s.keyName = shapeMap, shapeOfType(u.Elem(), pkg, visiting), key.name
+		}
+	case *types.Struct:
+		s.neverEmpty = true
+	}
+	return s
+}
+
+// groupjsonPath groupjson 包的导入路径。
+const groupjsonPath = "github.com/JieBaiYou/groupjson"
+
+// appendAnyFunc 字段类型为类型形参时使用的辅助函数。
+const appendAnyFunc = `
+// groupjsonAppender 本包生成了编码代码的类型。
+type groupjsonAppender interface {
+	groupjsonAppend(dst []byte, groups []string, mode groupjson.GroupMode, depth int) ([]byte, error)
+}
+
+// groupjsonAppendAny 追加类型形参的值，p 为其地址（不可寻址时为值本身）：
+// 实参类型生成了编码代码时直接调用，否则交给运行时。
+func groupjsonAppendAny(dst []byte, p any, groups []string, mode groupjson.GroupMode, depth int) ([]byte, error) {
+	if a, ok := p.(groupjsonAppender); ok {
+		return a.groupjsonAppend(dst, groups, mode, depth)
+	}
+	return groupjson.AppendJSON(dst, p, groups, mode)
+}
+`
+
+// coveredFunc 分组项含 "a+b" 时使用的辅助函数。
+const coveredFunc = `
+// groupjsonCovered 返回 entries 中全部分组都在 m 之列的分组项之并。
+func groupjsonCovered(m uint64, entries ...uint64) uint64 {
+	var c uint64
+	for _, e := range entries {
+		if e&^m == 0 {
+			c |= e
+		}
+	}
+	return c
+}
+`
+
+// emitter 写出生成代码，并记录用到的导入。
+type emitter struct {
+	buf *bytes.Buffer
+	// generated 本次生成的结构体类型（泛型类型为其声明），引用其他结构体时交给运行时
+	generated   map[*types.Named]*structDef
+	usesStrconv bool
+	usesCovered bool
+	usesAny     bool
+}
+
+func (w *emitter) printf(format string, args ...any) {
+	fmt.Fprintf(w.buf, format, args...)
+}
+
+// structType 写出类型 def 的 AppendGroupJSON、MarshalWithGroups 与带嵌套层数的 groupjsonAppend，
+// 以及其实例的专用编码函数。
+func (w *emitter) structType(def *structDef) {
+	w.printf("\n// AppendGroupJSON 按 groups 与 mode 将 %s 的 JSON 追加到 dst，实现 groupjson.GroupEncoderAppender。\n", def.name)
+	w.printf("func (v *%s) AppendGroupJSON(dst []byte, groups []string, mode groupjson.GroupMode) ([]byte, error) {\n", def.typ)
+	w.printf("return v.groupjsonAppend(dst, groups, mode, 1)\n}\n")
+
+	w.printf("\n// MarshalWithGroups 按 groups 输出 %s 的 JSON，与 groupjson.Marshal(v, groups...) 一致。\n", def.name)
+	w.printf("func (v *%s) MarshalWithGroups(groups ...string) ([]byte, error) {\n", def.typ)
+	w.printf("return v.AppendGroupJSON(nil, groups, groupjson.ModeOr)\n}\n")
+
+	w.printf("\n// groupjsonAppend 同 AppendGroupJSON，depth 为 v 所在的嵌套层数，\n")
+	w.printf("// 与反射编码器一致，超过 groupjson.DefaultMaxDepth 时返回 groupjson.ErrMaxDepth。\n")
+	w.printf("func (v *%s) groupjsonAppend(dst []byte, groups []string, mode groupjson.GroupMode, depth int) ([]byte, error) {\n", def.typ)
+	if len(def.insts) > 0 {
+		w.printf("switch v := any(v).(type) {\n")
+		for _, inst := range def.insts {
+			w.printf("case *%s:\nreturn %s(v, dst, groups, mode, depth)\n", inst.typ, inst.fn)
+		}
+		w.printf("}\n")
+	}
+	w.body(def)
+
+	for _, inst := range def.insts {
+		w.printf("\n// %s 是 %s 的专用编码代码，由 %s 的 groupjsonAppend 调用。\n", inst.fn, inst.typ, def.name)
+		w.printf("func %s(v *%s, dst []byte, groups []string, mode groupjson.GroupMode, depth int) ([]byte, error) {\n", inst.fn, inst.typ)
+		w.body(inst)
+	}
+}
+
+// body 写出 groupjsonAppend 的函数体（含结尾的右括号）。
+func (w *emitter) body(def *structDef) {
+	w.printf("if v == nil {\nreturn append(dst, \"null\"...), nil\n}\n")
+	w.printf("if depth > groupjson.DefaultMaxDepth {\nreturn dst, groupjson.ErrMaxDepth\n}\n")
+	if len(def.fields) == 0 {
+		w.printf("return append(dst, \"{}\"...), nil\n}\n")
+		return
+	}
+	w.printf("all := len(groups) == 0\n")
+	if len(def.groups) > 0 {
+		w.printf("var m uint64\nunknown := false\nfor _, g := range groups {\nswitch g {\n")
+		for i, g := range def.groups {
+			w.printf("case %s:\nm |= 1 << %d\n", strconv.Quote(g), i)
+		}
+		w.printf("default:\nunknown = true\n}\n}\n")
+		w.printf("or := mode != groupjson.ModeAnd\n")
+		w.printf("and := !or && !unknown && m != 0\n")
+	}
+	if w.needsErr(def) {
+		w.printf("var err error\n")
+	}
+	w.printf("sep := 1\ndst = append(dst, '{')\n")
+	for i := range def.fields {
+		w.field(&def.fields[i])
+	}
+	w.printf("return append(dst, '}'), nil\n}\n")
+}
+
+// needsErr 判断类型的编码代码是否会用到 err 变量。
+func (w *emitter) needsErr(def *structDef) bool {
+	for _, f := range def.fields {
+		if f.shape.mayFail() {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *shape) mayFail() bool {
+	switch s.kind {
+	case shapeFloat32, shapeFloat64, shapeInterface, shapeOther, shapeStruct, shapeTypeParam:
+		return true
+	case shapePointer, shapeSlice, shapeArray, shapeMap:
+		return s.elem.mayFail()
+	}
+	return false
+}
+
+// field 写出单个字段：分组判断、嵌入指针与 omit 规则、键名与值。
+func (w *emitter) field(f *fieldDef) {
+	conds := append([]string{w.visible(f)}, f.guards...)
+	if f.omitEmpty {
+		conds = appendCond(conds, w.nonEmpty(f.shape, f.x))
+	}
+	if f.omitZero {
+		conds = appendCond(conds, w.nonZero(f.shape, f.x))
+	}
+	w.printf("if %s {\n", strings.Join(conds, " && "))
+	w.printf("dst = append(dst, %s[sep:]...)\nsep = 0\n", goString(","+f.key+":"))
+	w.value(f.shape, f.x, "&"+f.x, f.asString, 1)
+	w.printf("}\n")
+}
+
+// appendCond 追加非空的条件。
+func appendCond(conds []string, cond string) []string {
+	if cond == "" {
+		return conds
+	}
+	return append(conds, cond)
+}
+
+// visible 返回字段在本次请求的分组下是否可见的表达式，规则与反射编码器的分组位集合一致：
+// 未请求分组时全部可见；OR 模式下任一分组项成立即可见；AND 模式下成立的分组项须覆盖全部请求分组。
+func (w *emitter) visible(f *fieldDef) string {
+	if len(f.entries) == 0 {
+		return "all"
+	}
+	simple, union := true, uint64(0)
+	for _, e := range f.entries {
+		simple = simple && e&(e-1) == 0
+		union |= e
+	}
+	if simple {
+		return fmt.Sprintf("(all || (or && m&%#x != 0) || (and && m&^%#x == 0))", union, union)
+	}
+	w.usesCovered = true
+	list := make([]string, len(f.entries))
+	for i, e := range f.entries {
+		list[i] = fmt.Sprintf("%#x", e)
+	}
+	call := fmt.Sprintf("groupjsonCovered(m, %s)", strings.Join(list, ", "))
+	return fmt.Sprintf("(all || (or && %s != 0) || (and && %s == m))", call, call)
+}
+
+// nonEmpty 返回字段 x 按 omitempty 规则非空的表达式，永远非空时返回空串。
+// 与反射编码器一致，非 nil 的指针字段按其指向的值判断。
+func (w *emitter) nonEmpty(s *shape, x string) string {
+	if s.kind == shapePointer && s.elem.kind == shapeTypeParam {
+		// 实参本身可能是指针，交给运行时按字段规则判断
+		return "!groupjson.IsEmpty(&" + x + ")"
+	}
+	if s.kind == shapePointer {
+		return strings.Join(appendCond([]string{x + " != nil"}, w.nonEmptyValue(s.elem, "*"+x, x)), " && ")
+	}
+	return w.nonEmptyValue(s, x, "&"+x)
+}
+
+// nonEmptyValue 返回值 x 非空的表达式，addr 为 x 的地址表达式。
+func (w *emitter) nonEmptyValue(s *shape, x, addr string) string {
+	switch s.kind {
+	case shapeString:
+		return x + ` != ""`
+	case shapeBool:
+		return x
+	case shapeInt, shapeUint, shapeFloat32, shapeFloat64:
+		return x + " != 0"
+	case shapeInterface, shapePointer:
+		return x + " != nil"
+	case shapeSlice, shapeArray, shapeMap:
+		return "len(" + x + ") != 0"
+	}
+	if s.neverEmpty {
+		return ""
+	}
+	return "!groupjson.IsEmpty(" + addr + ")"
+}
+
+// nonZero 返回字段 x 按 omitzero 规则非零的表达式，指针字段的处理同 nonEmpty。
+func (w *emitter) nonZero(s *shape, x string) string {
+	if s.kind == shapePointer && s.elem.kind == shapeTypeParam {
+		return "!groupjson.IsZero(&" + x + ")"
+	}
+	if s.kind == shapePointer {
+		return x + " != nil && " + w.nonZeroValue(s.elem, "*"+x, x)
+	}
+	return w.nonZeroValue(s, x, "&"+x)
+}
+
+// nonZeroValue 返回值 x 非零的表达式，声明了 IsZero 方法的类型交给运行时判断。
+func (w *emitter) nonZeroValue(s *shape, x, addr string) string {
+	if s.zeroer {
+		return "!groupjson.IsZero(" + addr + ")"
+	}
+	switch s.kind {
+	case shapeString, shapeBool, shapeInt, shapeUint, shapeFloat32, shapeFloat64:
+		return w.nonEmptyValue(s, x, addr)
+	case shapeInterface, shapePointer, shapeSlice, shapeMap:
+		return x + " != nil"
+	}
+	return "!groupjson.IsZero(" + addr + ")"
+}
+
+// value 写出追加 x 的代码。addr 为 x 的地址表达式（不可寻址时为空），交给运行时编码时传入地址，
+// 使指针接收者的方法与反射路径一样生效；quoted 对应 ,string；depth 用于区分嵌套循环变量。
+func (w *emitter) value(s *shape, x, addr string, quoted bool, depth int) {
+	switch s.kind {
+	case shapeString:
+		if quoted {
+			w.printf("dst = groupjson.AppendString(dst, string(groupjson.AppendString(nil, %s)))\n", convert("string", s.name, x))
+		} else {
+			w.printf("dst = groupjson.AppendString(dst, %s)\n", convert("string", s.name, x))
+		}
+	case shapeBool, shapeInt, shapeUint, shapeFloat32, shapeFloat64:
+		if quoted {
+			w.printf("dst = append(dst, '\"')\n")
+		}
+		w.scalar(s, x)
+		if quoted {
+			w.printf("dst = append(dst, '\"')\n")
+		}
+	case shapePointer:
+		w.printf("if %s == nil {\ndst = append(dst, \"null\"...)\n} else {\n", x)
+		if s.elem.kind == shapeOther || s.elem.kind == shapeInterface || (s.elem.kind == shapeStruct && w.generatedFor(s.elem.named) == nil) {
+			w.runtime(x)
+		} else {
+			w.value(s.elem, "*"+x, x, quoted, depth)
+		}
+		w.printf("}\n")
+	case shapeSlice, shapeArray:
+		if s.kind == shapeSlice {
+			w.printf("if %s == nil {\ndst = append(dst, \"null\"...)\n} else {\n", x)
+		}
+		w.depthCheck(depth)
+		if strings.HasPrefix(x, "*") {
+			x = "(" + x + ")"
+		}
+		i := fmt.Sprintf("i%d", depth)
+		w.printf("dst = append(dst, '[')\nfor %s := range %s {\n", i, x)
+		w.printf("if %s > 0 {\ndst = append(dst, ',')\n}\n", i)
+		elem := x + "[" + i + "]"
+		w.value(s.elem, elem, "&"+elem, false, depth+1)
+		w.printf("}\ndst = append(dst, ']')\n")
+		if s.kind == shapeSlice {
+			w.printf("}\n")
+		}
+	case shapeMap:
+		k, e, n := fmt.Sprintf("k%d", depth), fmt.Sprintf("e%d", depth), fmt.Sprintf("n%d", depth)
+		w.printf("if %s == nil {\ndst = append(dst, \"null\"...)\n} else {\n", x)
+		w.depthCheck(depth)
+		w.printf("dst = append(dst, '{')\n%s := 0\nfor %s, %s := range %s {\n", n, k, e, x)
+		w.printf("if %s > 0 {\ndst = append(dst, ',')\n}\n%s++\n", n, n)
+		w.printf("dst = groupjson.AppendString(dst, %s)\ndst = append(dst, ':')\n", convert("string", s.keyName, k))
+		w.value(s.elem, e, "", false, depth+1)
+		w.printf("}\ndst = append(dst, '}')\n}\n")
+	case shapeStruct:
+		if w.generatedFor(s.named) == nil {
+			if addr != "" {
+				x = addr
+			}
+			w.runtime(x)
+			break
+		}
+		// 经由指针时 addr 即指针本身，否则 x 可寻址（字段、切片元素或 range 变量）
+		if addr != "" && !strings.HasPrefix(addr, "&") {
+			x = addr
+		}
+		if strings.HasPrefix(x, "*") {
+			x = "(" + x + ")"
+		}
+		w.printf("if dst, err = %s.groupjsonAppend(dst, groups, mode, depth+%d); err != nil {\nreturn dst, err\n}\n", x, depth)
+	case shapeTypeParam:
+		// 经由指针时 addr 即指针本身（已判断非 nil）；map 的值不可寻址，与反射路径一样按值传入
+		if addr != "" {
+			x = addr
+		}
+		w.usesAny = true
+		w.printf("if dst, err = groupjsonAppendAny(dst, %s, groups, mode, depth+%d); err != nil {\nreturn dst, err\n}\n", x, depth)
+	default:
+		if addr != "" {
+			x = addr
+		}
+		w.runtime(x)
+	}
+}
+
+// generatedFor 返回结构体 n（泛型实例按其泛型类型）本次生成的定义，未生成时返回 nil。
+func (w *emitter) generatedFor(n *types.Named) *structDef {
+	return w.generated[n.Origin()]
+}
+
+// depthCheck 写出容器的嵌套层数检查，level 为容器相对所在结构体的层数。
+// 与反射编码器一致，nil 的切片与 map 写出 null，不计入层数。
+func (w *emitter) depthCheck(level int) {
+	w.printf("if depth+%d > groupjson.DefaultMaxDepth {\nreturn dst, groupjson.ErrMaxDepth\n}\n", level)
+}
+
+// scalar 写出数值或布尔值。
+func (w *emitter) scalar(s *shape, x string) {
+	switch s.kind {
+	case shapeBool:
+		w.usesStrconv = true
+		w.printf("dst = strconv.AppendBool(dst, %s)\n", convert("bool", s.name, x))
+	case shapeInt:
+		w.usesStrconv = true
+		w.printf("dst = strconv.AppendInt(dst, %s, 10)\n", convert("int64", s.name, x))
+	case shapeUint:
+		w.usesStrconv = true
+		w.printf("dst = strconv.AppendUint(dst, %s, 10)\n", convert("uint64", s.name, x))
+	case shapeFloat32:
+		w.printf("if dst, err = groupjson.AppendFloat(dst, float64(%s), 32); err != nil {\nreturn dst, err\n}\n", x)
+	case shapeFloat64:
+		w.printf("if dst, err = groupjson.AppendFloat(dst, %s, 64); err != nil {\nreturn dst, err\n}\n", convert("float64", s.name, x))
+	}
+}
+
+// convert 返回把类型为 from 的表达式 x 转换为基本类型 to 的表达式，类型相同时原样返回；
+// from 为空表示命名类型，总是需要转换。
+func convert(to, from, x string) string {
+	if to == from {
+		return x
+	}
+	return to + "(" + x + ")"
+}
+
+// runtime 写出交给 groupjson.AppendJSON 编码的代码。
+func (w *emitter) runtime(x string) {
+	w.printf("if dst, err = groupjson.AppendJSON(dst, %s, groups, mode); err != nil {\nreturn dst, err\n}\n", x)
+}
+
+// goString 返回 s 的 Go 字符串字面量，可能时使用反引号以便阅读。
+func goString(s string) string {
+	if strconv.CanBackquote(s) {
+		return "`" + s + "`"
+	}
+	return strconv.Quote(s)
+}
@@ -0,0 +1,260 @@
+package codegen
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestGenerateUpToDate 提交的 internal/model/model_groupjson.go 与当前生成器的输出一致。
+func TestGenerateUpToDate(t *testing.T) {
+	// 与 model.go 中 go:generate 的参数一致
+	g := Generator{Instantiate: []string{"Page[Author]"}}
+	if err := g.Load("./internal/model"); err != nil {
+		t.Fatal(err)
+	}
+	files, err := g.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || filepath.Base(files[0].Path) != "model_groupjson.go" {
+		t.Fatalf("files = %v", files)
+	}
+	diff, err := g.Check(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff) > 0 {
+		t.Errorf("internal/model/model_groupjson.go is stale; run go generate ./internal/model in codegen\n%s", diff)
+	}
+}
+
+// writeModule 在临时目录中写出只含给定文件的模块，返回模块目录。
+func writeModule(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	files["go.mod"] = "module example.com/m\n\ngo 1.24\n"
+	for name, src := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestGeneratePackages(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"base/base.go": "package base\n\ntype Model struct {\n\tID int `json:\"id\" groups:\"public\"`\n}\n",
+		"a/user.go": "package a\n\nimport \"example.com/m/base\"\n\n" +
+			"type User struct {\n\tbase.Model\n\tName string `json:\"name\" groups:\"public\"`\n\tProfile Profile `json:\"profile\"`\n\tOwner base.Model\n}\n",
+		"a/profile.go": "package a\n\ntype Profile struct{ Bio string }\n\nfunc (u *User) JSON() ([]byte, error) { return u.MarshalWithGroups() }\n",
+		// 过时的生成文件不影响重新生成
+		"a/a_groupjson.go": header + "\npackage a\n\nfunc (v *User) Broken() { v.Missing() }\n",
+		"b/order.go":       "package b\n\ntype Order struct{ Total float64 }\n\ntype item struct{ N int }\n",
+		"c/c.go":           "package c\n\nconst C = 1\n",
+	})
+	g := Generator{Dir: dir, Types: []string{"User", "Order"}}
+	if err := g.Load("./..."); err != nil {
+		t.Fatal(err)
+	}
+	files, err := g.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 || files[0].Path != filepath.Join(dir, "a", "a_groupjson.go") || files[1].Path != filepath.Join(dir, "b", "b_groupjson.go") {
+		t.Fatalf("files = %v", files)
+	}
+	user := string(files[0].Source)
+	// 嵌入的其他包类型在生成时展开；本包的嵌套结构体递归生成并直接调用，其他包的结构体交给运行时
+	for _, want := range []string{
+		"func (v *User) AppendGroupJSON", `"id":`, "v.Model.ID",
+		"func (v *Profile) AppendGroupJSON", "v.Profile.groupjsonAppend(dst, groups, mode, depth+1)",
+		"groupjson.AppendJSON(dst, &v.Owner",
+	} {
+		if !strings.Contains(user, want) {
+			t.Errorf("a_groupjson.go missing %q:\n%s", want, user)
+		}
+	}
+
+	// 默认生成每个包中全部导出的结构体，没有结构体的包不产生文件
+	g.Types = nil
+	if files, err = g.Generate(); err != nil || len(files) != 3 || filepath.Base(files[2].Path) != "base_groupjson.go" ||
+		!strings.Contains(string(files[0].Source), "func (v *Profile)") || strings.Contains(string(files[1].Source), "item") {
+		t.Errorf("default types: %d files, %v", len(files), err)
+	}
+	g.Types = []string{"Missing"}
+	if _, err := g.Generate(); err == nil || !strings.Contains(err.Error(), "Missing not found") {
+		t.Errorf("missing type: err = %v", err)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		"a/a.go": "package a\n\ntype A struct {\n\tX int `json:\"x\"`\n}\n",
+		"b/b.go": "package b\n\ntype B struct{ Y string }\n",
+		// 非生成的同名文件不视为遗留文件
+		"c/c.go":           "package c\n\ntype c struct{}\n",
+		"c/c_groupjson.go": "package c\n",
+	})
+	var g Generator
+	g.Dir = dir
+	check := func() string {
+		t.Helper()
+		if err := g.Load("./..."); err != nil {
+			t.Fatal(err)
+		}
+		files, err := g.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		diff, err := g.Check(files)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(diff)
+	}
+
+	// 尚未生成的文件整体为新增
+	diff := check()
+	if !strings.Contains(diff, "+++ "+filepath.Join(dir, "a", "a_groupjson.go")+" (generated)\n@@ -0,0 +1,") || !strings.Contains(diff, "b_groupjson.go") {
+		t.Fatalf("missing files:\n%s", diff)
+	}
+	files, _ := g.Generate()
+	for _, f := range files {
+		if err := os.WriteFile(f.Path, f.Source, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if diff := check(); diff != "" {
+		t.Fatalf("up to date:\n%s", diff)
+	}
+
+	// 字段改名后只输出改动附近的 hunk；类型删除后遗留的生成文件整体为删除
+	mustWrite := func(name, src string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mustWrite("a/a.go", "package a\n\ntype A struct {\n\tX int `json:\"z\"`\n}\n")
+	mustWrite("b/b.go", "package b\n")
+	diff = check()
+	for _, want := range []string{"-\t\tdst = append(dst, `,\"x\":`[sep:]...)\n+\t\tdst = append(dst, `,\"z\":`[sep:]...)\n", "@@ -1,", "+0,0 @@"} {
+		if !strings.Contains(diff, want) {
+			t.Errorf("diff missing %q:\n%s", want, diff)
+		}
+	}
+	if strings.Count(diff, "@@ -") != 2 || strings.Contains(diff, "package a") || strings.Contains(diff, "c_groupjson.go") {
+		t.Errorf("diff:\n%s", diff)
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	dir := writeModule(t, map[string]string{"x.go": `package x
+
+import "sync"
+
+type S int
+
+type Mask struct { A string ` + "`groups:\"public;mask=email\"`" + ` }
+type NullAs struct { A *int ` + "`nullas:\"0\"`" + ` }
+type Marshaler struct{}
+func (Marshaler) MarshalJSON() ([]byte, error) { return nil, nil }
+type Promoted struct { Marshaler }
+type Text struct { A T ` + "`json:\",string\"`" + ` }
+type T struct{}
+func (*T) MarshalText() ([]byte, error) { return nil, nil }
+type Strings[V ~string] struct { V V ` + "`json:\",string\"`" + ` }
+type Invalid struct { A Undefined }
+type Locks struct {
+	Mu sync.Mutex
+	WG sync.WaitGroup
+	A  S ` + "`json:\"a\"`" + `
+}
+`})
+	var g Generator
+	g.Dir = dir
+	if err := g.Load("."); err != nil {
+		t.Fatal(err)
+	}
+	for typ, want := range map[string]string{
+		"Mask":      "mask modifier",
+		"NullAs":    "nullas tag",
+		"Marshaler": "implements MarshalJSON",
+		"Promoted":  "implements MarshalJSON",
+		"Text":      ",string",
+		"Strings":   "type parameter",
+		"Invalid":   "undefined: Undefined",
+	} {
+		g.Types = []string{typ}
+		if _, err := g.Generate(); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: err = %v, want %q", typ, err, want)
+		}
+	}
+
+	// 锁字段被跳过，与反射编码器一致；命名的基本类型直接写出
+	g.Types = []string{"Locks"}
+	files, err := g.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if src := string(files[0].Source); strings.Contains(src, `"Mu"`) || strings.Contains(src, `"WG"`) || !strings.Contains(src, "int64(v.A)") {
+		t.Errorf("Locks:\n%s", src)
+	}
+}
+
+func TestGenerateGeneric(t *testing.T) {
+	dir := writeModule(t, map[string]string{"x.go": `package x
+
+type User struct{ Name string }
+
+type Pair[K comparable, V any] struct {
+	Key   K
+	Value *V
+	Page  Page[V]
+}
+
+type Page[T any] struct{ Items []T }
+
+type S struct{}
+`})
+	g := Generator{Dir: dir, Types: []string{"Pair"}, Instantiate: []string{"Pair[int,User]", "Pair[string, *User]", "Page[[]User]"}}
+	if err := g.Load("."); err != nil {
+		t.Fatal(err)
+	}
+	files, err := g.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := string(files[0].Source)
+	// 实例由泛型方法分派到专用函数，函数名去掉标点，重名时加序号；引用的泛型类型与实参类型一并生成
+	for _, want := range []string{
+		"func (v *Pair[K, V]) groupjsonAppend(", "case *Pair[int, User]:\n\t\treturn groupjsonAppendPairintUser(v,",
+		"func groupjsonAppendPairstringUser(v *Pair[string, *User],", "func groupjsonAppendPageUser(v *Page[[]User],",
+		"func (v *Page[T]) AppendGroupJSON", "func (v *User) AppendGroupJSON", "groupjsonAppendAny(dst, &v.Key,",
+		"v.Page.groupjsonAppend(dst, groups, mode, depth+1)",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("missing %q:\n%s", want, src)
+		}
+	}
+	if strings.Contains(src, "func (v *S)") {
+		t.Errorf("unexpected S:\n%s", src)
+	}
+
+	for inst, want := range map[string]string{
+		"User[int]":    "is not a generic type",
+		"Pair[int]":    "not enough type arguments",
+		"Pair[int,T]":  "undefined: T",
+		"Pair(int)":    "want a generic type instance",
+		"Missing[int]": "Missing[int] not found",
+	} {
+		g.Instantiate = []string{inst}
+		if _, err := g.Generate(); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: err = %v, want %q", inst, err, want)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+// Package common 是 model 匿名嵌入的其他包的类型，用于测试跨包的字段提升。
+package common
+
+import "time"
+
+// Audit 审计字段，以值嵌入。
+type Audit struct {
+	CreatedBy string    `json:"created_by" groups:"admin"`
+	UpdatedAt time.Time `json:"updated_at,omitzero" groups:"admin"`
+	Revision  int       `json:"revision,omitempty" groups:"public,admin"`
+}
+
+// Meta 来源信息，以指针嵌入；Title 被外层的同名字段遮蔽。
+type Meta struct {
+	Source string `json:"source" groups:"internal"`
+	Title  string `json:"title" groups:"public"`
+	Origin string `json:"origin" groups:"public"`
+}
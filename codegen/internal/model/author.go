@@ -0,0 +1,48 @@
+package model
+
+import "github.com/JieBaiYou/groupjson/codegen/internal/common"
+
+type Author struct {
+	Name  string `json:"name" groups:"public,admin"`
+	Email string `json:"email" groups:"admin"`
+}
+
+// Stats 以 ,inline=stat_ 展开到 Article 中。
+type Stats struct {
+	Likes  int `json:"likes" groups:"public"`
+	Shares int `json:"shares,omitempty" groups:"admin"`
+}
+
+// Comment 递归的嵌套类型，生成代码按反射编码器的规则计算嵌套层数。
+type Comment struct {
+	Text    string             `json:"text" groups:"public"`
+	Author  Author             `json:"author" groups:"public"`
+	Replies []*Comment         `json:"replies,omitempty" groups:"public"`
+	ByLang  map[string]Comment `json:"by_lang,omitempty" groups:"admin"`
+	Votes   votes              `json:"votes" groups:"public"`
+	Contact contact            `json:"contact" groups:"admin"`
+	Source  common.Meta        `json:"source" groups:"internal"`
+	Next    *Comment           `json:"next,omitempty" groups:"admin"`
+}
+
+// votes 只被 Comment 引用的未导出类型，同样生成编码方法。
+type votes struct {
+	Up   int `json:"up" groups:"public"`
+	Down int `json:"down,omitempty" groups:"admin"`
+}
+
+// contact 使用了脱敏修饰，无法生成，由运行时编码。
+type contact struct {
+	Email string `json:"email" groups:"admin;mask=email"`
+}
+
+// Page 泛型类型：生成泛型方法，类型形参的值在运行时按实参分派；
+// Page[Author] 经 -instantiate 另外生成专用代码。
+type Page[T any] struct {
+	Items []T          `json:"items" groups:"public,admin"`
+	First T            `json:"first,omitempty" groups:"public"`
+	Best  *T           `json:"best,omitempty" groups:"admin"`
+	ByKey map[string]T `json:"by_key,omitempty" groups:"admin"`
+	Total int          `json:"total" groups:"public,admin"`
+	Next  *Page[T]     `json:"next,omitempty" groups:"public"`
+}
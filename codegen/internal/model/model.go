@@ -0,0 +1,58 @@
+// Package model 是 codegen 测试使用的模型，model_groupjson.go 由 gen-encoders 生成。
+package model
+
+import (
+	"sync"
+	"time"
+
+	"github.com/JieBaiYou/groupjson/codegen/internal/common"
+)
+
+//go:generate go run -C ../../../cmd/groupjson . gen-encoders -instantiate=Page[Author] ../../codegen/internal/model
+
+// Status 没有自定义序列化方法的命名类型，生成代码按字符串直接写出。
+type Status string
+
+// Priority 声明了 IsZero，omitzero 按该方法判断。
+type Priority int
+
+func (p Priority) IsZero() bool { return p <= 0 }
+
+type Article struct {
+	common.Audit
+	*common.Meta
+	Stats Stats `json:",inline=stat_"`
+
+	ID        int64               `json:"id" groups:"public,admin"`
+	Title     string              `json:"title" groups:"public,admin"`
+	Body      string              `json:"body,omitempty" groups:"public"`
+	Draft     bool                `json:"draft" groups:"admin"`
+	Views     uint32              `json:"views,string" groups:"admin"`
+	Score     float64             `json:"score,omitzero" groups:"public"`
+	Ratio     *float32            `json:"ratio" groups:"public"`
+	Tags      []string            `json:"tags,omitempty" groups:"public,admin"`
+	Matrix    [][]int             `json:"matrix" groups:"admin"`
+	Counts    map[string]int      `json:"counts" groups:"admin"`
+	Labels    map[string][]string `json:"labels,omitempty" groups:"public"`
+	Grid      [2]uint8            `json:"grid" groups:"public"`
+	Raw       []byte              `json:"raw,omitempty" groups:"admin"`
+	Author    *Author             `json:"author" groups:"public"`
+	Reviewers []Author            `json:"reviewers" groups:"admin+internal"`
+	Comments  []Comment           `json:"comments,omitempty" groups:"public,admin"`
+	Related   *Page[Author]       `json:"related,omitempty" groups:"public"`
+	Extra     any                 `json:"extra,omitempty" groups:"public,admin"`
+	Published time.Time           `json:"published" groups:"public" order:"1"`
+	Status    Status              `json:"status,omitempty" groups:"public"`
+	Quoted    string              `json:"quoted,string" groups:"admin"`
+	Level     *int                `json:"level,string" groups:"admin"`
+	Rank      *int                `json:"rank,omitempty" groups:"public"`
+	Priority  Priority            `json:"priority,omitzero" groups:"admin"`
+	Origin    string              `json:"origin" groups:"-"`
+	Note      string              `json:"note"`
+	Secret    string              `json:"secret" groups:"-"`
+	Ignored   string              `json:"-"`
+
+	Mu    sync.Mutex
+	mu    sync.Mutex
+	draft string
+}
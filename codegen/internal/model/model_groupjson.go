@@ -0,0 +1,921 @@
+// Code generated by groupjson gen-encoders. DO NOT EDIT.
+
+package model
+
+import (
+	"strconv"
+
+	"github.com/JieBaiYou/groupjson"
+)
+
+// AppendGroupJSON 按 groups 与 mode 将 Author 的 JSON 追加到 dst，实现 groupjson.GroupEncoderAppender。
+func (v *Author) AppendGroupJSON(dst []byte, groups []string, mode groupjson.GroupMode) ([]byte, error) {
+	return v.groupjsonAppend(dst, groups, mode, 1)
+}
+
+// MarshalWithGroups 按 groups 输出 Author 的 JSON，与 groupjson.Marshal(v, groups...) 一致。
+func (v *Author) MarshalWithGroups(groups ...string) ([]byte, error) {
+	return v.AppendGroupJSON(nil, groups, groupjson.ModeOr)
+}
+
+// groupjsonAppend 同 AppendGroupJSON，depth 为 v 所在的嵌套层数，
+// 与反射编码器一致，超过 groupjson.DefaultMaxDepth 时返回 groupjson.ErrMaxDepth。
+func (v *Author) groupjsonAppend(dst []byte, groups []string, mode groupjson.GroupMode, depth int) ([]byte, error) {
+	if v == nil {
+		return append(dst, "null"...), nil
+	}
+	if depth > groupjson.DefaultMaxDepth {
+		return dst, groupjson.ErrMaxDepth
+	}
+	all := len(groups) == 0
+	var m uint64
+	unknown := false
+	for _, g := range groups {
+		switch g {
+		case "public":
+			m |= 1 << 0
+		case "admin":
+			m |= 1 << 1
+		default:
+			unknown = true
+		}
+	}
+	or := mode != groupjson.ModeAnd
+	and := !or && !unknown && m != 0
+	sep := 1
+	dst = append(dst, '{')
+	if all || (or && m&0x3 != 0) || (and && m&^0x3 == 0) {
+		dst = append(dst, `,"name":`[sep:]...)
+		sep = 0
+		dst = groupjson.AppendString(dst, v.Name)
+	}
+	if all || (or && m&0x2 != 0) || (and && m&^0x2 == 0) {
+		dst = append(dst, `,"email":`[sep:]...)
+		sep = 0
+		dst = groupjson.AppendString(dst, v.Email)
+	}
+	return append(dst, '}'), nil
+}
+
+// AppendGroupJSON 按 groups 与 mode 将 Stats 的 JSON 追加到 dst，实现 groupjson.GroupEncoderAppender。
+func (v *Stats) AppendGroupJSON(dst []byte, groups []string, mode groupjson.GroupMode) ([]byte, error) {
+	return v.groupjsonAppend(dst, groups, mode, 1)
+}
+
+// MarshalWithGroups 按 groups 输出 Stats 的 JSON，与 groupjson.Marshal(v, groups...) 一致。
+func (v *Stats) MarshalWithGroups(groups ...string) ([]byte, error) {
+	return v.AppendGroupJSON(nil, groups, groupjson.ModeOr)
+}
+
+// groupjsonAppend 同 AppendGroupJSON，depth 为 v 所在的嵌套层数，
+// 与反射编码器一致，超过 groupjson.DefaultMaxDepth 时返回 groupjson.ErrMaxDepth。
+func (v *Stats) groupjsonAppend(dst []byte, groups []string, mode groupjson.GroupMode, depth int) ([]byte, error) {
+	if v == nil {
+		return append(dst, "null"...), nil
+	}
+	if depth > groupjson.DefaultMaxDepth {
+		return dst, groupjson.ErrMaxDepth
+	}
+	all := len(groups) == 0
+	var m uint64
+	unknown := false
+	for _, g := range groups {
+		switch g {
+		case "public":
+			m |= 1 << 0
+		case "admin":
+			m |= 1 << 1
+		default:
+			unknown = true
+		}
+	}
+	or := mode != groupjson.ModeAnd
+	and := !or && !unknown && m != 0
+	sep := 1
+	dst = append(dst, '{')
+	if all || (or && m&0x1 != 0) || (and && m&^0x1 == 0) {
+		dst = append(dst, `,"likes":`[sep:]...)
+		sep = 0
+		dst = strconv.AppendInt(dst, int64(v.Likes), 10)
+	}
+	if (all || (or && m&0x2 != 0) || (and && m&^0x2 == 0)) && v.Shares != 0 {
+		dst = append(dst, `,"shares":`[sep:]...)
+		sep = 0
+		dst = strconv.AppendInt(dst, int64(v.Shares), 10)
+	}
+	return append(dst, '}'), nil
+}
+
+// AppendGroupJSON 按 groups 与 mode 将 Comment 的 JSON 追加到 dst，实现 groupjson.GroupEncoderAppender。
+func (v *Comment) AppendGroupJSON(dst []byte, groups []string, mode groupjson.GroupMode) ([]byte, error) {
+	return v.groupjsonAppend(dst, groups, mode, 1)
+}
+
+// MarshalWithGroups 按 groups 输出 Comment 的 JSON，与 groupjson.Marshal(v, groups...) 一致。
+func (v *Comment) MarshalWithGroups(groups ...string) ([]byte, error) {
+	return v.AppendGroupJSON(nil, groups, groupjson.ModeOr)
+}
+
+// groupjsonAppend 同 AppendGroupJSON，depth 为 v 所在的嵌套层数，
+// 与反射编码器一致，超过 groupjson.DefaultMaxDepth 时返回 groupjson.ErrMaxDepth。
+func (v *Comment) groupjsonAppend(dst []byte, groups []string, mode groupjson.GroupMode, depth int) ([]byte, error) {
+	if v == nil {
+		return append(dst, "null"...), nil
+	}
+	if depth > groupjson.DefaultMaxDepth {
+		return dst, groupjson.ErrMaxDepth
+	}
+	all := len(groups) == 0
+	var m uint64
+	unknown := false
+	for _, g := range groups {
+		switch g {
+		case "public":
+			m |= 1 << 0
+		case "admin":
+			m |= 1 << 1
+		case "internal":
+			m |= 1 << 2
+		default:
+			unknown = true
+		}
+	}
+	or := mode != groupjson.ModeAnd
+	and := !or && !unknown && m != 0
+	var err error
+	sep := 1
+	dst = append(dst, '{')
+	if all || (or && m&0x1 != 0) || (and && m&^0x1 == 0) {
+		dst = append(dst, `,"text":`[sep:]...)
+		sep = 0
+		dst = groupjson.AppendString(dst, v.Text)
+	}
+	if all || (or && m&0x1 != 0) || (and && m&^0x1 == 0) {
+		dst = append(dst, `,"author":`[sep:]...)
+		sep = 0
+		if dst, err = v.Author.groupjsonAppend(dst, groups, mode, depth+1); err != nil {
+			return dst, err
+		}
+	}
+	if (all || (or && m&0x1 != 0) || (and && m&^0x1 == 0)) && len(v.Replies) != 0 {
+		dst = append(dst, `,"replies":`[sep:]...)
+		sep = 0
+		if v.Replies == nil {
+			dst = append(dst, "null"...)
+		} else {
+			if depth+1 > groupjson.DefaultMaxDepth {
+				return dst, groupjson.ErrMaxDepth
+			}
+			dst = append(dst, '[')
+			for i1 := range v.Replies {
+				if i1 > 0 {
+					dst = append(dst, ',')
+				}
+				if v.Replies[i1] == nil {
+					dst = append(dst, "null"...)
+				} else {
+					if dst, err = v.Replies[i1].groupjsonAppend(dst, groups, mode, depth+2); err != nil {
+						return dst, err
+					}
+				}
+			}
+			dst = append(dst, ']')
+		}
+	}
+	if (all || (or && m&0x2 != 0) || (and && m&^0x2 == 0)) && len(v.ByLang) != 0 {
+		dst = append(dst, `,"by_lang":`[sep:]...)
+		sep = 0
+		if v.ByLang == nil {
+			dst = append(dst, "null"...)
+		} else {
+			if depth+1 > groupjson.DefaultMaxDepth {
+				return dst, groupjson.ErrMaxDepth
+			}
+			dst = append(dst, '{')
+			n1 := 0
+			for k1, e1 := range v.ByLang {
+				if n1 > 0 {
+					dst = append(dst, ',')
+				}
+				n1++
+				dst = groupjson.AppendString(dst, k1)
+				dst = append(dst, ':')
+				if dst, err = e1.groupjsonAppend(dst, groups, mode, depth+2); err != nil {
+					return dst, err
+				}
+			}
+			dst = append(dst, '}')
+		}
+	}
+	if all || (or && m&0x1 != 0) || (and && m&^0x1 == 0) {
+		dst = append(dst, `,"votes":`[sep:]...)
+		sep = 0
+		if dst, err = v.Votes.groupjsonAppend(dst, groups, mode, depth+1); err != nil {
+			return dst, err
+		}
+	}
+	if all || (or && m&0x2 != 0) || (and && m&^0x2 == 0) {
+		dst = append(dst, `,"contact":`[sep:]...)
+		sep = 0
+		if dst, err = groupjson.AppendJSON(dst, &v.Contact, groups, mode); err != nil {
+			return dst, err
+		}
+	}
+	if all || (or && m&0x4 != 0) || (and && m&^0x4 == 0) {
+		dst = append(dst, `,"source":`[sep:]...)
+		sep = 0
+		if dst, err = groupjson.AppendJSON(dst, &v.Source, groups, mode); err != nil {
+			return dst, err
+		}
+	}
+	if (all || (or && m&0x2 != 0) || (and && m&^0x2 == 0)) && v.Next != nil {
+		dst = append(dst, `,"next":`[sep:]...)
+		sep = 0
+		if v.Next == nil {
+			dst = append(dst, "null"...)
+		} else {
+			if dst, err = v.Next.groupjsonAppend(dst, groups, mode, depth+1); err != nil {
+				return dst, err
+			}
+		}
+	}
+	return append(dst, '}'), nil
+}
+
+// AppendGroupJSON 按 groups 与 mode 将 votes 的 JSON 追加到 dst，实现 groupjson.GroupEncoderAppender。
+func (v *votes) AppendGroupJSON(dst []byte, groups []string, mode groupjson.GroupMode) ([]byte, error) {
+	return v.groupjsonAppend(dst, groups, mode, 1)
+}
+
+// MarshalWithGroups 按 groups 输出 votes 的 JSON，与 groupjson.Marshal(v, groups...) 一致。
+func (v *votes) MarshalWithGroups(groups ...string) ([]byte, error) {
+	return v.AppendGroupJSON(nil, groups, groupjson.ModeOr)
+}
+
+// groupjsonAppend 同 AppendGroupJSON，depth 为 v 所在的嵌套层数，
+// 与反射编码器一致，超过 groupjson.DefaultMaxDepth 时返回 groupjson.ErrMaxDepth。
+func (v *votes) groupjsonAppend(dst []byte, groups []string, mode groupjson.GroupMode, depth int) ([]byte, error) {
+	if v == nil {
+		return append(dst, "null"...), nil
+	}
+	if depth > groupjson.DefaultMaxDepth {
+		return dst, groupjson.ErrMaxDepth
+	}
+	all := len(groups) == 0
+	var m uint64
+	unknown := false
+	for _, g := range groups {
+		switch g {
+		case "public":
+			m |= 1 << 0
+		case "admin":
+			m |= 1 << 1
+		default:
+			unknown = true
+		}
+	}
+	or := mode != groupjson.ModeAnd
+	and := !or && !unknown && m != 0
+	sep := 1
+	dst = append(dst, '{')
+	if all || (or && m&0x1 != 0) || (and && m&^0x1 == 0) {
+		dst = append(dst, `,"up":`[sep:]...)
+		sep = 0
+		dst = strconv.AppendInt(dst, int64(v.Up), 10)
+	}
+	if (all || (or && m&0x2 != 0) || (and && m&^0x2 == 0)) && v.Down != 0 {
+		dst = append(dst, `,"down":`[sep:]...)
+		sep = 0
+		dst = strconv.AppendInt(dst, int64(v.Down), 10)
+	}
+	return append(dst, '}'), nil
+}
+
+// AppendGroupJSON 按 groups 与 mode 将 Page 的 JSON 追加到 dst，实现 groupjson.GroupEncoderAppender。
+func (v *Page[T]) AppendGroupJSON(dst []byte, groups []string, mode groupjson.GroupMode) ([]byte, error) {
+	return v.groupjsonAppend(dst, groups, mode, 1)
+}
+
+// MarshalWithGroups 按 groups 输出 Page 的 JSON，与 groupjson.Marshal(v, groups...) 一致。
+func (v *Page[T]) MarshalWithGroups(groups ...string) ([]byte, error) {
+	return v.AppendGroupJSON(nil, groups, groupjson.ModeOr)
+}
+
+// groupjsonAppend 同 AppendGroupJSON，depth 为 v 所在的嵌套层数，
+// 与反射编码器一致，超过 groupjson.DefaultMaxDepth 时返回 groupjson.ErrMaxDepth。
+func (v *Page[T]) groupjsonAppend(dst []byte, groups []string, mode groupjson.GroupMode, depth int) ([]byte, error) {
+	switch v := any(v).(type) {
+	case *Page[Author]:
+		return groupjsonAppendPageAuthor(v, dst, groups, mode, depth)
+	}
+	if v == nil {
+		return append(dst, "null"...), nil
+	}
+	if depth > groupjson.DefaultMaxDepth {
+		return dst, groupjson.ErrMaxDepth
+	}
+	all := len(groups) == 0
+	var m uint64
+	unknown := false
+	for _, g := range groups {
+		switch g {
+		case "public":
+			m |= 1 << 0
+		case "admin":
+			m |= 1 << 1
+		default:
+			unknown = true
+		}
+	}
+	or := mode != groupjson.ModeAnd
+	and := !or && !unknown && m != 0
+	var err error
+	sep := 1
+	dst = append(dst, '{')
+	if all || (or && m&0x3 != 0) || (and && m&^0x3 == 0) {
+		dst = append(dst, `,"items":`[sep:]...)
+		sep = 0
+		if v.Items == nil {
+			dst = append(dst, "null"...)
+		} else {
+			if depth+1 > groupjson.DefaultMaxDepth {
+				return dst, groupjson.ErrMaxDepth
+			}
+			dst = append(dst, '[')
+			for i1 := range v.Items {
+				if i1 > 0 {
+					dst = append(dst, ',')
+				}
+				if dst, err = groupjsonAppendAny(dst, &v.Items[i1], groups, mode, depth+2); err != nil {
+					return dst, err
+				}
+			}
+			dst = append(dst, ']')
+		}
+	}
+	if (all || (or && m&0x1 != 0) || (and && m&^0x1 == 0)) && !groupjson.IsEmpty(&v.First) {
+		dst = append(dst, `,"first":`[sep:]...)
+		sep = 0
+		if dst, err = groupjsonAppendAny(dst, &v.First, groups, mode, depth+1); err != nil {
+			return dst, err
+		}
+	}
+	if (all || (or && m&0x2 != 0) || (and && m&^0x2 == 0)) && !groupjson.IsEmpty(&v.Best) {
+		dst = append(dst, `,"best":`[sep:]...)
+		sep = 0
+		if v.Best == nil {
+			dst = append(dst, "null"...)
+		} else {
+			if dst, err = groupjsonAppendAny(dst, v.Best, groups, mode, depth+1); err != nil {
+				return dst, err
+			}
+		}
+	}
+	if (all || (or && m&0x2 != 0) || (and && m&^0x2 == 0)) && len(v.ByKey) != 0 {
+		dst = append(dst, `,"by_key":`[sep:]...)
+		sep = 0
+		if v.ByKey == nil {
+			dst = append(dst, "null"...)
+		} else {
+			if depth+1 > groupjson.DefaultMaxDepth {
+				return dst, groupjson.ErrMaxDepth
+			}
+			dst = append(dst, '{')
+			n1 := 0
+			for k1, e1 := range v.ByKey {
+				if n1 > 0 {
+					dst = append(dst, ',')
+				}
+				n1++
+				dst = groupjson.AppendString(dst, k1)
+				dst = append(dst, ':')
+				if dst, err = groupjsonAppendAny(dst, e1, groups, mode, depth+2); err != nil {
+					return dst, err
+				}
+			}
+			dst = append(dst, '}')
+		}
+	}
+	if all || (or && m&0x3 != 0) || (and && m&^0x3 == 0) {
+		dst = append(dst, `,"total":`[sep:]...)
+		sep = 0
+		dst = strconv.AppendInt(dst, int64(v.Total), 10)
+	}
+	if (all || (or && m&0x1 != 0) || (and && m&^0x1 == 0)) && v.Next != nil {
+		dst = append(dst, `,"next":`[sep:]...)
+		sep = 0
+		if v.Next == nil {
+			dst = append(dst, "null"...)
+		} else {
+			if dst, err = v.Next.groupjsonAppend(dst, groups, mode, depth+1); err != nil {
+				return dst, err
+			}
+		}
+	}
+	return append(dst, '}'), nil
+}
+
+// groupjsonAppendPageAuthor 是 Page[Author] 的专用编码代码，由 Page 的 groupjsonAppend 调用。
+func groupjsonAppendPageAuthor(v *Page[Author], dst []byte, groups []string, mode groupjson.GroupMode, depth int) ([]byte, error) {
+	if v == nil {
+		return append(dst, "null"...), nil
+	}
+	if depth > groupjson.DefaultMaxDepth {
+		return dst, groupjson.ErrMaxDepth
+	}
+	all := len(groups) == 0
+	var m uint64
+	unknown := false
+	for _, g := range groups {
+		switch g {
+		case "public":
+			m |= 1 << 0
+		case "admin":
+			m |= 1 << 1
+		default:
+			unknown = true
+		}
+	}
+	or := mode != groupjson.ModeAnd
+	and := !or && !unknown && m != 0
+	var err error
+	sep := 1
+	dst = append(dst, '{')
+	if all || (or && m&0x3 != 0) || (and && m&^0x3 == 0) {
+		dst = append(dst, `,"items":`[sep:]...)
+		sep = 0
+		if v.Items == nil {
+			dst = append(dst, "null"...)
+		} else {
+			if depth+1 > groupjson.DefaultMaxDepth {
+				return dst, groupjson.ErrMaxDepth
+			}
+			dst = append(dst, '[')
+			for i1 := range v.Items {
+				if i1 > 0 {
+					dst = append(dst, ',')
+				}
+				if dst, err = v.Items[i1].groupjsonAppend(dst, groups, mode, depth+2); err != nil {
+					return dst, err
+				}
+			}
+			dst = append(dst, ']')
+		}
+	}
+	if all || (or && m&0x1 != 0) || (and && m&^0x1 == 0) {
+		dst = append(dst, `,"first":`[sep:]...)
+		sep = 0
+		if dst, err = v.First.groupjsonAppend(dst, groups, mode, depth+1); err != nil {
+			return dst, err
+		}
+	}
+	if (all || (or && m&0x2 != 0) || (and && m&^0x2 == 0)) && v.Best != nil {
+		dst = append(dst, `,"best":`[sep:]...)
+		sep = 0
+		if v.Best == nil {
+			dst = append(dst, "null"...)
+		} else {
+			if dst, err = v.Best.groupjsonAppend(dst, groups, mode, depth+1); err != nil {
+				return dst, err
+			}
+		}
+	}
+	if (all || (or && m&0x2 != 0) || (and && m&^0x2 == 0)) && len(v.ByKey) != 0 {
+		dst = append(dst, `,"by_key":`[sep:]...)
+		sep = 0
+		if v.ByKey == nil {
+			dst = append(dst, "null"...)
+		} else {
+			if depth+1 > groupjson.DefaultMaxDepth {
+				return dst, groupjson.ErrMaxDepth
+			}
+			dst = append(dst, '{')
+			n1 := 0
+			for k1, e1 := range v.ByKey {
+				if n1 > 0 {
+					dst = append(dst, ',')
+				}
+				n1++
+				dst = groupjson.AppendString(dst, k1)
+				dst = append(dst, ':')
+				if dst, err = e1.groupjsonAppend(dst, groups, mode, depth+2); err != nil {
+					return dst, err
+				}
+			}
+			dst = append(dst, '}')
+		}
+	}
+	if all || (or && m&0x3 != 0) || (and && m&^0x3 == 0) {
+		dst = append(dst, `,"total":`[sep:]...)
+		sep = 0
+		dst = strconv.AppendInt(dst, int64(v.Total), 10)
+	}
+	if (all || (or && m&0x1 != 0) || (and && m&^0x1 == 0)) && v.Next != nil {
+		dst = append(dst, `,"next":`[sep:]...)
+		sep = 0
+		if v.Next == nil {
+			dst = append(dst, "null"...)
+		} else {
+			if dst, err = v.Next.groupjsonAppend(dst, groups, mode, depth+1); err != nil {
+				return dst, err
+			}
+		}
+	}
+	return append(dst, '}'), nil
+}
+
+// AppendGroupJSON 按 groups 与 mode 将 Article 的 JSON 追加到 dst，实现 groupjson.GroupEncoderAppender。
+func (v *Article) AppendGroupJSON(dst []byte, groups []string, mode groupjson.GroupMode) ([]byte, error) {
+	return v.groupjsonAppend(dst, groups, mode, 1)
+}
+
+// MarshalWithGroups 按 groups 输出 Article 的 JSON，与 groupjson.Marshal(v, groups...) 一致。
+func (v *Article) MarshalWithGroups(groups ...string) ([]byte, error) {
+	return v.AppendGroupJSON(nil, groups, groupjson.ModeOr)
+}
+
+// groupjsonAppend 同 AppendGroupJSON，depth 为 v 所在的嵌套层数，
+// 与反射编码器一致，超过 groupjson.DefaultMaxDepth 时返回 groupjson.ErrMaxDepth。
+func (v *Article) groupjsonAppend(dst []byte, groups []string, mode groupjson.GroupMode, depth int) ([]byte, error) {
+	if v == nil {
+		return append(dst, "null"...), nil
+	}
+	if depth > groupjson.DefaultMaxDepth {
+		return dst, groupjson.ErrMaxDepth
+	}
+	all := len(groups) == 0
+	var m uint64
+	unknown := false
+	for _, g := range groups {
+		switch g {
+		case "public":
+			m |= 1 << 0
+		case "admin":
+			m |= 1 << 1
+		case "internal":
+			m |= 1 << 2
+		default:
+			unknown = true
+		}
+	}
+	or := mode != groupjson.ModeAnd
+	and := !or && !unknown && m != 0
+	var err error
+	sep := 1
+	dst = append(dst, '{')
+	if all || (or && m&0x1 != 0) || (and && m&^0x1 == 0) {
+		dst = append(dst, `,"published":`[sep:]...)
+		sep = 0
+		if dst, err = groupjson.AppendJSON(dst, &v.Published, groups, mode); err != nil {
+			return dst, err
+		}
+	}
+	if all || (or && m&0x3 != 0) || (and && m&^0x3 == 0) {
+		dst = append(dst, `,"id":`[sep:]...)
+		sep = 0
+		dst = strconv.AppendInt(dst, v.ID, 10)
+	}
+	if all || (or && m&0x3 != 0) || (and && m&^0x3 == 0) {
+		dst = append(dst, `,"title":`[sep:]...)
+		sep = 0
+		dst = groupjson.AppendString(dst, v.Title)
+	}
+	if (all || (or && m&0x1 != 0) || (and && m&^0x1 == 0)) && v.Body != "" {
+		dst = append(dst, `,"body":`[sep:]...)
+		sep = 0
+		dst = groupjson.AppendString(dst, v.Body)
+	}
+	if all || (or && m&0x2 != 0) || (and && m&^0x2 == 0) {
+		dst = append(dst, `,"draft":`[sep:]...)
+		sep = 0
+		dst = strconv.AppendBool(dst, v.Draft)
+	}
+	if all || (or && m&0x2 != 0) || (and && m&^0x2 == 0) {
+		dst = append(dst, `,"views":`[sep:]...)
+		sep = 0
+		dst = append(dst, '"')
+		dst = strconv.AppendUint(dst, uint64(v.Views), 10)
+		dst = append(dst, '"')
+	}
+	if (all || (or && m&0x1 != 0) || (and && m&^0x1 == 0)) && v.Score != 0 {
+		dst = append(dst, `,"score":`[sep:]...)
+		sep = 0
+		if dst, err = groupjson.AppendFloat(dst, v.Score, 64); err != nil {
+			return dst, err
+		}
+	}
+	if all || (or && m&0x1 != 0) || (and && m&^0x1 == 0) {
+		dst = append(dst, `,"ratio":`[sep:]...)
+		sep = 0
+		if v.Ratio == nil {
+			dst = append(dst, "null"...)
+		} else {
+			if dst, err = groupjson.AppendFloat(dst, float64(*v.Ratio), 32); err != nil {
+				return dst, err
+			}
+		}
+	}
+	if (all || (or && m&0x3 != 0) || (and && m&^0x3 == 0)) && len(v.Tags) != 0 {
+		dst = append(dst, `,"tags":`[sep:]...)
+		sep = 0
+		if v.Tags == nil {
+			dst = append(dst, "null"...)
+		} else {
+			if depth+1 > groupjson.DefaultMaxDepth {
+				return dst, groupjson.ErrMaxDepth
+			}
+			dst = append(dst, '[')
+			for i1 := range v.Tags {
+				if i1 > 0 {
+					dst = append(dst, ',')
+				}
+				dst = groupjson.AppendString(dst, v.Tags[i1])
+			}
+			dst = append(dst, ']')
+		}
+	}
+	if all || (or && m&0x2 != 0) || (and && m&^0x2 == 0) {
+		dst = append(dst, `,"matrix":`[sep:]...)
+		sep = 0
+		if v.Matrix == nil {
+			dst = append(dst, "null"...)
+		} else {
+			if depth+1 > groupjson.DefaultMaxDepth {
+				return dst, groupjson.ErrMaxDepth
+			}
+			dst = append(dst, '[')
+			for i1 := range v.Matrix {
+				if i1 > 0 {
+					dst = append(dst, ',')
+				}
+				if v.Matrix[i1] == nil {
+					dst = append(dst, "null"...)
+				} else {
+					if depth+2 > groupjson.DefaultMaxDepth {
+						return dst, groupjson.ErrMaxDepth
+					}
+					dst = append(dst, '[')
+					for i2 := range v.Matrix[i1] {
+						if i2 > 0 {
+							dst = append(dst, ',')
+						}
+						dst = strconv.AppendInt(dst, int64(v.Matrix[i1][i2]), 10)
+					}
+					dst = append(dst, ']')
+				}
+			}
+			dst = append(dst, ']')
+		}
+	}
+	if all || (or && m&0x2 != 0) || (and && m&^0x2 == 0) {
+		dst = append(dst, `,"counts":`[sep:]...)
+		sep = 0
+		if v.Counts == nil {
+			dst = append(dst, "null"...)
+		} else {
+			if depth+1 > groupjson.DefaultMaxDepth {
+				return dst, groupjson.ErrMaxDepth
+			}
+			dst = append(dst, '{')
+			n1 := 0
+			for k1, e1 := range v.Counts {
+				if n1 > 0 {
+					dst = append(dst, ',')
+				}
+				n1++
+				dst = groupjson.AppendString(dst, k1)
+				dst = append(dst, ':')
+				dst = strconv.AppendInt(dst, int64(e1), 10)
+			}
+			dst = append(dst, '}')
+		}
+	}
+	if (all || (or && m&0x1 != 0) || (and && m&^0x1 == 0)) && len(v.Labels) != 0 {
+		dst = append(dst, `,"labels":`[sep:]...)
+		sep = 0
+		if v.Labels == nil {
+			dst = append(dst, "null"...)
+		} else {
+			if depth+1 > groupjson.DefaultMaxDepth {
+				return dst, groupjson.ErrMaxDepth
+			}
+			dst = append(dst, '{')
+			n1 := 0
+			for k1, e1 := range v.Labels {
+				if n1 > 0 {
+					dst = append(dst, ',')
+				}
+				n1++
+				dst = groupjson.AppendString(dst, k1)
+				dst = append(dst, ':')
+				if e1 == nil {
+					dst = append(dst, "null"...)
+				} else {
+					if depth+2 > groupjson.DefaultMaxDepth {
+						return dst, groupjson.ErrMaxDepth
+					}
+					dst = append(dst, '[')
+					for i2 := range e1 {
+						if i2 > 0 {
+							dst = append(dst, ',')
+						}
+						dst = groupjson.AppendString(dst, e1[i2])
+					}
+					dst = append(dst, ']')
+				}
+			}
+			dst = append(dst, '}')
+		}
+	}
+	if all || (or && m&0x1 != 0) || (and && m&^0x1 == 0) {
+		dst = append(dst, `,"grid":`[sep:]...)
+		sep = 0
+		if depth+1 > groupjson.DefaultMaxDepth {
+			return dst, groupjson.ErrMaxDepth
+		}
+		dst = append(dst, '[')
+		for i1 := range v.Grid {
+			if i1 > 0 {
+				dst = append(dst, ',')
+			}
+			dst = strconv.AppendUint(dst, uint64(v.Grid[i1]), 10)
+		}
+		dst = append(dst, ']')
+	}
+	if (all || (or && m&0x2 != 0) || (and && m&^0x2 == 0)) && !groupjson.IsEmpty(&v.Raw) {
+		dst = append(dst, `,"raw":`[sep:]...)
+		sep = 0
+		if dst, err = groupjson.AppendJSON(dst, &v.Raw, groups, mode); err != nil {
+			return dst, err
+		}
+	}
+	if all || (or && m&0x1 != 0) || (and && m&^0x1 == 0) {
+		dst = append(dst, `,"author":`[sep:]...)
+		sep = 0
+		if v.Author == nil {
+			dst = append(dst, "null"...)
+		} else {
+			if dst, err = v.Author.groupjsonAppend(dst, groups, mode, depth+1); err != nil {
+				return dst, err
+			}
+		}
+	}
+	if all || (or && groupjsonCovered(m, 0x6) != 0) || (and && groupjsonCovered(m, 0x6) == m) {
+		dst = append(dst, `,"reviewers":`[sep:]...)
+		sep = 0
+		if v.Reviewers == nil {
+			dst = append(dst, "null"...)
+		} else {
+			if depth+1 > groupjson.DefaultMaxDepth {
+				return dst, groupjson.ErrMaxDepth
+			}
+			dst = append(dst, '[')
+			for i1 := range v.Reviewers {
+				if i1 > 0 {
+					dst = append(dst, ',')
+				}
+				if dst, err = v.Reviewers[i1].groupjsonAppend(dst, groups, mode, depth+2); err != nil {
+					return dst, err
+				}
+			}
+			dst = append(dst, ']')
+		}
+	}
+	if (all || (or && m&0x3 != 0) || (and && m&^0x3 == 0)) && len(v.Comments) != 0 {
+		dst = append(dst, `,"comments":`[sep:]...)
+		sep = 0
+		if v.Comments == nil {
+			dst = append(dst, "null"...)
+		} else {
+			if depth+1 > groupjson.DefaultMaxDepth {
+				return dst, groupjson.ErrMaxDepth
+			}
+			dst = append(dst, '[')
+			for i1 := range v.Comments {
+				if i1 > 0 {
+					dst = append(dst, ',')
+				}
+				if dst, err = v.Comments[i1].groupjsonAppend(dst, groups, mode, depth+2); err != nil {
+					return dst, err
+				}
+			}
+			dst = append(dst, ']')
+		}
+	}
+	if (all || (or && m&0x1 != 0) || (and && m&^0x1 == 0)) && v.Related != nil {
+		dst = append(dst, `,"related":`[sep:]...)
+		sep = 0
+		if v.Related == nil {
+			dst = append(dst, "null"...)
+		} else {
+			if dst, err = v.Related.groupjsonAppend(dst, groups, mode, depth+1); err != nil {
+				return dst, err
+			}
+		}
+	}
+	if (all || (or && m&0x3 != 0) || (and && m&^0x3 == 0)) && v.Extra != nil {
+		dst = append(dst, `,"extra":`[sep:]...)
+		sep = 0
+		if dst, err = groupjson.AppendJSON(dst, &v.Extra, groups, mode); err != nil {
+			return dst, err
+		}
+	}
+	if (all || (or && m&0x1 != 0) || (and && m&^0x1 == 0)) && v.Status != "" {
+		dst = append(dst, `,"status":`[sep:]...)
+		sep = 0
+		dst = groupjson.AppendString(dst, string(v.Status))
+	}
+	if all || (or && m&0x2 != 0) || (and && m&^0x2 == 0) {
+		dst = append(dst, `,"quoted":`[sep:]...)
+		sep = 0
+		dst = groupjson.AppendString(dst, string(groupjson.AppendString(nil, v.Quoted)))
+	}
+	if all || (or && m&0x2 != 0) || (and && m&^0x2 == 0) {
+		dst = append(dst, `,"level":`[sep:]...)
+		sep = 0
+		if v.Level == nil {
+			dst = append(dst, "null"...)
+		} else {
+			dst = append(dst, '"')
+			dst = strconv.AppendInt(dst, int64(*v.Level), 10)
+			dst = append(dst, '"')
+		}
+	}
+	if (all || (or && m&0x1 != 0) || (and && m&^0x1 == 0)) && v.Rank != nil && *v.Rank != 0 {
+		dst = append(dst, `,"rank":`[sep:]...)
+		sep = 0
+		if v.Rank == nil {
+			dst = append(dst, "null"...)
+		} else {
+			dst = strconv.AppendInt(dst, int64(*v.Rank), 10)
+		}
+	}
+	if (all || (or && m&0x2 != 0) || (and && m&^0x2 == 0)) && !groupjson.IsZero(&v.Priority) {
+		dst = append(dst, `,"priority":`[sep:]...)
+		sep = 0
+		dst = strconv.AppendInt(dst, int64(v.Priority), 10)
+	}
+	if all {
+		dst = append(dst, `,"note":`[sep:]...)
+		sep = 0
+		dst = groupjson.AppendString(dst, v.Note)
+	}
+	if all || (or && m&0x2 != 0) || (and && m&^0x2 == 0) {
+		dst = append(dst, `,"created_by":`[sep:]...)
+		sep = 0
+		dst = groupjson.AppendString(dst, v.Audit.CreatedBy)
+	}
+	if (all || (or && m&0x2 != 0) || (and && m&^0x2 == 0)) && !groupjson.IsZero(&v.Audit.UpdatedAt) {
+		dst = append(dst, `,"updated_at":`[sep:]...)
+		sep = 0
+		if dst, err = groupjson.AppendJSON(dst, &v.Audit.UpdatedAt, groups, mode); err != nil {
+			return dst, err
+		}
+	}
+	if (all || (or && m&0x3 != 0) || (and && m&^0x3 == 0)) && v.Audit.Revision != 0 {
+		dst = append(dst, `,"revision":`[sep:]...)
+		sep = 0
+		dst = strconv.AppendInt(dst, int64(v.Audit.Revision), 10)
+	}
+	if (all || (or && m&0x4 != 0) || (and && m&^0x4 == 0)) && v.Meta != nil {
+		dst = append(dst, `,"source":`[sep:]...)
+		sep = 0
+		dst = groupjson.AppendString(dst, v.Meta.Source)
+	}
+	if all || (or && m&0x1 != 0) || (and && m&^0x1 == 0) {
+		dst = append(dst, `,"stat_likes":`[sep:]...)
+		sep = 0
+		dst = strconv.AppendInt(dst, int64(v.Stats.Likes), 10)
+	}
+	if (all || (or && m&0x2 != 0) || (and && m&^0x2 == 0)) && v.Stats.Shares != 0 {
+		dst = append(dst, `,"stat_shares":`[sep:]...)
+		sep = 0
+		dst = strconv.AppendInt(dst, int64(v.Stats.Shares), 10)
+	}
+	return append(dst, '}'), nil
+}
+
+// groupjsonCovered 返回 entries 中全部分组都在 m 之列的分组项之并。
+func groupjsonCovered(m uint64, entries ...uint64) uint64 {
+	var c uint64
+	for _, e := range entries {
+		if e&^m == 0 {
+			c |= e
+		}
+	}
+	return c
+}
+
+// groupjsonAppender 本包生成了编码代码的类型。
+type groupjsonAppender interface {
+	groupjsonAppend(dst []byte, groups []string, mode groupjson.GroupMode, depth int) ([]byte, error)
+}
+
+// groupjsonAppendAny 追加类型形参的值，p 为其地址（不可寻址时为值本身）：
+// 实参类型生成了编码代码时直接调用，否则交给运行时。
+func groupjsonAppendAny(dst []byte, p any, groups []string, mode groupjson.GroupMode, depth int) ([]byte, error) {
+	if a, ok := p.(groupjsonAppender); ok {
+		return a.groupjsonAppend(dst, groups, mode, depth)
+	}
+	return groupjson.AppendJSON(dst, p, groups, mode)
+}
@@ -0,0 +1,141 @@
+package model
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JieBaiYou/groupjson"
+	"github.com/JieBaiYou/groupjson/codegen/internal/common"
+)
+
+func articles() []Article {
+	ratio, level, zero, one := float32(0.25), 3, 0, 1
+	return []Article{
+		{},
+		{
+			Audit: common.Audit{CreatedBy: "c", UpdatedAt: time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC), Revision: 2},
+			Meta:  &common.Meta{Source: "s", Title: "shadowed", Origin: "o"}, Stats: Stats{Likes: 3},
+			Rank: &zero, Priority: -1, Origin: "never",
+			Comments: []Comment{{
+				Text: "c", Author: Author{Name: "a", Email: "e"}, Votes: votes{Up: 2, Down: 1},
+				Replies: []*Comment{{Text: "r"}, nil}, ByLang: map[string]Comment{"en": {Text: "en"}},
+				Contact: contact{Email: "x@example.com"}, Source: common.Meta{Source: "s"},
+				Next: &Comment{Text: "n"},
+			}},
+			ID: 1, Title: "a<b>&\"c\"\n", Body: "正文\u2028", Draft: true, Views: 42, Score: math.Copysign(0, -1),
+			Ratio: &ratio, Tags: []string{"x", ""}, Matrix: [][]int{{1, -2}, nil, {}},
+			Counts: map[string]int{"k": 1}, Labels: map[string][]string{"l": {"v"}},
+			Grid: [2]uint8{7, 255}, Raw: []byte("raw"), Author: &Author{Name: "n", Email: "e"},
+			Reviewers: []Author{{Name: "r"}}, Extra: map[string]any{"x": 1.5},
+			Related:   &Page[Author]{Items: []Author{{Name: "p"}}, Total: 1, Next: &Page[Author]{}},
+			Published: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), Status: "live",
+			Quoted: `q"1`, Level: &level, Note: "note", Secret: "s", Ignored: "i",
+		},
+		{Comments: []Comment{}, Meta: &common.Meta{}, Stats: Stats{Shares: 1}, Rank: &one, Priority: 2, Score: 0.5, Labels: map[string][]string{"n": nil}, Counts: map[string]int{}, Tags: []string{}, Reviewers: []Author{}},
+	}
+}
+
+// TestGeneratedMatchesReflection 生成代码与反射编码器在各种分组与模式下输出一致。
+func TestGeneratedMatchesReflection(t *testing.T) {
+	views := [][]string{nil, {"public"}, {"admin"}, {"public", "admin"}, {"admin", "internal"}, {"internal"}, {"other"}, {"public", "other"}}
+	for _, mode := range []groupjson.GroupMode{groupjson.ModeOr, groupjson.ModeAnd} {
+		for _, groups := range views {
+			// WithMaxBytes 不改变输出，但会禁用生成代码，得到纯反射的参照输出
+			ref := groupjson.NewEncoder().WithGroups(groups...).WithGroupMode(mode).WithMaxBytes(math.MaxInt32)
+			list := articles()
+			for i := range list {
+				a := &list[i]
+				want, err := ref.Marshal(a)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got, err := a.AppendGroupJSON(nil, groups, mode)
+				if err != nil || string(got) != string(want) {
+					t.Errorf("%v %v #%d:\n got %s, %v\nwant %s", mode, groups, i, got, err, want)
+				}
+			}
+		}
+	}
+}
+
+// TestGeneratedUsedByRuntime 反射编码器对嵌套在切片中的生成类型自动调用生成代码。
+func TestGeneratedUsedByRuntime(t *testing.T) {
+	list := articles()
+	want, _ := groupjson.NewEncoder().WithGroups("public").WithMaxBytes(math.MaxInt32).Marshal(list)
+	got, err := groupjson.Marshal(list, "public")
+	if err != nil || string(got) != string(want) {
+		t.Errorf("Marshal = %s, %v; want %s", got, err, want)
+	}
+	b, err := list[1].MarshalWithGroups("admin")
+	if err != nil || !strings.Contains(string(b), `"views":"42"`) {
+		t.Errorf("MarshalWithGroups = %s, %v", b, err)
+	}
+
+	nan := Article{Score: math.NaN()}
+	if _, err := nan.AppendGroupJSON(nil, []string{"public"}, groupjson.ModeOr); err == nil {
+		t.Error("NaN: expected error")
+	}
+}
+
+// thread 返回深度为 n 的回复链，最内层的回复带有空的（非 nil）Replies。
+func thread(n int) *Comment {
+	c := &Comment{Text: "leaf", Replies: []*Comment{}}
+	for i := 1; i < n; i++ {
+		c = &Comment{Text: "c", Replies: []*Comment{c}}
+	}
+	return c
+}
+
+// TestGeneratedDepth 递归类型的嵌套层数与反射编码器一致：同样在超过默认上限时返回 ErrMaxDepth。
+func TestGeneratedDepth(t *testing.T) {
+	ref := groupjson.NewEncoder().WithGroups("public").WithMaxBytes(math.MaxInt32)
+	for n := 12; n <= 18; n++ {
+		c := thread(n)
+		want, werr := ref.Marshal(c)
+		if errors.Is(werr, groupjson.ErrMaxDepth) != (n > 16) {
+			t.Fatalf("depth %d: reflection err = %v", n, werr)
+		}
+		got, err := c.MarshalWithGroups("public")
+		if werr == nil && (err != nil || string(got) != string(want)) || werr != nil && !errors.Is(err, groupjson.ErrMaxDepth) {
+			t.Errorf("depth %d: got %s, %v; want %s, %v", n, got, err, want, werr)
+		}
+	}
+
+	// 循环引用以 ErrMaxDepth 结束，而不是无限递归
+	c := &Comment{Text: "loop"}
+	c.Next = c
+	if _, err := c.MarshalWithGroups("admin"); !errors.Is(err, groupjson.ErrMaxDepth) {
+		t.Errorf("cycle: err = %v", err)
+	}
+}
+
+// TestGeneratedGeneric 泛型类型的生成代码与反射编码器一致：实例 Page[Author] 走专用代码，
+// 其他实参走泛型方法，类型形参的值按实参调用生成代码或交给运行时。
+func TestGeneratedGeneric(t *testing.T) {
+	a, zero, one := &Author{Name: "a", Email: "e"}, 0, 1
+	values := []groupjson.GroupEncoderAppender{
+		&Page[Author]{},
+		&Page[Author]{Items: []Author{*a}, First: *a, Best: a, ByKey: map[string]Author{"k": *a}, Total: 1, Next: &Page[Author]{Total: 2}},
+		&Page[int]{Items: []int{1, 2}, Best: &zero, ByKey: map[string]int{"k": 0}},
+		&Page[int]{First: 1, Best: &one, Next: &Page[int]{Items: []int{}}},
+		&Page[*Author]{Items: []*Author{a, nil}, First: a, Best: new(*Author), ByKey: map[string]*Author{"k": nil}},
+		&Page[Comment]{Items: []Comment{{Text: "c", Replies: []*Comment{{Text: "r"}}}}, First: Comment{Next: &Comment{}}},
+		&Page[time.Time]{First: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), Best: &time.Time{}},
+	}
+	for _, groups := range [][]string{nil, {"public"}, {"admin"}} {
+		ref := groupjson.NewEncoder().WithGroups(groups...).WithMaxBytes(math.MaxInt32)
+		for i, v := range values {
+			want, err := ref.Marshal(v)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := v.AppendGroupJSON(nil, groups, groupjson.ModeOr)
+			if err != nil || string(got) != string(want) {
+				t.Errorf("%v #%d:\n got %s, %v\nwant %s", groups, i, got, err, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package codegen
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// header 生成文件的首行，加载时据此识别并忽略已生成的文件。
+const header = "// Code generated by groupjson gen-encoders. DO NOT EDIT.\n"
+
+// loadMode 需要类型信息：字段标签、方法集与声明位置；依赖同样从源码做类型检查，
+// 不依赖构建缓存中的导出数据。
+const loadMode = packages.NeedName | packages.NeedFiles | packages.NeedImports | packages.NeedDeps | packages.NeedTypes
+
+// Load 按包模式（如 "."、"./..."、导入路径）加载包，结构体可以分布在包内多个文件中，
+// 匿名嵌入的其他包的类型同样通过类型信息解析。
+//
+// 已生成的 *_groupjson.go 只保留包声明，使字段改名后生成代码过时也不影响重新生成；
+// 包内其他文件的类型错误（如调用了尚未生成的方法）同样被忽略，只有涉及待生成类型的字段时才报告。
+func (g *Generator) Load(patterns ...string) error {
+	if len(patterns) == 0 {
+		patterns = []string{"."}
+	}
+	cfg := &packages.Config{
+		Mode: loadMode,
+		Dir:  g.Dir,
+		ParseFile: func(fset *token.FileSet, filename string, src []byte) (*ast.File, error) {
+			mode := parser.AllErrors | parser.ParseComments
+			if bytes.HasPrefix(src, []byte(header)) {
+				mode = parser.PackageClauseOnly
+			}
+			return parser.ParseFile(fset, filename, src, mode)
+		},
+	}
+	pkgs, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		return fmt.Errorf("codegen: %w", err)
+	}
+	g.pkgs = g.pkgs[:0]
+	for _, p := range pkgs {
+		for _, e := range p.Errors {
+			if e.Kind != packages.TypeError {
+				return fmt.Errorf("codegen: %s", e)
+			}
+		}
+		if p.Types == nil || p.Name == "main" {
+			continue
+		}
+		g.pkgs = append(g.pkgs, p)
+	}
+	if len(g.pkgs) == 0 {
+		return fmt.Errorf("codegen: no packages matched %s", strings.Join(patterns, " "))
+	}
+	slices.SortFunc(g.pkgs, func(a, b *packages.Package) int { return strings.Compare(a.PkgPath, b.PkgPath) })
+	return nil
+}
+
+// typeError 返回包的第一个类型错误，没有时返回 nil。
+func typeError(p *packages.Package) error {
+	for _, e := range p.Errors {
+		if e.Kind == packages.TypeError {
+			return errors.New(e.Error())
+		}
+	}
+	return nil
+}
+
+// outputPath 返回包的生成文件路径：包目录下的 <包名>_groupjson.go。
+func outputPath(p *packages.Package) string {
+	dir := "."
+	if len(p.GoFiles) > 0 {
+		dir = filepath.Dir(p.GoFiles[0])
+	}
+	return filepath.Join(dir, p.Name+"_groupjson.go")
+}
+
+// packageStructs 返回包中声明的结构体类型，按源码位置（文件名、偏移）排序，使输出稳定。
+func packageStructs(p *packages.Package) []*types.TypeName {
+	var out []*types.TypeName
+	scope := p.Types.Scope()
+	for _, name := range scope.Names() {
+		tn, ok := scope.Lookup(name).(*types.TypeName)
+		if !ok || tn.IsAlias() {
+			continue
+		}
+		if _, ok := tn.Type().Underlying().(*types.Struct); ok {
+			out = append(out, tn)
+		}
+	}
+	slices.SortFunc(out, func(a, b *types.TypeName) int {
+		pa, pb := p.Fset.Position(a.Pos()), p.Fset.Position(b.Pos())
+		if c := strings.Compare(pa.Filename, pb.Filename); c != 0 {
+			return c
+		}
+		return pa.Offset - pb.Offset
+	})
+	return out
+}
@@ -0,0 +1,124 @@
+package groupjson
+
+import (
+	"bytes"
+	"reflect"
+)
+
+// MarshalColumnar 将结构体切片（或数组，元素可为指针）输出为列式 JSON：
+// {"id":[1,2],"name":["a","b"]}。列由分组筛选决定，每列长度与切片长度一致，
+// 因 omitempty、条件、路径规则等被省略的值以及 nil 元素对应位置为 null；计算字段不参与。
+// 适合图表等直接消费列数据的前端，省去转置步骤。非 ErrorFail 的 ErrorPolicy 下跳过或置空了部分值时，
+// 同时返回完整输出与汇总这些问题的 *MultiError。
+func (e Encoder) MarshalColumnar(slice any) ([]byte, error) {
+	rv := reflect.ValueOf(slice)
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil, ErrNilValue
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, ErrInvalidType
+	}
+	et, ok := keyTableElem(rv.Type())
+	if !ok {
+		return nil, ErrInvalidType
+	}
+
+	if err := e.checkTop(); err != nil {
+		return nil, err
+	}
+	ctx := acquireContext(e.opts)
+	defer releaseContext(ctx)
+
+	p := e.getPlan(et, e.schemaFor(et), ctx.groupKey)
+	var cols []*fieldInfo
+	for _, f := range p.fields {
+		if e.tupleColumn(p, f) {
+			cols = append(cols, f)
+		}
+	}
+	bufs := make([]bytes.Buffer, len(cols))
+
+	for i := 0; i < rv.Len(); i++ {
+		el := rv.Index(i)
+		for el.Kind() == reflect.Pointer && !el.IsNil() {
+			el = el.Elem()
+		}
+		ctx.pushPath(PathSegment{Kind: SegmentIndex, Index: i})
+		if err := e.encodeColumnRow(bufs, el, p, cols, ctx, i > 0); err != nil {
+			return nil, withSegment(err, PathSegment{Kind: SegmentIndex, Index: i}, rv.Type().Elem())
+		}
+		ctx.popPath()
+	}
+
+	var out bytes.Buffer
+	e.openTop(&out)
+	out.WriteByte('{')
+	for j, f := range cols {
+		if j > 0 {
+			out.WriteByte(',')
+		}
+		out.Write(f.keyBytes)
+		out.WriteByte('[')
+		out.Write(bufs[j].Bytes())
+		out.WriteByte(']')
+	}
+	out.WriteByte('}')
+	err := e.finishTop(&out, rv, ctx, nil)
+	if err != nil && !isMultiError(err) {
+		return nil, err
+	}
+	e.sample(slice, out.Bytes())
+	return out.Bytes(), err
+}
+
+// encodeColumnRow 将一个元素的各列值追加到对应列缓冲，sep 表示需要先写逗号。
+func (e Encoder) encodeColumnRow(bufs []bytes.Buffer, el reflect.Value, p *plan, cols []*fieldInfo, ctx *encodeContext, sep bool) error {
+	if sep {
+		for j := range bufs {
+			bufs[j].WriteByte(',')
+		}
+	}
+	if el.Kind() == reflect.Pointer {
+		for j := range bufs {
+			bufs[j].WriteString("null")
+		}
+		return nil
+	}
+
+	if err := ctx.incDepth(); err != nil {
+		return err
+	}
+	defer ctx.decDepth()
+	if len(e.opts.Invariants) > 0 {
+		if err := e.checkInvariants(el, ctx); err != nil {
+			return err
+		}
+	}
+
+	for j, f := range cols {
+		buf := &bufs[j]
+		fv, ok, err := e.fieldValue(el, p, f, ctx)
+		if err != nil {
+			return withSegment(err, PathSegment{Kind: SegmentField, Name: f.jsonName}, el.Type().FieldByIndex(f.index).Type)
+		}
+		if !ok {
+			buf.WriteString("null")
+			continue
+		}
+		mark := buf.Len()
+		ctx.pushPath(PathSegment{Kind: SegmentField, Name: f.jsonName})
+		err = e.encodeField(buf, fv, f, ctx)
+		ctx.popPath()
+		if err != nil {
+			if err != errOmit {
+				return withSegment(err, PathSegment{Kind: SegmentField, Name: f.jsonName}, el.Type().FieldByIndex(f.index).Type)
+			}
+			buf.Truncate(mark)
+			buf.WriteString("null")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+// Package compat 在新的运行时编码器之上提供 v1 的 GroupJSON/MarshalWithOptions/MarshalWithGroups 接口，
+// 便于大型代码库逐步迁移：先替换导入路径，再按 Deprecated 注释逐处改写为 groupjson.Encoder。
+//
+// 全部导出标识均带有标准的 "Deprecated:" 段落，staticcheck（SA1019）与 gopls 会在调用处给出提示。
+package compat
+
+import (
+	"github.com/JieBaiYou/groupjson"
+)
+
+// GroupMode 分组匹配模式。
+//
+// Deprecated: 使用 groupjson.GroupMode。
+type GroupMode = groupjson.GroupMode
+
+const (
+	// ModeOr 字段属于任一指定分组即包含。
+	//
+	// Deprecated: 使用 groupjson.ModeOr。
+	ModeOr = groupjson.ModeOr
+	// ModeAnd 字段必须同时属于所有指定分组才包含。
+	//
+	// Deprecated: 使用 groupjson.ModeAnd。
+	ModeAnd = groupjson.ModeAnd
+)
+
+// Options v1 的序列化选项。
+//
+// Deprecated: 使用 groupjson.NewEncoder() 及其 WithXxx 方法。
+type Options struct {
+	// Groups 需要包含的分组
+	Groups []string
+	// GroupMode 分组匹配模式
+	GroupMode GroupMode
+	// TagKey 分组标签名，为空时使用 "groups"
+	TagKey string
+	// TopLevelKey 非空时以该键包裹结果
+	TopLevelKey string
+	// MaxDepth 最大递归深度，<= 0 时使用 groupjson.DefaultMaxDepth
+	MaxDepth int
+	// TruncateDepth 为 true 时沿用 v1 语义：超过 MaxDepth 的值静默输出为 null，
+	// 否则与新编码器一致返回 groupjson.ErrMaxDepth
+	TruncateDepth bool
+	// EscapeHTML 是否转义 HTML 字符
+	EscapeHTML bool
+}
+
+// DefaultOptions 返回 v1 的默认选项，保留 v1 的深度截断行为。
+//
+// Deprecated: 使用 groupjson.DefaultOptions。
+func DefaultOptions() Options {
+	return Options{
+		GroupMode:     ModeOr,
+		TagKey:        groupjson.DefaultTagKey,
+		MaxDepth:      groupjson.DefaultMaxDepth,
+		TruncateDepth: true,
+	}
+}
+
+// Encoder 将 v1 选项转换为等价的 groupjson.Encoder，便于迁移时逐步替换调用处。
+func (o Options) Encoder() groupjson.Encoder {
+	enc := groupjson.NewEncoder().
+		WithGroups(o.Groups...).
+		WithGroupMode(o.GroupMode).
+		WithTopLevelKey(o.TopLevelKey).
+		WithEscapeHTML(o.EscapeHTML)
+	if o.TagKey != "" {
+		enc = enc.WithTagKey(o.TagKey)
+	}
+	if o.MaxDepth > 0 {
+		enc = enc.WithMaxDepth(o.MaxDepth)
+	}
+	if o.TruncateDepth {
+		enc = enc.WithDepthPolicy(groupjson.DepthTruncateNull)
+	}
+	return enc
+}
+
+// GroupJSON v1 的链式构建器。
+//
+// Deprecated: 使用 groupjson.Encoder，其 WithXxx 方法返回新值而非修改接收者。
+type GroupJSON struct {
+	opts Options
+}
+
+// New 创建使用 DefaultOptions 的 GroupJSON。
+//
+// Deprecated: 使用 groupjson.NewEncoder。
+func New() *GroupJSON {
+	return &GroupJSON{opts: DefaultOptions()}
+}
+
+// WithGroups 设置分组。
+//
+// Deprecated: 使用 groupjson.Encoder.WithGroups。
+func (g *GroupJSON) WithGroups(groups ...string) *GroupJSON {
+	g.opts.Groups = groups
+	return g
+}
+
+// WithGroupMode 设置分组匹配模式。
+//
+// Deprecated: 使用 groupjson.Encoder.WithGroupMode。
+func (g *GroupJSON) WithGroupMode(mode GroupMode) *GroupJSON {
+	g.opts.GroupMode = mode
+	return g
+}
+
+// WithTagKey 设置分组标签名。
+//
+// Deprecated: 使用 groupjson.Encoder.WithTagKey。
+func (g *GroupJSON) WithTagKey(key string) *GroupJSON {
+	g.opts.TagKey = key
+	return g
+}
+
+// WithTopLevelKey 设置顶层包装键。
+//
+// Deprecated: 使用 groupjson.Encoder.WithTopLevelKey。
+func (g *GroupJSON) WithTopLevelKey(key string) *GroupJSON {
+	g.opts.TopLevelKey = key
+	return g
+}
+
+// WithMaxDepth 设置最大递归深度。
+//
+// Deprecated: 使用 groupjson.Encoder.WithMaxDepth 与 WithDepthPolicy。
+func (g *GroupJSON) WithMaxDepth(n int) *GroupJSON {
+	g.opts.MaxDepth = n
+	return g
+}
+
+// Marshal 按当前选项序列化 v。
+//
+// Deprecated: 使用 groupjson.Encoder.Marshal。
+func (g *GroupJSON) Marshal(v any) ([]byte, error) {
+	return g.opts.Encoder().Marshal(v)
+}
+
+// MarshalWithOptions 按 opts 序列化 v。
+//
+// Deprecated: 使用 opts.Encoder().Marshal(v)，或直接构建 groupjson.Encoder。
+func MarshalWithOptions(v any, opts Options) ([]byte, error) {
+	return opts.Encoder().Marshal(v)
+}
+
+// MarshalWithGroups 使用默认选项与 groups 序列化 v。
+//
+// Deprecated: 使用 groupjson.NewEncoder().WithGroups(groups...).Marshal(v)。
+func MarshalWithGroups(v any, groups ...string) ([]byte, error) {
+	opts := DefaultOptions()
+	opts.Groups = groups
+	return opts.Encoder().Marshal(v)
+}
@@ -0,0 +1,48 @@
+package compat
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/JieBaiYou/groupjson"
+)
+
+type User struct {
+	ID    int    `json:"id" groups:"public,admin"`
+	Email string `json:"email" groups:"admin"`
+	Next  *User  `json:"next,omitempty" groups:"public"`
+}
+
+func TestAdapterMatchesEncoder(t *testing.T) {
+	u := User{ID: 1, Email: "a@x"}
+	want, err := groupjson.NewEncoder().WithGroups("admin").Marshal(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, fn := range map[string]func() ([]byte, error){
+		"MarshalWithGroups":  func() ([]byte, error) { return MarshalWithGroups(u, "admin") },
+		"MarshalWithOptions": func() ([]byte, error) { return MarshalWithOptions(u, Options{Groups: []string{"admin"}}) },
+		"GroupJSON":          func() ([]byte, error) { return New().WithGroups("admin").Marshal(u) },
+	} {
+		got, err := fn()
+		if err != nil || string(got) != string(want) {
+			t.Errorf("%s = %s, %v; want %s", name, got, err, want)
+		}
+	}
+}
+
+func TestDepthTruncation(t *testing.T) {
+	u := &User{ID: 1, Next: &User{ID: 2, Next: &User{ID: 3}}}
+	got, err := New().WithGroups("public").WithMaxDepth(2).Marshal(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"id":1,"next":{"id":2,"next":null}}`; string(got) != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+
+	_, err = MarshalWithOptions(u, Options{Groups: []string{"public"}, MaxDepth: 2})
+	if !errors.Is(err, groupjson.ErrMaxDepth) {
+		t.Errorf("err = %v, want ErrMaxDepth without TruncateDepth", err)
+	}
+}
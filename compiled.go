@@ -0,0 +1,251 @@
+package groupjson
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"sync"
+)
+
+// encoderFunc 针对某一具体类型预编译的编码函数。v 必须有效且类型与编译时一致，
+// 选项相关的行为（分组、转义、数值格式等）仍在调用时从 e.opts 读取，因此同一函数可被所有配置共享。
+type encoderFunc func(e Encoder, buf *bytes.Buffer, v reflect.Value, ctx *encodeContext) error
+
+// encoderCache 类型 -> 预编译的编码函数。
+var encoderCache cache[reflect.Type, encoderFunc]
+
+// encoderFor 返回类型 t 的编码函数，首次使用时编译并缓存。
+// 类型级别的分派（是否实现各类 Marshaler、按 Kind 选择分支）只在编译时做一次，
+// 编码时不再对每个值做接口断言——后者需要 Interface()/Addr()，是反射路径上主要的分配来源。
+func encoderFor(t reflect.Type) encoderFunc {
+	if f, ok := encoderCache.Load(t); ok {
+		return f
+	}
+	// 先存入一个间接函数，使递归类型（如 type Tree []Tree）在编译期间能引用自身
+	var (
+		wg sync.WaitGroup
+		f  encoderFunc
+	)
+	wg.Add(1)
+	indirect, loaded := encoderCache.LoadOrStore(t, func(e Encoder, buf *bytes.Buffer, v reflect.Value, ctx *encodeContext) error {
+		wg.Wait()
+		return f(e, buf, v, ctx)
+	})
+	if loaded {
+		return indirect
+	}
+	f = compileEncoder(t)
+	wg.Done()
+	encoderCache.Store(t, f)
+	return f
+}
+
+// compileEncoder 为类型 t 生成编码函数。需要逐值判断的类型（接口、自定义序列化、sync.Map、
+// 迭代器、分组 map、[]byte、通道等）交给 encodeValue 解释执行，行为与编译前完全一致。
+func compileEncoder(t reflect.Type) encoderFunc {
+	if dynamicType(t) {
+		return Encoder.encodeValue
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		elem := encoderFor(t.Elem())
+		return func(e Encoder, buf *bytes.Buffer, v reflect.Value, ctx *encodeContext) error {
+			if v.IsNil() {
+				buf.WriteString("null")
+				return nil
+			}
+			return elem(e, buf, v.Elem(), ctx)
+		}
+	case reflect.Struct:
+		return Encoder.encodeStruct
+	case reflect.Map, reflect.Slice, reflect.Array:
+		enc := compileContainer(t)
+		if backendPlain(t) {
+			return withBackend(enc)
+		}
+		return enc
+	case reflect.String:
+		return withBackendString(func(e Encoder, buf *bytes.Buffer, v reflect.Value, _ *encodeContext) error {
+			e.writeString(buf, v.String())
+			return nil
+		})
+	case reflect.Bool:
+		return func(_ Encoder, buf *bytes.Buffer, v reflect.Value, _ *encodeContext) error {
+			buf.WriteString(strconv.FormatBool(v.Bool()))
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(e Encoder, buf *bytes.Buffer, v reflect.Value, _ *encodeContext) error {
+			n := v.Int()
+			if e.opts.Int64AsString && (n > maxSafeInt || n < -maxSafeInt) {
+				return e.encodeScalar(buf, v)
+			}
+			var scratch [24]byte
+			buf.Write(strconv.AppendInt(scratch[:0], n, 10))
+			return nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return func(e Encoder, buf *bytes.Buffer, v reflect.Value, _ *encodeContext) error {
+			n := v.Uint()
+			if e.opts.Int64AsString && n > maxSafeInt {
+				return e.encodeScalar(buf, v)
+			}
+			var scratch [24]byte
+			buf.Write(strconv.AppendUint(scratch[:0], n, 10))
+			return nil
+		}
+	case reflect.Float32, reflect.Float64:
+		return func(e Encoder, buf *bytes.Buffer, v reflect.Value, ctx *encodeContext) error {
+			if err := e.encodeScalar(buf, v); err != nil {
+				return ctx.valueFailed(buf, v, err)
+			}
+			return nil
+		}
+	}
+	return Encoder.encodeValue
+}
+
+// compileContainer 为切片、数组或 map 类型生成编码函数，基本类型元素使用快速路径。
+func compileContainer(t reflect.Type) encoderFunc {
+	if t.Kind() == reflect.Map {
+		if fast := compilePrimitiveMap(t); fast != nil {
+			return fast
+		}
+		return Encoder.encodeMap
+	}
+	if fast := compilePrimitiveSlice(t); fast != nil {
+		return fast
+	}
+	return Encoder.encodeSlice
+}
+
+// dynamicType 判断类型 t 的编码方式是否取决于具体的值（或值是否可寻址），无法在编译时确定。
+func dynamicType(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Interface, reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		return true
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return true
+		}
+	case reflect.Map:
+		if t.Implements(groupedMapType) {
+			return true
+		}
+	}
+	if t == syncMapType || t.Kind() == reflect.Pointer {
+		// 指针本身的方法集由其元素决定，解引用后再判断
+		return t == syncMapType
+	}
+	// 指针接收者的方法只在值可寻址时可用，交给 encodeValue 逐值判断
+	return hasMarshaler(t) || hasAppender(t)
+}
+
+// hasMarshaler 判断 t（或 *t）是否实现 GroupMarshaler、json.Marshaler 或 encoding.TextMarshaler。
+func hasMarshaler(t reflect.Type) bool {
+	pt := reflect.PointerTo(t)
+	return pt.Implements(groupMarshalerType) || pt.Implements(jsonMarshalerType) || pt.Implements(textMarshalerType)
+}
+
+// Compiled 绑定到某一根类型的预编译编码器，由 Encoder.Compile 生成，只读、可并发使用。
+// 字段计划、分组位集合、顶层包装的开头字节与根类型的编码函数都在编译时确定，
+// 每次调用只剩值本身的编码，适合热点接口。
+type Compiled struct {
+	e Encoder
+	// t 根类型
+	t reflect.Type
+	// enc 根类型的编码函数
+	enc encoderFunc
+	// groupKey、groups 预先计算的计划缓存键与分组位集合
+	groupKey string
+	groups   groupQuery
+	// head 顶层包装（Envelope 或 TopLevelKey）的开头字节
+	head []byte
+}
+
+// Compile 以样本值 v 的类型为根类型，按当前配置生成预编译编码器，并预先构建其（及嵌套结构体的）字段计划。
+// 开启 WithStrictGroups 时分组校验也在此完成。编译后 Encoder 的配置被冻结，修改需重新编译；
+// Envelope.Meta 若随请求变化，应使用普通的 Encoder。
+func (e Encoder) Compile(v any) (*Compiled, error) {
+	t := reflect.TypeOf(v)
+	if t == nil {
+		return nil, ErrInvalidType
+	}
+	return e.compileRoot(t)
+}
+
+// compileRoot 为根类型 t 生成预编译编码器。t 为接口类型时（见 TypedEncoder）只预先计算分组，
+// 编码函数按每次的动态类型查找。
+func (e Encoder) compileRoot(t reflect.Type) (*Compiled, error) {
+	if e.opts.StrictGroups {
+		if err := e.checkGroups(); err != nil {
+			return nil, err
+		}
+	}
+	var enc encoderFunc
+	if t.Kind() != reflect.Interface {
+		enc = encoderFor(t)
+		e.prerenderType(t, map[reflect.Type]struct{}{})
+	}
+	var head bytes.Buffer
+	e.openTop(&head)
+	return &Compiled{
+		e:        e,
+		t:        t,
+		enc:      enc,
+		groupKey: planGroupKey(e.opts),
+		groups:   compileGroups(e.opts),
+		head:     head.Bytes(),
+	}, nil
+}
+
+// Marshal 与 Encoder.Marshal 相同；v 的类型必须与编译时的根类型一致，否则返回 ErrInvalidType。
+func (c *Compiled) Marshal(v any) ([]byte, error) {
+	buf := getBuffer(c.e.opts.BufferSize)
+	defer putBuffer(buf)
+
+	err := c.encodeTop(buf, v)
+	if err != nil && !isMultiError(err) {
+		return nil, err
+	}
+	c.e.sample(v, buf.Bytes())
+	return append([]byte(nil), buf.Bytes()...), err
+}
+
+// Encode 与 Encoder.Encode 相同；v 的类型必须与编译时的根类型一致，否则返回 ErrInvalidType。
+func (c *Compiled) Encode(w io.Writer, v any) error {
+	buf := getBuffer(c.e.opts.BufferSize)
+	defer putBuffer(buf)
+
+	err := c.encodeTop(buf, v)
+	if err != nil && !isMultiError(err) {
+		return err
+	}
+	c.e.sample(v, buf.Bytes())
+	if _, werr := w.Write(buf.Bytes()); werr != nil {
+		return werr
+	}
+	return err
+}
+
+func (c *Compiled) encodeTop(buf *bytes.Buffer, v any) error {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() || rv.Type() != c.t {
+		return fmt.Errorf("%w: compiled for %v, got %T", ErrInvalidType, c.t, v)
+	}
+	return c.encodeRoot(buf, rv)
+}
+
+// encodeRoot 编码类型已确认的根值 rv。
+func (c *Compiled) encodeRoot(buf *bytes.Buffer, rv reflect.Value) error {
+	ctx := acquireContextFor(c.e.opts, c.groupKey, c.groups)
+	defer releaseContext(ctx)
+
+	buf.Write(c.head)
+	if c.enc == nil {
+		return c.e.finishTop(buf, rv, ctx, c.e.encode(buf, rv, ctx))
+	}
+	return c.e.finishTop(buf, rv, ctx, c.e.encodeWith(buf, rv, ctx, c.enc))
+}
@@ -0,0 +1,48 @@
+package groupjson
+
+import (
+	"context"
+	"io"
+)
+
+// groupsKey 请求上下文中保存分组的键类型。
+type groupsKey struct{}
+
+// ContextWithGroups 返回携带分组的子上下文，通常在鉴权中间件中调用。
+func ContextWithGroups(ctx context.Context, groups ...string) context.Context {
+	return context.WithValue(ctx, groupsKey{}, append([]string(nil), groups...))
+}
+
+// GroupsFromContext 取出 ContextWithGroups 保存的分组。
+func GroupsFromContext(ctx context.Context) ([]string, bool) {
+	groups, ok := ctx.Value(groupsKey{}).([]string)
+	return groups, ok
+}
+
+// WithGroupResolver 设置从上下文解析分组的函数，供 MarshalContext/EncodeContext 使用。
+func (e Encoder) WithGroupResolver(fn func(ctx context.Context) []string) Encoder {
+	e.opts.GroupResolver = fn
+	return e
+}
+
+// resolveGroups 按 GroupResolver、上下文分组、静态分组的优先级确定本次分组。
+func (e Encoder) resolveGroups(ctx context.Context) []string {
+	if e.opts.GroupResolver != nil {
+		return e.opts.GroupResolver(ctx)
+	}
+	if groups, ok := GroupsFromContext(ctx); ok {
+		return groups
+	}
+	return e.opts.Groups
+}
+
+// MarshalContext 使用从 ctx 解析出的分组输出 JSON 字节。
+func (e Encoder) MarshalContext(ctx context.Context, v any) ([]byte, error) {
+	return e.MarshalGroups(v, e.resolveGroups(ctx)...)
+}
+
+// EncodeContext 使用从 ctx 解析出的分组写入 io.Writer。
+func (e Encoder) EncodeContext(ctx context.Context, w io.Writer, v any) error {
+	e.opts.Groups = e.resolveGroups(ctx)
+	return e.Encode(w, v)
+}
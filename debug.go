@@ -0,0 +1,51 @@
+package groupjson
+
+import (
+	"log/slog"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// DebugEnv 设置为 "1" 时开启调试输出：每个类型首次编码时打印生效选项与 schema 摘要。
+const DebugEnv = "GROUPJSON_DEBUG"
+
+// debugEnabled 进程内只读取一次环境变量。
+var debugEnabled = sync.OnceValue(func() bool { return os.Getenv(DebugEnv) == "1" })
+
+// debugSeen 已输出过调试信息的类型。
+var debugSeen cache[schemaKey, struct{}]
+
+// WithDebugLogger 注入调试输出使用的 logger，未设置时使用 slog.Default()。
+// 仅在环境变量 GROUPJSON_DEBUG=1 时生效。
+func (e Encoder) WithDebugLogger(l *slog.Logger) Encoder { e.opts.DebugLogger = l; return e }
+
+// debugType 在类型首次编码时输出生效选项、分组与 schema 摘要。
+func (e Encoder) debugType(t reflect.Type, sch *schema) {
+	if _, loaded := debugSeen.LoadOrStore(e.schemaKey(t), struct{}{}); loaded {
+		return
+	}
+	l := e.opts.DebugLogger
+	if l == nil {
+		l = slog.Default()
+	}
+	fields := make([]string, 0, len(sch.fields))
+	for _, f := range sch.fields {
+		fields = append(fields, f.jsonName+"["+strings.Join(f.groups, ",")+"]")
+	}
+	l.Info("groupjson: first encode of type",
+		"type", t.String(),
+		"groups", e.opts.Groups,
+		"mode", e.opts.Mode.String(),
+		"tag_key", e.opts.TagKey,
+		"max_depth", e.opts.MaxDepth,
+		"depth_policy", e.opts.DepthPolicy,
+		"cycle_handling", e.opts.CycleHandling,
+		"escape_html", e.opts.EscapeHTML,
+		"sort_keys", e.opts.SortKeys,
+		"allow_fields", e.opts.AllowFields,
+		"deny_fields", e.opts.DenyFields,
+		"fields", fields,
+	)
+}
@@ -0,0 +1,75 @@
+package groupjson
+
+import (
+	"bytes"
+	"maps"
+	"reflect"
+	"strconv"
+)
+
+// DepthTagKey 字段级深度限制标签，如 gjdepth:"2"：字段值以下最多再嵌套 2 层容器（对象/数组各计一层），
+// 超出部分按 DepthPolicy 处理，不影响文档其余部分的 MaxDepth。
+const DepthTagKey = "gjdepth"
+
+// WithMaxDepthFor 限制类型 t 的自身嵌套层数：编码路径上同时存在超过 n 个 t 实例时，
+// 更深的实例按 DepthPolicy 处理。适合截断评论树等递归结构而不收紧整体 MaxDepth。
+// t 为指针类型时按其元素类型计；n <= 0 时移除该类型的限制。
+func (e Encoder) WithMaxDepthFor(t reflect.Type, n int) Encoder {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	m := maps.Clone(e.opts.TypeDepths)
+	if m == nil {
+		m = map[reflect.Type]int{}
+	}
+	if n <= 0 {
+		delete(m, t)
+	} else {
+		m[t] = n
+	}
+	e.opts.TypeDepths = m
+	return e
+}
+
+// parseDepthTag 解析 gjdepth 标签，缺失或非正整数时返回 0。
+func parseDepthTag(sf reflect.StructField) int {
+	n, err := strconv.Atoi(sf.Tag.Get(DepthTagKey))
+	if err != nil || n <= 0 {
+		return 0
+	}
+	return n
+}
+
+// encodeFieldLimited 在字段级深度限制下编码字段值（仅在限制比外层更严时调用），结束后恢复外层的深度上限。
+func (e Encoder) encodeFieldLimited(buf *bytes.Buffer, fv reflect.Value, f *fieldInfo, ctx *encodeContext) error {
+	saved := ctx.maxDepth
+	ctx.maxDepth = ctx.depth + f.maxDepth
+	err := e.encodeField(buf, fv, f, ctx)
+	ctx.maxDepth = saved
+	return err
+}
+
+// enterType 记录进入一个受 WithMaxDepthFor 限制的类型实例；超出限制时按 DepthPolicy
+// 返回 ErrMaxDepth 或 errOmit，ok 为 true 时调用方需在结束时调用 leaveType。
+func (c *encodeContext) enterType(t reflect.Type) (ok bool, err error) {
+	n, limited := c.opts.TypeDepths[t]
+	if !limited {
+		return false, nil
+	}
+	if c.typeDepth == nil {
+		c.typeDepth = map[reflect.Type]int{}
+	}
+	if c.typeDepth[t] >= n {
+		if c.opts.DepthPolicy == DepthError {
+			return false, ErrMaxDepth
+		}
+		return false, errOmit
+	}
+	c.typeDepth[t]++
+	return true, nil
+}
+
+// leaveType 离开 enterType 记录的类型实例。
+func (c *encodeContext) leaveType(t reflect.Type) {
+	c.typeDepth[t]--
+}
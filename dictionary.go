@@ -0,0 +1,52 @@
+package groupjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+)
+
+// KeyDictionary 为样本值的类型（递归包含嵌套结构体、切片、map 元素类型）
+// 收集当前分组下会输出的全部 `"key":` 字节序列并拼接，可作为 zstd 等压缩器的原始字典。
+//
+// 键顺序约定：结构体字段始终按 schema 声明顺序输出（匿名嵌入字段按广度优先提升），
+// 同类型对象的键序在所有输出中保持一致；map 键需配合 WithSortKeys(true) 才稳定。
+// 字典按同样的顺序生成，确保与实际输出的字节片段对齐。
+func (e Encoder) KeyDictionary(samples ...any) []byte {
+	var out bytes.Buffer
+	seen := map[reflect.Type]struct{}{}
+	for _, s := range samples {
+		e.collectKeys(&out, reflect.TypeOf(s), seen)
+	}
+	return out.Bytes()
+}
+
+var jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+
+func (e Encoder) collectKeys(out *bytes.Buffer, t reflect.Type, seen map[reflect.Type]struct{}) {
+	for t != nil {
+		switch t.Kind() {
+		case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+			t = t.Elem()
+			continue
+		}
+		break
+	}
+	// 自定义序列化的类型输出不受 schema 约束，不参与字典
+	if t == nil || t.Kind() != reflect.Struct || reflect.PointerTo(t).Implements(jsonMarshalerType) {
+		return
+	}
+	if _, ok := seen[t]; ok {
+		return
+	}
+	seen[t] = struct{}{}
+
+	sch := e.schemaFor(t)
+	for _, f := range sch.fields {
+		if f.never || (len(e.opts.Groups) > 0 && !e.includeField(f.groups)) {
+			continue
+		}
+		out.Write(f.keyBytes)
+		e.collectKeys(out, t.FieldByIndex(f.index).Type, seen)
+	}
+}
@@ -0,0 +1,72 @@
+package groupjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+)
+
+// DiffViews 使用默认配置比较 v 在两组分组下的可见路径，见 Encoder.DiffViews。
+func DiffViews(v any, groupsA, groupsB []string) (added, removed []string, err error) {
+	return NewEncoder().DiffViews(v, groupsA, groupsB)
+}
+
+// DiffViews 返回 v 在 groupsB 视图中可见而 groupsA 中不可见的路径（added），以及相反的路径（removed），
+// 路径形如 users[0].email 并按字典序排列。便于安全审查确认某个视图（如 admin）比另一个多暴露了哪些数据。
+// 只比较键是否出现，脱敏导致的取值差异不计入。
+func (e Encoder) DiffViews(v any, groupsA, groupsB []string) (added, removed []string, err error) {
+	a, err := e.viewPaths(v, groupsA)
+	if err != nil {
+		return nil, nil, err
+	}
+	b, err := e.viewPaths(v, groupsB)
+	if err != nil {
+		return nil, nil, err
+	}
+	for p := range b {
+		if _, ok := a[p]; !ok {
+			added = append(added, p)
+		}
+	}
+	for p := range a {
+		if _, ok := b[p]; !ok {
+			removed = append(removed, p)
+		}
+	}
+	slices.Sort(added)
+	slices.Sort(removed)
+	return added, removed, nil
+}
+
+// viewPaths 编码 v 的一个视图并收集其中全部对象键的路径。
+func (e Encoder) viewPaths(v any, groups []string) (map[string]struct{}, error) {
+	b, err := e.MarshalGroups(v, groups...)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	out := map[string]struct{}{}
+	collectPaths(doc, nil, out)
+	return out, nil
+}
+
+// collectPaths 递归记录 doc 中每个对象键（含中间对象）的路径。
+func collectPaths(doc any, path Path, out map[string]struct{}) {
+	switch x := doc.(type) {
+	case map[string]any:
+		for k, val := range x {
+			p := append(path, PathSegment{Kind: SegmentKey, Name: k})
+			out[p.String()] = struct{}{}
+			collectPaths(val, p, out)
+		}
+	case []any:
+		for i, val := range x {
+			collectPaths(val, append(path, PathSegment{Kind: SegmentIndex, Index: i}), out)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package groupjson
+
+import "reflect"
+
+// typeNames 已注册的具体类型 -> 判别名。
+var typeNames cache[reflect.Type, string]
+
+// RegisterType 为结构体类型 T 注册判别名，配合 WithTypeDiscriminator 使用：
+//
+//	groupjson.RegisterType[Circle]("circle")
+//	groupjson.RegisterType[Square]("square")
+//
+// T 以值或指针形式存放在接口中时均可识别。重复注册时覆盖。
+func RegisterType[T any](name string) {
+	typeNames.Store(reflect.TypeFor[T](), name)
+}
+
+// WithTypeDiscriminator 开启后，接口类型的字段、切片元素与 map 值若持有已注册的结构体类型，
+// 按具体类型进行分组筛选，并在对象开头注入 key（如 "type"）说明具体类型，
+// 让客户端区分收到的变体。结构体自身与 key 同名的字段不再输出；未注册的类型保持原样。
+func (e Encoder) WithTypeDiscriminator(key string) Encoder {
+	e.opts.TypeDiscriminator = key
+	return e
+}
+
+// discriminated 解引用接口中的值，若为已注册的结构体类型则返回结构体值与判别名。
+// 自定义序列化的类型走各自的编码路径，不注入判别键。
+func discriminated(v reflect.Value) (reflect.Value, string, bool) {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return v, "", false
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct || opaqueSchemaType(v.Type()) {
+		return v, "", false
+	}
+	name, ok := typeNames.Load(v.Type())
+	return v, name, ok
+}
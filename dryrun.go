@@ -0,0 +1,107 @@
+package groupjson
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Plan 使用默认配置返回 v 的类型在 groups 下可能输出的字段路径，见 Encoder.Plan。
+func Plan(v any, groups ...string) ([]string, error) {
+	return NewEncoder().WithGroups(groups...).Plan(v)
+}
+
+// Plan 只遍历类型的字段计划而不编码任何值，返回当前分组下可能输出的 JSON 路径（按输出顺序，含中间对象），
+// 如 ["id", "address", "address.city", "orders[*].total", "meta.*"]。切片元素写作 [*]、map 的值写作 *，
+// 与 WithAllowFields/WithDenyFields 的模式语法一致，可直接用于测试断言、策略评审或生成 fields 文档。
+// 带 omitempty 或 if 条件的字段取决于运行期的值，同样列出；自引用类型在第二次出现处停止展开。
+func (e Encoder) Plan(v any) ([]string, error) {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, ErrInvalidType
+	}
+	var out []string
+	e.planPaths(t, nil, map[reflect.Type]bool{}, &out)
+	return out, nil
+}
+
+// planPaths 将结构体 t 在 path 下可能输出的字段路径追加到 out；active 为展开中的类型，用于截断自引用。
+func (e Encoder) planPaths(t reflect.Type, path Path, active map[reflect.Type]bool, out *[]string) {
+	if active[t] || len(path) >= e.opts.MaxDepth {
+		return
+	}
+	active[t] = true
+	defer delete(active, t)
+
+	sch := e.schemaFor(t)
+	p := e.getPlan(t, sch, planGroupKey(e.opts))
+	for _, f := range p.fields {
+		if !p.filtered && f.never {
+			continue
+		}
+		fp := append(path[:len(path):len(path)], PathSegment{Kind: SegmentField, Name: f.jsonName})
+		include := p.filtered || len(e.opts.Groups) == 0 || e.includeField(f.groups)
+		if matchAnyPattern(e.opts.denyPatterns, fp) {
+			include = false
+		} else if !include && matchAnyPattern(e.opts.allowPatterns, fp) {
+			include = true
+		}
+		if !include {
+			continue
+		}
+		*out = append(*out, planPathString(fp))
+		e.planValue(t.FieldByIndex(f.index).Type, fp, active, out)
+	}
+	if list, ok := virtualFields.Load(t); ok {
+		for _, vf := range list {
+			if len(e.opts.Groups) == 0 || e.includeField(vf.groups) {
+				*out = append(*out, planPathString(append(path[:len(path):len(path)], PathSegment{Kind: SegmentField, Name: vf.name})))
+			}
+		}
+	}
+}
+
+// planValue 展开字段值类型中的容器与嵌套结构体；自定义序列化的类型视为叶子。
+func (e Encoder) planValue(t reflect.Type, path Path, active map[reflect.Type]bool, out *[]string) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == timeType || t == syncMapType ||
+		t.Implements(groupMarshalerType) || reflect.PointerTo(t).Implements(groupMarshalerType) ||
+		t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType) ||
+		t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType) {
+		return
+	}
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			return
+		}
+		// Index 为 -1 的下标段只匹配 [*] 模式，指定下标的规则取决于运行期的值
+		e.planValue(t.Elem(), append(path, PathSegment{Kind: SegmentIndex, Index: -1}), active, out)
+	case reflect.Map:
+		elem := append(path, PathSegment{Kind: SegmentKey, Name: "*"})
+		*out = append(*out, planPathString(elem))
+		e.planValue(t.Elem(), elem, active, out)
+	case reflect.Struct:
+		e.planPaths(t, path, active, out)
+	}
+}
+
+// planPathString 与 Path.String 相同，但将占位下标写作 [*]。
+func planPathString(p Path) string {
+	var sb strings.Builder
+	for i, s := range p {
+		if s.Kind == SegmentIndex && s.Index < 0 {
+			sb.WriteString("[*]")
+			continue
+		}
+		if i > 0 && (s.Kind == SegmentField || s.Kind == SegmentKey && !strings.ContainsAny(s.Name, ".[]")) {
+			sb.WriteByte('.')
+		}
+		sb.WriteString(Path{s}.String())
+	}
+	return sb.String()
+}
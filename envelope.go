@@ -0,0 +1,117 @@
+package groupjson
+
+import (
+	"bytes"
+	"reflect"
+	"slices"
+	"strconv"
+)
+
+// Envelope 描述顶层响应信封，如 {"data":…,"meta":{"count":2,"generated_at":…}}。
+// 数据与 meta 在同一次编码中写出，无需先序列化数据再二次包装。
+type Envelope struct {
+	// DataKey 数据所在的键，默认 "data"
+	DataKey string
+	// MetaKey 元信息所在的键，默认 "meta"；meta 为空时整体省略
+	MetaKey string
+	// Meta 附加的元信息，按键名排序输出，其中的结构体同样应用分组筛选
+	Meta map[string]any
+	// CountKey 非空时在 meta 中以该键写入元素个数（数据为切片、数组或 map 时）；
+	// Meta 中已有同名键时以 Meta 为准
+	CountKey string
+}
+
+// WithEnvelope 以 env 包裹顶层输出，设置后 TopLevelKey 不再生效。
+// Meta 常随请求变化（如生成时间），可在每次请求时基于共享的 Encoder 派生。
+func (e Encoder) WithEnvelope(env Envelope) Encoder { e.opts.Envelope = &env; return e }
+
+// openTop 写入顶层包装的开头（Envelope 或 TopLevelKey）。
+func (e Encoder) openTop(buf *bytes.Buffer) {
+	key := e.opts.TopLevelKey
+	if env := e.opts.Envelope; env != nil {
+		key = env.DataKey
+		if key == "" {
+			key = "data"
+		}
+	}
+	if key == "" {
+		return
+	}
+	buf.WriteByte('{')
+	e.writeString(buf, key)
+	buf.WriteByte(':')
+}
+
+// closeTop 写入顶层包装的结尾，count < 0 表示元素个数未知。
+func (e Encoder) closeTop(buf *bytes.Buffer, count int, ctx *encodeContext) error {
+	env := e.opts.Envelope
+	if env == nil {
+		if e.opts.TopLevelKey != "" {
+			buf.WriteByte('}')
+		}
+		return nil
+	}
+	keys := make([]string, 0, len(env.Meta))
+	for k := range env.Meta {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	_, hasCount := env.Meta[env.CountKey]
+	writeCount := env.CountKey != "" && count >= 0 && !hasCount
+	if len(keys) > 0 || writeCount {
+		metaKey := env.MetaKey
+		if metaKey == "" {
+			metaKey = "meta"
+		}
+		buf.WriteByte(',')
+		e.writeString(buf, metaKey)
+		buf.WriteString(":{")
+		first := true
+		if writeCount {
+			e.writeString(buf, env.CountKey)
+			buf.WriteByte(':')
+			buf.WriteString(strconv.Itoa(count))
+			first = false
+		}
+		ctx.pushPath(PathSegment{Kind: SegmentKey, Name: metaKey})
+		defer ctx.popPath()
+		for _, k := range keys {
+			mark, wasFirst := buf.Len(), first
+			if !first {
+				buf.WriteByte(',')
+			}
+			first = false
+			e.writeString(buf, k)
+			buf.WriteByte(':')
+			ctx.pushPath(PathSegment{Kind: SegmentKey, Name: k})
+			err := e.encode(buf, reflect.ValueOf(env.Meta[k]), ctx)
+			ctx.popPath()
+			if err != nil {
+				if err != errOmit {
+					err = withSegment(err, PathSegment{Kind: SegmentKey, Name: k}, reflect.TypeOf(env.Meta[k]))
+					return withSegment(err, PathSegment{Kind: SegmentKey, Name: metaKey}, reflect.TypeOf(env.Meta))
+				}
+				buf.Truncate(mark)
+				first = wasFirst
+			}
+		}
+		buf.WriteByte('}')
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+// topCount 返回顶层数据的元素个数，非集合类型返回 -1。
+func topCount(v reflect.Value) int {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return -1
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return v.Len()
+	}
+	return -1
+}
@@ -2,6 +2,8 @@ package groupjson
 
 import (
 	"errors"
+	"fmt"
+	"reflect"
 )
 
 // 错误常量
@@ -12,4 +14,95 @@ var (
 	ErrCircularReference = errors.New("groupjson: circular reference detected")
 	ErrUnsupportedType   = errors.New("groupjson: unsupported type for serialization")
 	ErrNonStringMapKey   = errors.New("groupjson: map key is not string type")
+	ErrUnknownMask       = errors.New("groupjson: unknown mask function")
+	ErrUnknownCondition  = errors.New("groupjson: unknown condition field")
+	ErrInvalidNullAs     = errors.New("groupjson: nullas tag is not a valid JSON literal")
+	ErrInvalidMode       = errors.New("groupjson: invalid group mode")
+	ErrInvalidSchema     = errors.New("groupjson: invalid schema")
+	ErrInvalidExample    = errors.New("groupjson: invalid example tag")
+	ErrMaxBytes          = errors.New("groupjson: output exceeded maximum size")
+	ErrChannelLimit      = errors.New("groupjson: channel exceeded maximum items")
+	ErrUnknownGroup      = errors.New("groupjson: unregistered group")
 )
+
+// errOmit 内部哨兵：DepthTruncateOmit/CycleOmit 下通知上层容器省略当前成员，不会返回给调用方。
+var errOmit = errors.New("groupjson: member omitted by policy")
+
+// EncodeError 描述编码失败的位置：出错值的路径与类型，Cause 为原始错误（通常是上面的哨兵错误
+// 或自定义 Marshaler 返回的错误），可用 errors.Is/As 判断。根值本身的错误（如 ErrNilValue）不包装。
+type EncodeError struct {
+	// Path 出错值的路径，如 article.comments[2].time
+	Path Path
+	// FieldType 出错值（字段、元素或 map 值）声明的 Go 类型，未知时为 nil
+	FieldType reflect.Type
+	// Cause 原始错误
+	Cause error
+}
+
+func (e *EncodeError) Error() string {
+	return fmt.Sprintf("%v (at %s, type %v)", e.Cause, e.Path, e.FieldType)
+}
+
+func (e *EncodeError) Unwrap() error { return e.Cause }
+
+// withSegment 为向上传递的编码错误补充位置：首次包装时记录出错值的类型 t，
+// 之后每经过一层容器在路径前插入该层的段，因此热路径无需维护路径。
+// errOmit 与自带路径的 *InvariantError 原样返回。
+func withSegment(err error, seg PathSegment, t reflect.Type) error {
+	switch x := err.(type) {
+	case *EncodeError:
+		x.Path = append(Path{seg}, x.Path...)
+		return x
+	case *InvariantError:
+		return err
+	}
+	if err == errOmit {
+		return err
+	}
+	return &EncodeError{Path: Path{seg}, FieldType: t, Cause: err}
+}
+
+// valueType 返回 v 的类型，无效值返回 nil。
+func valueType(v reflect.Value) reflect.Type {
+	if !v.IsValid() {
+		return nil
+	}
+	return v.Type()
+}
+
+// MultiError 汇总非 ErrorFail 的 ErrorPolicy 下被跳过或置空的全部值，与（不完整的）输出一同返回，
+// 调用方可照常响应，同时一次记录所有出错字段。errors.Is/As 会逐个检查其中的错误。
+type MultiError struct {
+	// Errors 按编码顺序排列的问题
+	Errors []*EncodeError
+}
+
+func (m *MultiError) Error() string {
+	if len(m.Errors) == 1 {
+		return m.Errors[0].Error()
+	}
+	return fmt.Sprintf("%v (and %d more errors)", m.Errors[0], len(m.Errors)-1)
+}
+
+// Unwrap 返回全部错误，供 errors.Is/As 遍历。
+func (m *MultiError) Unwrap() []error {
+	out := make([]error, len(m.Errors))
+	for i, e := range m.Errors {
+		out[i] = e
+	}
+	return out
+}
+
+// multiError 返回汇总 errs 的 *MultiError，没有问题时返回 nil。
+func multiError(errs []*EncodeError) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return &MultiError{Errors: errs}
+}
+
+// isMultiError 判断 err 是否仅为汇总的非致命错误（输出仍然有效）。
+func isMultiError(err error) bool {
+	_, ok := err.(*MultiError)
+	return ok
+}
@@ -0,0 +1,86 @@
+package groupjson
+
+import (
+	"bytes"
+	"unicode/utf8"
+)
+
+const hexDigits = "0123456789abcdef"
+
+// safeSet ASCII 字符能否不经转义直接写入 JSON 字符串，htmlSafeSet 额外转义 <、>、&。
+// 与 encoding/json 的同名表一致。
+var safeSet, htmlSafeSet = func() (safe, html [utf8.RuneSelf]bool) {
+	for c := 0x20; c < utf8.RuneSelf; c++ {
+		safe[c] = c != '"' && c != '\\'
+		html[c] = safe[c] && c != '<' && c != '>' && c != '&'
+	}
+	return safe, html
+}()
+
+// appendString 将 s 作为带引号的 JSON 字符串追加到 buf，逐字节与 encoding/json 一致：
+// 控制字符使用 \b \f \n \r \t 或 \u00XX，非法 UTF-8 替换为 U+FFFD，U+2028/U+2029 始终转义
+// （JSONP 安全），escapeHTML 时 <、>、& 转义为 \u003c 等。
+func appendString(buf *bytes.Buffer, s string, escapeHTML bool) {
+	// 先按无需转义的长度预留容量，再直接追加到 buf 的剩余容量中：Write 时源与目标重合，不再拷贝
+	buf.Grow(len(s) + 2)
+	buf.Write(appendQuoted(buf.AvailableBuffer(), s, escapeHTML))
+}
+
+// appendQuoted 是 appendString 的 []byte 版本。
+func appendQuoted(dst []byte, s string, escapeHTML bool) []byte {
+	safe := &safeSet
+	if escapeHTML {
+		safe = &htmlSafeSet
+	}
+	dst = append(dst, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if c := s[i]; c < utf8.RuneSelf {
+			if safe[c] {
+				i++
+				continue
+			}
+			dst = append(dst, s[start:i]...)
+			switch c {
+			case '\\', '"':
+				dst = append(dst, '\\', c)
+			case '\b':
+				dst = append(dst, `\b`...)
+			case '\f':
+				dst = append(dst, `\f`...)
+			case '\n':
+				dst = append(dst, `\n`...)
+			case '\r':
+				dst = append(dst, `\r`...)
+			case '\t':
+				dst = append(dst, `\t`...)
+			default:
+				// 其余控制字符与 HTML 字符
+				dst = append(dst, `\u00`...)
+				dst = append(dst, hexDigits[c>>4], hexDigits[c&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			dst = append(dst, s[start:i]...)
+			dst = utf8.AppendRune(dst, utf8.RuneError)
+			i += size
+			start = i
+			continue
+		}
+		if r == '\u2028' || r == '\u2029' {
+			dst = append(dst, s[start:i]...)
+			dst = append(dst, `\u202`...)
+			dst = append(dst, hexDigits[r&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	dst = append(dst, s[start:]...)
+	return append(dst, '"')
+}
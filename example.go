@@ -0,0 +1,39 @@
+package groupjson
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// ExampleTagKey 字段示例值的标签键，如 example:"alice@example.com"、example:"42"。
+// 示例值随 Schema 导出供文档使用，fixtures 包生成数据时也优先采用，保证各分组视图中的示例一致。
+const ExampleTagKey = "example"
+
+// ExampleValue 将 example 标签文本解析为类型 t 的值：先按 JSON 字面量解析，
+// 失败时再视为 JSON 字符串解析，因此字符串、时间等类型无需在标签内额外加引号。
+func ExampleValue(t reflect.Type, tag string) (reflect.Value, error) {
+	p := reflect.New(t)
+	if err := json.Unmarshal([]byte(tag), p.Interface()); err == nil {
+		return p.Elem(), nil
+	}
+	p = reflect.New(t)
+	if err := json.Unmarshal([]byte(strconv.Quote(tag)), p.Interface()); err != nil {
+		return reflect.Value{}, fmt.Errorf("%w: %q for %s: %v", ErrInvalidExample, tag, t, err)
+	}
+	return p.Elem(), nil
+}
+
+// exampleJSON 返回字段示例值规范化后的 JSON，未声明示例时返回 nil。
+func exampleJSON(sf reflect.StructField) (json.RawMessage, error) {
+	tag, ok := sf.Tag.Lookup(ExampleTagKey)
+	if !ok {
+		return nil, nil
+	}
+	v, err := ExampleValue(sf.Type, tag)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", sf.Name, err)
+	}
+	return json.Marshal(v.Interface())
+}
@@ -0,0 +1,138 @@
+package groupjson
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"strconv"
+)
+
+// 基本类型的切片与 map（如 []int、[]string、map[string]string）在遥测与标签数组中十分常见，
+// 这里为它们生成逐 Kind 展开的循环，省去每个元素的编码函数分派与路径维护。
+// 需要逐元素路径（路径规则、追踪、非 ErrorFail 策略等）或 MaxBytes 检查时回退到通用实现。
+
+// primitiveKind 判断 t 是否为可走快速路径的基本类型：Kind 为数字、字符串或布尔，且没有自定义序列化。
+func primitiveKind(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Float32, reflect.Float64, reflect.String, reflect.Bool:
+		return !dynamicType(t)
+	}
+	return false
+}
+
+// fastPathAllowed 本次编码能否使用快速路径。
+func (c *encodeContext) fastPathAllowed() bool {
+	return !c.trackPath && c.opts.MaxBytes <= 0
+}
+
+// compilePrimitiveSlice 为元素为基本类型的切片/数组生成编码函数，不适用时返回 nil。
+func compilePrimitiveSlice(t reflect.Type) encoderFunc {
+	elem := t.Elem()
+	if !primitiveKind(elem) || (t.Kind() == reflect.Slice && elem.Kind() == reflect.Uint8) {
+		return nil
+	}
+	write := primitiveWriter(elem)
+	return func(e Encoder, buf *bytes.Buffer, v reflect.Value, ctx *encodeContext) error {
+		if !ctx.fastPathAllowed() || (e.opts.Int64AsString && isIntKind(elem.Kind())) {
+			return e.encodeSlice(buf, v, ctx)
+		}
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			e.writeNilCollection(buf, "[]")
+			return nil
+		}
+		if err := ctx.incDepth(); err != nil {
+			return ctx.truncate(buf, err)
+		}
+		defer ctx.decDepth()
+
+		buf.WriteByte('[')
+		for i, n := 0, v.Len(); i < n; i++ {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := write(e, buf, v.Index(i)); err != nil {
+				return withSegment(err, PathSegment{Kind: SegmentIndex, Index: i}, elem)
+			}
+		}
+		buf.WriteByte(']')
+		return nil
+	}
+}
+
+// compilePrimitiveMap 为键为字符串、值为基本类型的 map 生成编码函数，不适用时返回 nil。
+// 要求按键排序时回退到通用实现。
+func compilePrimitiveMap(t reflect.Type) encoderFunc {
+	elem := t.Elem()
+	if t.Key().Kind() != reflect.String || !primitiveKind(elem) {
+		return nil
+	}
+	write := primitiveWriter(elem)
+	return func(e Encoder, buf *bytes.Buffer, v reflect.Value, ctx *encodeContext) error {
+		if !ctx.fastPathAllowed() || e.opts.SortKeys || (e.opts.Int64AsString && isIntKind(elem.Kind())) {
+			return e.encodeMap(buf, v, ctx)
+		}
+		if v.IsNil() {
+			e.writeNilCollection(buf, "{}")
+			return nil
+		}
+		if err := ctx.incDepth(); err != nil {
+			return ctx.truncate(buf, err)
+		}
+		defer ctx.decDepth()
+
+		buf.WriteByte('{')
+		first := true
+		for it := v.MapRange(); it.Next(); {
+			if !first {
+				buf.WriteByte(',')
+			}
+			first = false
+			key := it.Key().String()
+			e.writeString(buf, key)
+			buf.WriteByte(':')
+			if err := write(e, buf, it.Value()); err != nil {
+				return withSegment(err, PathSegment{Kind: SegmentKey, Name: key}, elem)
+			}
+		}
+		buf.WriteByte('}')
+		return nil
+	}
+}
+
+// primitiveWriter 返回写出单个基本类型值的函数。
+func primitiveWriter(t reflect.Type) func(e Encoder, buf *bytes.Buffer, v reflect.Value) error {
+	switch t.Kind() {
+	case reflect.String:
+		return func(e Encoder, buf *bytes.Buffer, v reflect.Value) error {
+			appendString(buf, v.String(), e.opts.EscapeHTML)
+			return nil
+		}
+	case reflect.Bool:
+		return func(_ Encoder, buf *bytes.Buffer, v reflect.Value) error {
+			buf.WriteString(strconv.FormatBool(v.Bool()))
+			return nil
+		}
+	case reflect.Float32, reflect.Float64:
+		bitSize := t.Bits()
+		return func(e Encoder, buf *bytes.Buffer, v reflect.Value) error {
+			f := v.Float()
+			if math.IsNaN(f) || math.IsInf(f, 0) {
+				return e.writeFloatSpecial(buf, v, f)
+			}
+			e.writeFloat(buf, f, bitSize)
+			return nil
+		}
+	default:
+		return func(_ Encoder, buf *bytes.Buffer, v reflect.Value) error {
+			var scratch [24]byte
+			buf.Write(strconv.AppendInt(scratch[:0], v.Int(), 10))
+			return nil
+		}
+	}
+}
+
+// isIntKind 判断 k 是否为有符号整数。
+func isIntKind(k reflect.Kind) bool {
+	return k >= reflect.Int && k <= reflect.Int64
+}
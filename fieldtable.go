@@ -0,0 +1,128 @@
+package groupjson
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+)
+
+// FieldTable 使用默认配置写出 types 的字段与分组对照表，见 Encoder.FieldTable。
+func FieldTable(w io.Writer, types ...any) error {
+	return NewEncoder().FieldTable(w, types...)
+}
+
+// FieldTable 为 types 及其字段引用的结构体类型（每个类型只写一次，引用的类型按名称排在后面）写出
+// 字段与分组的对照表，便于审查各视图暴露了哪些字段而不必逐个阅读标签。每行一个 JSON 字段，每列一个分组：
+// "✓" 表示请求该分组即输出，"+" 表示只在与同一分组项（如 "admin+internal"）中的其他分组一起请求时输出；
+// 最后一列列出 omitempty、mask、if 等修饰。没有分组标签的字段只在不指定分组时输出，标为 ungrouped。
+func (e Encoder) FieldTable(w io.Writer, types ...any) error {
+	var roots []string
+	all := map[string][]SchemaField{}
+	for _, v := range types {
+		s, err := e.Schema(v)
+		if err != nil {
+			return err
+		}
+		if !slices.Contains(roots, s.Root) {
+			roots = append(roots, s.Root)
+		}
+		for name, fields := range s.Types {
+			all[name] = fields
+		}
+	}
+	var refs []string
+	for name := range all {
+		if !slices.Contains(roots, name) {
+			refs = append(refs, name)
+		}
+	}
+	slices.Sort(refs)
+
+	// 不含制表符的类型名行隔断列，各类型的表格分别对齐
+	var buf bytes.Buffer
+	tw := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	for i, name := range append(roots, refs...) {
+		if i > 0 {
+			fmt.Fprintln(tw)
+		}
+		writeFieldTable(tw, name, all[name])
+	}
+	tw.Flush()
+	// 去掉修饰列为空时对齐产生的行尾空格
+	var out bytes.Buffer
+	for line := range bytes.Lines(buf.Bytes()) {
+		out.Write(bytes.TrimRight(line, " \n"))
+		out.WriteByte('\n')
+	}
+	_, err := w.Write(out.Bytes())
+	return err
+}
+
+// writeFieldTable 写出一个类型的对照表，分组列按首次出现的顺序排列。
+func writeFieldTable(w io.Writer, name string, fields []SchemaField) {
+	var groups []string
+	for _, f := range fields {
+		for _, entry := range f.Groups {
+			for _, g := range strings.Split(entry, "+") {
+				if g != "" && g != NeverGroup && !slices.Contains(groups, g) {
+					groups = append(groups, g)
+				}
+			}
+		}
+	}
+	fmt.Fprintln(w, name)
+	writeRow(w, "FIELD", groups, "FLAGS")
+	for _, f := range fields {
+		cells := make([]string, len(groups))
+		var flags []string
+		if !f.Never {
+			for _, entry := range f.Groups {
+				mark := "✓"
+				if strings.Contains(entry, "+") {
+					mark = "+"
+					flags = append(flags, entry)
+				}
+				for _, g := range strings.Split(entry, "+") {
+					if i := slices.Index(groups, g); i >= 0 && cells[i] != "✓" {
+						cells[i] = mark
+					}
+				}
+			}
+		}
+		switch {
+		case f.Never:
+			flags = append(flags, "never")
+		case len(f.Groups) == 0:
+			flags = append(flags, "ungrouped")
+		}
+		if f.OmitEmpty {
+			flags = append(flags, "omitempty")
+		}
+		if f.OmitZero {
+			flags = append(flags, "omitzero")
+		}
+		if f.Mask != "" {
+			flags = append(flags, "mask="+f.Mask)
+		}
+		if len(f.Unmask) > 0 {
+			flags = append(flags, "unmask="+strings.Join(f.Unmask, ","))
+		}
+		if f.If != "" {
+			flags = append(flags, "if="+f.If)
+		}
+		if f.Ref != "" {
+			prefix := strings.NewReplacer("array", "[]", "map", "map[string]", "/", "").Replace(f.Container)
+			flags = append(flags, "ref="+prefix+f.Ref)
+		}
+		writeRow(w, f.Name, cells, strings.Join(flags, " "))
+	}
+}
+
+// writeRow 写出表格的一行：字段列、各分组列与修饰列。
+func writeRow(w io.Writer, field string, cells []string, flags string) {
+	cols := slices.Concat([]string{field}, cells, []string{flags})
+	fmt.Fprintf(w, "  %s\n", strings.Join(cols, "\t"))
+}
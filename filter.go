@@ -0,0 +1,121 @@
+package groupjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+)
+
+// WithFilterMarshalers 开启后，当实现了 json.Marshaler 的结构体返回 JSON 对象时，
+// 重新解析该对象并按其 Go 类型的 schema 过滤顶层键，堵住自定义序列化绕过分组的漏洞。
+// 指定分组时，schema 中不存在的键一律丢弃。
+func (e Encoder) WithFilterMarshalers(on bool) Encoder { e.opts.FilterMarshalers = on; return e }
+
+// writeMarshalerJSON 写入 json.Marshaler 的输出，必要时按 t 的 schema 过滤。
+func (e Encoder) writeMarshalerJSON(buf *bytes.Buffer, b []byte, t reflect.Type) error {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	trimmed := bytes.TrimSpace(b)
+	if !e.opts.FilterMarshalers || t.Kind() != reflect.Struct || len(trimmed) == 0 || trimmed[0] != '{' {
+		buf.Write(b)
+		return nil
+	}
+	return e.filterObject(buf, trimmed, t)
+}
+
+// filterObject 按 t 的 schema 过滤 JSON 对象的顶层键，保持原有键顺序。
+func (e Encoder) filterObject(buf *bytes.Buffer, obj []byte, t reflect.Type) error {
+	sch := e.schemaFor(t)
+	byName := make(map[string]*fieldInfo, len(sch.fields))
+	for i := range sch.fields {
+		byName[sch.fields[i].jsonName] = &sch.fields[i]
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(obj))
+	dec.UseNumber()
+	if _, err := dec.Token(); err != nil { // '{'
+		return err
+	}
+	buf.WriteByte('{')
+	first := true
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key, _ := tok.(string)
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return err
+		}
+		f, ok := byName[key]
+		if ok && f.never {
+			continue
+		}
+		if len(e.opts.Groups) > 0 && (!ok || !e.includeField(f.groups)) {
+			continue
+		}
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		e.writeString(buf, key)
+		buf.WriteByte(':')
+		buf.Write(raw)
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+// FilterJSON 使用默认配置按 groups 过滤已编码的 JSON，见 Encoder.FilterJSON。
+func FilterJSON(data []byte, schemaType any, groups ...string) ([]byte, error) {
+	return NewEncoder().WithGroups(groups...).FilterJSON(data, schemaType)
+}
+
+// FilterJSON 按 schemaType（结构体值或指针，仅用于取类型）的 schema 与当前分组过滤已编码的 JSON，
+// 剔除不允许输出的键并执行脱敏，data 可以是对象或对象数组。网关等场景可直接过滤缓存或
+// 上游服务返回的数据，无需先反序列化为结构体。类型的 schema 在首次使用后缓存。
+func (e Encoder) FilterJSON(data []byte, schemaType any) ([]byte, error) {
+	return e.filterBySchema(data, schemaType, false)
+}
+
+// SanitizeRequest 使用默认配置按 groups 清理入站 JSON，见 Encoder.SanitizeRequest。
+func SanitizeRequest(data []byte, target any, groups ...string) ([]byte, error) {
+	return NewEncoder().WithGroups(groups...).SanitizeRequest(data, target)
+}
+
+// SanitizeRequest 按 target（结构体值或指针，仅用于取类型）的 schema 删除调用方分组无权写入的键，
+// 返回可直接交给 json.Unmarshal 的数据。与输出过滤不同，这里只看分组：不执行脱敏与 if 条件；
+// schema 中不存在的键同样删除，避免 encoding/json 的大小写不敏感匹配绕过筛选（如 "EMAIL"）。
+func (e Encoder) SanitizeRequest(data []byte, target any) ([]byte, error) {
+	return e.filterBySchema(data, target, true)
+}
+
+// filterBySchema FilterJSON 与 SanitizeRequest 的共同实现。
+func (e Encoder) filterBySchema(data []byte, typ any, inbound bool) ([]byte, error) {
+	t := reflect.TypeOf(typ)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, ErrInvalidType
+	}
+	key := e.schemaKey(t)
+	s, ok := exportedSchemas.Load(key)
+	if !ok {
+		var err error
+		if s, err = e.Schema(reflect.Zero(t).Interface()); err != nil {
+			return nil, err
+		}
+		exportedSchemas.Store(key, s)
+	}
+	var buf bytes.Buffer
+	if err := e.filterSchemaValue(&buf, s, bytes.TrimSpace(data), s.Root, "array", inbound); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// exportedSchemas FilterJSON 使用的已导出 schema 缓存。
+var exportedSchemas cache[schemaKey, *Schema]
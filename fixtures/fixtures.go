@@ -0,0 +1,173 @@
+// Package fixtures 根据真实模型类型生成伪随机实例，并按分组视图渲染为 JSON 文件，
+// 为前端提供与后端模型一致的分角色 mock 数据。
+//
+// 生成过程只依赖反射：导出字段按类型填充，json:"-" 的字段保持零值，
+// 声明了 example 标签（见 groupjson.ExampleTagKey）的字段直接使用示例值，标签无法解析时退回随机填充；
+// 同一 seed 多次生成的结果完全一致，便于将 fixture 提交到仓库并审查差异。
+package fixtures
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/JieBaiYou/groupjson"
+)
+
+// MaxDepth 生成嵌套结构体/指针时的最大深度，防止自引用类型无限递归。
+const MaxDepth = 4
+
+// baseTime time.Time 字段的基准时间，随机偏移在其前后一年内。
+var baseTime = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+var timeType = reflect.TypeFor[time.Time]()
+
+// Generate 按 seed 生成类型 t 的伪随机实例，返回值的动态类型为 t。
+func Generate(t reflect.Type, seed int64) any {
+	g := generator{rng: rand.New(rand.NewPCG(uint64(seed), 0x9e3779b97f4a7c15))}
+	v := reflect.New(t).Elem()
+	g.fill(v, "", 0)
+	return v.Interface()
+}
+
+// GenerateFor 是 Generate 的泛型形式。
+func GenerateFor[T any](seed int64) T {
+	return Generate(reflect.TypeFor[T](), seed).(T)
+}
+
+// Render 将 v 按每个分组分别序列化（带缩进），写入 dir/<name>.<group>.json，返回写出的文件路径。
+// groups 为空时使用 v 的类型标签中出现的全部分组。
+func Render(enc groupjson.Encoder, dir, name string, v any, groups ...string) ([]string, error) {
+	if len(groups) == 0 {
+		m, err := enc.VisibilityMatrix(v)
+		if err != nil {
+			return nil, err
+		}
+		groups = m.Groups
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	files := make([]string, 0, len(groups))
+	for _, g := range groups {
+		b, err := enc.MarshalGroups(v, g)
+		if err != nil {
+			return files, fmt.Errorf("fixtures: %s/%s: %w", name, g, err)
+		}
+		var out bytes.Buffer
+		if err := json.Indent(&out, b, "", "  "); err != nil {
+			return files, err
+		}
+		out.WriteByte('\n')
+		path := filepath.Join(dir, name+"."+g+".json")
+		if err := os.WriteFile(path, out.Bytes(), 0o644); err != nil {
+			return files, err
+		}
+		files = append(files, path)
+	}
+	return files, nil
+}
+
+// generator 持有生成过程中的随机源。
+type generator struct {
+	// rng 确定性随机源
+	rng *rand.Rand
+}
+
+// fill 按类型填充 v，name 为所在字段名，用于生成可读的字符串。
+func (g generator) fill(v reflect.Value, name string, depth int) {
+	if v.Type() == timeType {
+		d := time.Duration(g.rng.Int64N(int64(365*24*time.Hour))) - 182*24*time.Hour
+		v.Set(reflect.ValueOf(baseTime.Add(d).Truncate(time.Second)))
+		return
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		v.SetBool(g.rng.IntN(2) == 1)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(g.rng.Int64N(100) + 1)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		v.SetUint(g.rng.Uint64N(100) + 1)
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(float64(g.rng.IntN(10000)) / 100)
+	case reflect.String:
+		v.SetString(g.str(name))
+	case reflect.Pointer:
+		if depth >= MaxDepth {
+			return
+		}
+		p := reflect.New(v.Type().Elem())
+		g.fill(p.Elem(), name, depth+1)
+		v.Set(p)
+	case reflect.Struct:
+		if depth >= MaxDepth {
+			return
+		}
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if sf.PkgPath != "" || sf.Tag.Get("json") == "-" {
+				continue
+			}
+			if tag, ok := sf.Tag.Lookup(groupjson.ExampleTagKey); ok {
+				if ex, err := groupjson.ExampleValue(sf.Type, tag); err == nil {
+					v.Field(i).Set(ex)
+					continue
+				}
+			}
+			g.fill(v.Field(i), sf.Name, depth+1)
+		}
+	case reflect.Slice:
+		if depth >= MaxDepth {
+			return
+		}
+		n := g.rng.IntN(3) + 1
+		s := reflect.MakeSlice(v.Type(), n, n)
+		for i := 0; i < n; i++ {
+			g.fill(s.Index(i), name, depth+1)
+		}
+		v.Set(s)
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			g.fill(v.Index(i), name, depth+1)
+		}
+	case reflect.Map:
+		if depth >= MaxDepth || v.Type().Key().Kind() != reflect.String {
+			return
+		}
+		m := reflect.MakeMap(v.Type())
+		n := g.rng.IntN(2) + 1
+		for i := 0; i < n; i++ {
+			k := reflect.New(v.Type().Key()).Elem()
+			k.SetString(fmt.Sprintf("key%d", i+1))
+			e := reflect.New(v.Type().Elem()).Elem()
+			g.fill(e, name, depth+1)
+			m.SetMapIndex(k, e)
+		}
+		v.Set(m)
+	}
+	// interface、chan、func 等保持零值
+}
+
+// str 根据字段名生成可读字符串，常见语义字段（邮箱、电话、URL）生成对应格式。
+func (g generator) str(name string) string {
+	n := g.rng.IntN(1000)
+	lower := strings.ToLower(name)
+	switch {
+	case strings.Contains(lower, "email"):
+		return fmt.Sprintf("user%d@example.com", n)
+	case strings.Contains(lower, "phone"):
+		return fmt.Sprintf("138%08d", g.rng.IntN(100000000))
+	case strings.Contains(lower, "url"):
+		return fmt.Sprintf("https://example.com/%s/%d", lower, n)
+	case lower == "":
+		return fmt.Sprintf("value%d", n)
+	}
+	return fmt.Sprintf("%s_%d", lower, n)
+}
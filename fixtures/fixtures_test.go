@@ -0,0 +1,70 @@
+package fixtures
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/JieBaiYou/groupjson"
+)
+
+type Node struct {
+	Name     string  `json:"name" groups:"public"`
+	Children []*Node `json:"children" groups:"public"`
+}
+
+type Account struct {
+	ID       int               `json:"id" groups:"public,admin"`
+	Email    string            `json:"email" groups:"admin" example:"ops@example.com"`
+	Password string            `json:"-"`
+	Created  time.Time         `json:"created" groups:"admin"`
+	Labels   map[string]string `json:"labels" groups:"public"`
+	Tree     *Node             `json:"tree" groups:"public"`
+}
+
+func TestGenerateDeterministic(t *testing.T) {
+	a := GenerateFor[Account](42)
+	b := GenerateFor[Account](42)
+	if !reflect.DeepEqual(a, b) {
+		t.Fatalf("same seed produced different values:\n%+v\n%+v", a, b)
+	}
+	if a.ID == 0 || a.Email == "" || a.Created.IsZero() || len(a.Labels) == 0 || a.Tree == nil {
+		t.Errorf("fields not populated: %+v", a)
+	}
+	if a.Email != "ops@example.com" {
+		t.Errorf("example tag not used: Email = %q", a.Email)
+	}
+	if a.Password != "" {
+		t.Errorf("json:\"-\" field should stay zero, got %q", a.Password)
+	}
+}
+
+func TestRender(t *testing.T) {
+	dir := t.TempDir()
+	v := GenerateFor[Account](1)
+	files, err := Render(groupjson.NewEncoder(), dir, "account", v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("files = %v, want admin and public views", files)
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, "account.public.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := got["email"]; ok {
+		t.Errorf("public view leaked email: %s", b)
+	}
+	if _, ok := got["labels"]; !ok {
+		t.Errorf("public view missing labels: %s", b)
+	}
+}
@@ -0,0 +1,43 @@
+package groupjson
+
+import (
+	"bytes"
+	"reflect"
+	"strconv"
+)
+
+// PrecTagKey 字段级浮点精度标签，如 gjprec:"2" 让该字段（含其中的切片、map 元素）以固定两位小数输出，
+// 优先于 WithFloatFormat。
+const PrecTagKey = "gjprec"
+
+// WithFloatFormat 设置浮点数的格式与精度，含义同 strconv.FormatFloat：format 取 'f'、'e'、'E'、'g' 或 'G'，
+// prec 为 -1 时使用能精确还原的最短表示。如 WithFloatFormat('f', 2) 让金额与指标固定输出两位小数。
+// 默认为 'g' 与 -1；'b'、'x' 等输出不是合法 JSON 数字的格式被忽略，保持默认。
+func (e Encoder) WithFloatFormat(format byte, prec int) Encoder {
+	switch format {
+	case 'f', 'e', 'E', 'g', 'G':
+		e.opts.FloatFormat, e.opts.FloatPrec = format, prec
+	default:
+		e.opts.FloatFormat, e.opts.FloatPrec = 0, 0
+	}
+	return e
+}
+
+// parsePrecTag 解析 gjprec 标签，缺失或非法时返回 -1。
+func parsePrecTag(sf reflect.StructField) int {
+	n, err := strconv.Atoi(sf.Tag.Get(PrecTagKey))
+	if err != nil || n < 0 {
+		return -1
+	}
+	return n
+}
+
+// writeFloat 按当前的浮点格式写出有限浮点数。
+func (e Encoder) writeFloat(buf *bytes.Buffer, f float64, bitSize int) {
+	format, prec := byte('g'), -1
+	if e.opts.FloatFormat != 0 {
+		format, prec = e.opts.FloatFormat, e.opts.FloatPrec
+	}
+	var scratch [64]byte
+	buf.Write(strconv.AppendFloat(scratch[:0], f, format, prec, bitSize))
+}
@@ -0,0 +1,58 @@
+package groupjson
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"strconv"
+)
+
+// 以下函数供 cmd/groupjson gen-encoders 生成的代码调用，输出与反射编码器的默认配置逐字节一致。
+// 业务代码通常不需要直接使用。
+
+// AppendJSON 以默认配置、按 groups 与 mode 把 v 的 JSON 追加到 dst。
+// 生成代码用它编码无法在生成时展开的值（其他包的类型、接口等）；
+// 传入字段的指针可使指针接收者的 MarshalJSON 等方法与反射路径一样生效。
+func AppendJSON(dst []byte, v any, groups []string, mode GroupMode) ([]byte, error) {
+	e := NewEncoder()
+	e.opts.Groups, e.opts.Mode = groups, mode
+	buf := getBuffer(0)
+	defer putBuffer(buf)
+	if err := e.encodeTop(buf, v); err != nil {
+		return dst, err
+	}
+	return append(dst, buf.Bytes()...), nil
+}
+
+// AppendString 把 s 作为 JSON 字符串追加到 dst（不转义 HTML 字符）。
+func AppendString(dst []byte, s string) []byte {
+	return appendQuoted(dst, s, false)
+}
+
+// AppendFloat 以最短表示把 f 追加到 dst，bits 为 32 或 64；NaN 与 ±Inf 返回 *json.UnsupportedValueError。
+func AppendFloat(dst []byte, f float64, bits int) ([]byte, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return dst, &json.UnsupportedValueError{Value: reflect.ValueOf(f), Str: strconv.FormatFloat(f, 'g', -1, 64)}
+	}
+	return strconv.AppendFloat(dst, f, 'g', -1, bits), nil
+}
+
+// IsEmpty 判断 ptr 指向的字段按 omitempty 规则是否为空。ptr 须为非 nil 指针。
+// 与反射编码器对字段的处理一致，字段为非 nil 的指针时按其指向的值判断。
+func IsEmpty(ptr any) bool {
+	return isEmptyValue(fieldValue(ptr))
+}
+
+// IsZero 判断 ptr 指向的字段按 omitzero 规则是否为零值（优先调用 IsZero 方法），指针字段的处理同 IsEmpty。
+func IsZero(ptr any) bool {
+	return isZeroValue(fieldValue(ptr))
+}
+
+// fieldValue 返回 ptr 指向的值，为非 nil 指针时再解引用一次，与 fieldByIndex 一致。
+func fieldValue(ptr any) reflect.Value {
+	v := reflect.ValueOf(ptr).Elem()
+	if v.Kind() == reflect.Pointer && !v.IsNil() {
+		v = v.Elem()
+	}
+	return v
+}
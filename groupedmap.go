@@ -0,0 +1,110 @@
+package groupjson
+
+import (
+	"bytes"
+	"reflect"
+	"sort"
+)
+
+// Grouped 是 GroupedMap 中的一项：值及其所属分组。
+type Grouped[V any] struct {
+	// Value 项的值，结构体等复合值同样按分组递归筛选
+	Value V
+	// Groups 该项所属分组，规则与字段的分组标签一致（支持 "-" 与 "a+b"）
+	Groups []string
+}
+
+// GroupedMap 按键声明分组的 map，适合功能开关、配置包等动态键集合参与视图筛选：
+//
+//	flags := groupjson.GroupedMap[bool]{}
+//	flags.Set("dark_mode", true, "public")
+//	flags.Set("beta_billing", false, "admin")
+//
+// 编码时每一项按其分组（以及 Allow/Deny 路径规则）单独判断是否输出，不再是整体可见或整体隐藏。
+type GroupedMap[V any] map[string]Grouped[V]
+
+// Set 设置键 key 的值与分组。
+func (m GroupedMap[V]) Set(key string, v V, groups ...string) {
+	m[key] = Grouped[V]{Value: v, Groups: groups}
+}
+
+func (m GroupedMap[V]) groupedKeys() []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func (m GroupedMap[V]) groupedEntry(key string) (reflect.Value, []string) {
+	it := m[key]
+	return reflect.ValueOf(&it.Value).Elem(), it.Groups
+}
+
+// groupedMap 由 GroupedMap 的各个实例化类型实现，供编码器识别。
+type groupedMap interface {
+	groupedKeys() []string
+	groupedEntry(key string) (reflect.Value, []string)
+}
+
+var groupedMapType = reflect.TypeFor[groupedMap]()
+
+// encodeGroupedMap 逐项应用分组与路径规则后写出对象。
+func (e Encoder) encodeGroupedMap(buf *bytes.Buffer, m groupedMap, ctx *encodeContext) error {
+	if err := ctx.incDepth(); err != nil {
+		return ctx.truncate(buf, err)
+	}
+	defer ctx.decDepth()
+
+	keys := m.groupedKeys()
+	if e.opts.SortKeys {
+		sort.Strings(keys)
+	}
+	buf.WriteByte('{')
+	first := true
+	for _, k := range keys {
+		v, groups := m.groupedEntry(k)
+		if !e.entryVisible(k, groups, ctx) {
+			continue
+		}
+		mark, wasFirst := buf.Len(), first
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		e.writeString(buf, k)
+		buf.WriteByte(':')
+		ctx.pushPath(PathSegment{Kind: SegmentKey, Name: k})
+		err := e.encode(buf, v, ctx)
+		ctx.popPath()
+		if err != nil {
+			if err != errOmit {
+				return withSegment(err, PathSegment{Kind: SegmentKey, Name: k}, valueType(v))
+			}
+			buf.Truncate(mark)
+			first = wasFirst
+		}
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+// entryVisible 按与结构体字段相同的规则判断 GroupedMap 的一项是否输出。
+func (e Encoder) entryVisible(key string, groups []string, ctx *encodeContext) bool {
+	for _, g := range groups {
+		if g == NeverGroup {
+			return false
+		}
+	}
+	include := len(e.opts.Groups) == 0 || e.includeField(groups)
+	if ctx.trackPath {
+		path := append(ctx.path, PathSegment{Kind: SegmentKey, Name: key})
+		if matchAnyPattern(e.opts.denyPatterns, path) {
+			return false
+		}
+		if !include && matchAnyPattern(e.opts.allowPatterns, path) {
+			return true
+		}
+	}
+	return include
+}
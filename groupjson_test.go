@@ -2,10 +2,24 @@ package groupjson
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"io"
+	"iter"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"text/template"
 	"time"
 )
 
@@ -100,7 +114,7 @@ func TestOmitEmptyAndOmitZero(t *testing.T) {
 		t.Fatalf("omitempty string should be omitted: %s", s)
 	}
 
-	// omitzero 仅省略标量零值，应保留空集合
+	// omitzero 仅省略零值，非 nil 的空集合应保留
 	u.Scores = []int{}
 	b, err = NewEncoder().WithGroups("public").Marshal(u)
 	if err != nil {
@@ -281,6 +295,2895 @@ func TestRawMessageAndBytes(t *testing.T) {
 	}
 }
 
+func TestNeverGroup(t *testing.T) {
+	type Account struct {
+		ID   int    `json:"id" groups:"public"`
+		Hash string `json:"hash" groups:"admin,-"`
+	}
+	a := Account{ID: 1, Hash: "secret"}
+	for _, enc := range []Encoder{NewEncoder(), NewEncoder().WithGroups("admin")} {
+		b, err := enc.Marshal(a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(b), "hash") {
+			t.Fatalf("never field leaked: %s", string(b))
+		}
+	}
+}
+
+func TestLogHandler(t *testing.T) {
+	type Login struct {
+		User     string `json:"user" groups:"log"`
+		Password string `json:"password" groups:"internal"`
+	}
+	var buf bytes.Buffer
+	logger := slog.New(NewLogHandler(slog.NewJSONHandler(&buf, nil), NewEncoder()))
+	logger.Info("login", "req", Login{User: "u", Password: "p"}, "n", 1)
+	s := buf.String()
+	if !strings.Contains(s, `"req":{"user":"u"}`) {
+		t.Fatalf("struct attr should be filtered: %s", s)
+	}
+	if strings.Contains(s, "password") {
+		t.Fatalf("password leaked into log: %s", s)
+	}
+
+	buf.Reset()
+	slog.New(slog.NewJSONHandler(&buf, nil)).Info("login", "req", LogValue(&Login{User: "v"}))
+	if !strings.Contains(buf.String(), `"req":{"user":"v"}`) {
+		t.Fatalf("LogValue should filter: %s", buf.String())
+	}
+
+	buf.Reset()
+	logger.Info("batch", "reqs", []Login{{User: "a", Password: "p"}}, "byUser", map[string]*Login{"b": {User: "b", Password: "p"}}, "tags", []string{"x"})
+	s = buf.String()
+	if !strings.Contains(s, `"reqs":[{"user":"a"}]`) || !strings.Contains(s, `"byUser":{"b":{"user":"b"}}`) || !strings.Contains(s, `"tags":["x"]`) {
+		t.Fatalf("containers of structs should be filtered: %s", s)
+	}
+	if strings.Contains(s, "password") {
+		t.Fatalf("password leaked into log: %s", s)
+	}
+
+	buf.Reset()
+	logger.Error("failed", "err", errors.New("boom"), "at", time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC))
+	s = buf.String()
+	if !strings.Contains(s, `"err":"boom"`) || !strings.Contains(s, `"at":"2024-05-01T00:00:00Z"`) {
+		t.Fatalf("errors and marshalers should pass through: %s", s)
+	}
+}
+
+func TestMarshalGroups(t *testing.T) {
+	u := User{ID: 1, Name: "A", Email: "a@x"}
+	shared := NewEncoder().WithGroups("public")
+
+	b, err := shared.MarshalGroups(u, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "email") {
+		t.Fatalf("per-call groups should apply: %s", string(b))
+	}
+
+	// 共享实例的分组不应被修改
+	b, _ = shared.Marshal(u)
+	if strings.Contains(string(b), "email") {
+		t.Fatalf("shared encoder mutated: %s", string(b))
+	}
+}
+
+func TestMarshalContext(t *testing.T) {
+	u := User{ID: 1, Name: "A", Email: "a@x"}
+	ctx := ContextWithGroups(context.Background(), "admin")
+
+	b, err := NewEncoder().WithGroups("public").MarshalContext(ctx, u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "email") {
+		t.Fatalf("context groups should apply: %s", string(b))
+	}
+
+	enc := NewEncoder().WithGroupResolver(func(context.Context) []string { return []string{"public"} })
+	b, _ = enc.MarshalContext(ctx, u)
+	if strings.Contains(string(b), "email") {
+		t.Fatalf("resolver should take precedence: %s", string(b))
+	}
+
+	b, _ = NewEncoder().WithGroups("public").MarshalContext(context.Background(), u)
+	if strings.Contains(string(b), "email") {
+		t.Fatalf("static groups should be the fallback: %s", string(b))
+	}
+}
+
+func TestVisibilityMatrix(t *testing.T) {
+	m, err := NewEncoder().VisibilityMatrix(&Address{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Groups) != 2 || len(m.Rows) != 8 {
+		t.Fatalf("unexpected matrix shape: %+v", m)
+	}
+	md := m.Markdown()
+	if !strings.Contains(md, "| and | admin,public | ✓ | ✓ |") {
+		t.Fatalf("markdown missing AND row:\n%s", md)
+	}
+	if _, err := NewEncoder().VisibilityMatrix(1); err != ErrInvalidType {
+		t.Fatalf("expect ErrInvalidType, got %v", err)
+	}
+}
+
+// groupMoney 同时实现 GroupMarshaler 与 json.Marshaler
+type groupMoney struct{ Cents int }
+
+func (m groupMoney) MarshalJSON() ([]byte, error) { return []byte(`"hidden"`), nil }
+
+func (m groupMoney) MarshalJSONGroups(groups []string, mode GroupMode) ([]byte, error) {
+	for _, g := range groups {
+		if g == "admin" {
+			return []byte(strconv.Itoa(m.Cents)), nil
+		}
+	}
+	return []byte(`"***"`), nil
+}
+
+func TestGroupMarshaler(t *testing.T) {
+	type Order struct {
+		Total groupMoney `json:"total" groups:"public,admin"`
+	}
+	o := Order{Total: groupMoney{Cents: 999}}
+	b, err := NewEncoder().WithGroups("admin").Marshal(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"total":999}` {
+		t.Fatalf("GroupMarshaler should take precedence: %s", string(b))
+	}
+	b, _ = NewEncoder().WithGroups("public").Marshal(&o)
+	if string(b) != `{"total":"***"}` {
+		t.Fatalf("GroupMarshaler should receive groups: %s", string(b))
+	}
+}
+
+func TestAllowDenyFields(t *testing.T) {
+	type Team struct {
+		Lead    User   `json:"lead" groups:"public"`
+		Members []User `json:"members" groups:"public"`
+	}
+	team := Team{Lead: User{ID: 1, Email: "l@x"}, Members: []User{{ID: 2, Email: "m@x", Password: "p"}}}
+
+	b, err := NewEncoder().WithGroups("public").WithAllowFields("lead.email").Marshal(team)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := string(b)
+	if !strings.Contains(s, "l@x") || strings.Contains(s, "m@x") {
+		t.Fatalf("allow should only expose lead.email: %s", s)
+	}
+
+	b, _ = NewEncoder().WithGroups("public", "internal").WithDenyFields("**.password", "lead.id").WithAllowFields("lead.id").Marshal(team)
+	s = string(b)
+	if strings.Contains(s, "password") {
+		t.Fatalf("deny should hide nested password: %s", s)
+	}
+	if strings.Contains(s, `"lead":{"id"`) {
+		t.Fatalf("deny should win over allow: %s", s)
+	}
+
+	// 直接构造的 Options 中的规则同样生效
+	opts := DefaultOptions()
+	opts.DenyFields = []string{"**.password"}
+	b, _ = MarshalWith(opts, team, "public", "internal")
+	if strings.Contains(string(b), "password") {
+		t.Fatalf("MarshalWith should apply DenyFields: %s", b)
+	}
+	if !strings.Contains(s, `"members":[{"id":2`) {
+		t.Fatalf("other fields should stay: %s", s)
+	}
+}
+
+// TestDenyFieldsMapKeys Deny 规则同样隐藏 map、sync.Map 与 iter.Seq2 中的键。
+func TestDenyFieldsMapKeys(t *testing.T) {
+	payload := map[string]any{
+		"password": "x",
+		"user":     map[string]any{"name": "n", "password": "y", "tokens": []any{map[string]any{"password": "z"}}},
+	}
+	enc := NewEncoder().WithDenyFields("**.password").WithSortKeys(true)
+	b, err := enc.Marshal(payload)
+	if err != nil || string(b) != `{"user":{"name":"n","tokens":[{}]}}` {
+		t.Fatalf("map: %s, %v", b, err)
+	}
+	m, err := enc.ToMap(struct {
+		Data map[string]any `json:"data"`
+	}{payload})
+	if data, _ := m["data"].(map[string]any); err != nil || data == nil || data["password"] != nil || data["user"].(map[string]any)["password"] != nil {
+		t.Fatalf("ToMap: %v, %v", m, err)
+	}
+
+	var sm sync.Map
+	sm.Store("password", "x")
+	sm.Store("name", "n")
+	b, _ = enc.Marshal(&sm)
+	if string(b) != `{"name":"n"}` {
+		t.Fatalf("sync.Map: %s", b)
+	}
+	seq := iter.Seq2[string, any](func(yield func(string, any) bool) {
+		_ = yield("password", "x") && yield("name", "n")
+	})
+	b, _ = enc.Marshal(seq)
+	if string(b) != `{"name":"n"}` {
+		t.Fatalf("iter.Seq2: %s", b)
+	}
+}
+
+// leakyProfile 自定义 MarshalJSON 会输出全部字段
+type leakyProfile struct {
+	Name   string `json:"name" groups:"public"`
+	Secret string `json:"secret" groups:"admin"`
+}
+
+func (p leakyProfile) MarshalJSON() ([]byte, error) {
+	return []byte(`{"name":"` + p.Name + `","secret":"` + p.Secret + `","extra":1}`), nil
+}
+
+func TestFilterMarshalers(t *testing.T) {
+	type Wrap struct {
+		P leakyProfile `json:"p" groups:"public"`
+	}
+	w := Wrap{P: leakyProfile{Name: "n", Secret: "s"}}
+
+	b, _ := NewEncoder().WithGroups("public").Marshal(w)
+	if !strings.Contains(string(b), "secret") {
+		t.Fatalf("marshaler output should be untouched by default: %s", string(b))
+	}
+
+	b, err := NewEncoder().WithGroups("public").WithFilterMarshalers(true).Marshal(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"p":{"name":"n"}}` {
+		t.Fatalf("marshaler output should be filtered: %s", string(b))
+	}
+}
+
+func TestFieldPattern(t *testing.T) {
+	path := Path{{Kind: SegmentField, Name: "users"}, {Kind: SegmentIndex, Index: 2}, {Kind: SegmentField, Name: "email"}}
+	if path.String() != "users[2].email" {
+		t.Fatalf("unexpected path string: %s", path)
+	}
+	cases := map[string]bool{
+		"users[*].email": true,
+		"users[2].email": true,
+		"users[1].email": false,
+		"users.*.email":  true,
+		"**.email":       true,
+		"**":             true,
+		"users.email":    false,
+		"*.email":        false,
+		"users[x].email": false,
+	}
+	for p, want := range cases {
+		if got := compilePattern(p).match(path); got != want {
+			t.Errorf("pattern %q: got %v, want %v", p, got, want)
+		}
+	}
+}
+
+func TestDepthPolicy(t *testing.T) {
+	type Tree struct {
+		Name string         `json:"name" groups:"public"`
+		Kids []Tree         `json:"kids" groups:"public"`
+		Attr map[string]int `json:"attr" groups:"public"`
+	}
+	tr := Tree{Name: "a", Kids: []Tree{{Name: "b"}}, Attr: map[string]int{"x": 1}}
+
+	b, err := NewEncoder().WithGroups("public").WithMaxDepth(1).WithDepthPolicy(DepthTruncateNull).Marshal(tr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"name":"a","kids":null,"attr":null}` {
+		t.Fatalf("truncate null mismatch: %s", string(b))
+	}
+
+	b, err = NewEncoder().WithGroups("public").WithMaxDepth(2).WithDepthPolicy(DepthTruncateOmit).Marshal(tr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"name":"a","kids":[],"attr":{"x":1}}` {
+		t.Fatalf("truncate omit mismatch: %s", string(b))
+	}
+}
+
+func TestPathString(t *testing.T) {
+	p := Path{{Kind: SegmentKey, Name: "a.b"}, {Kind: SegmentIndex, Index: 0}, {Kind: SegmentKey, Name: "c"}}
+	if p.String() != `["a.b"][0].c` {
+		t.Fatalf("unexpected path string: %s", p)
+	}
+}
+
+func TestScratchArena(t *testing.T) {
+	u := User{ID: 1, Name: "A", Tags: []string{"x"}, Addr: Address{City: "SZ"}}
+	enc := NewEncoder().WithGroups("public").WithDenyFields("tags").WithScratchArena(true)
+	want, _ := NewEncoder().WithGroups("public").WithDenyFields("tags").Marshal(u)
+	for i := 0; i < 3; i++ {
+		b, err := enc.Marshal(u)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != string(want) {
+			t.Fatalf("pooled context output mismatch: %s vs %s", b, want)
+		}
+	}
+}
+
+func TestCycleHandling(t *testing.T) {
+	a := &Node{Val: 1}
+	a.Next = &Node{Val: 2, Next: a}
+	enc := NewEncoder().WithGroups("public")
+
+	cases := map[CycleHandling]string{
+		CycleNull: `{"val":1,"next":{"val":2,"next":null}}`,
+		CycleOmit: `{"val":1,"next":{"val":2}}`,
+		CycleRef:  `{"val":1,"next":{"val":2,"next":{"$ref":"#"}}}`,
+	}
+	for h, want := range cases {
+		b, err := enc.WithCycleHandling(h).Marshal(a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != want {
+			t.Fatalf("cycle handling %d: got %s, want %s", h, b, want)
+		}
+	}
+
+	list := []*Node{a}
+	b, _ := enc.WithCycleHandling(CycleRef).Marshal(list)
+	if !strings.Contains(string(b), `{"$ref":"#/0"}`) {
+		t.Fatalf("ref should point into slice: %s", b)
+	}
+
+	// 首字段为结构体时不应误判为循环
+	type Inner struct {
+		X int `json:"x"`
+	}
+	type Outer struct {
+		In Inner `json:"in"`
+	}
+	if _, err := NewEncoder().Marshal(&Outer{}); err != nil {
+		t.Fatalf("nested first field misdetected as cycle: %v", err)
+	}
+}
+
+func TestMaskTag(t *testing.T) {
+	type Contact struct {
+		Email string `json:"email" groups:"public,admin;mask=email;unmask=admin"`
+		Phone string `json:"phone" groups:"public;mask=phone"`
+		Card  *int   `json:"card" groups:"public;mask=last4"`
+		Bad   string `json:"bad" groups:"debug;mask=nope"`
+	}
+	card := 4111111111111111
+	c := Contact{Email: "alice@example.com", Phone: "13800001234", Card: &card}
+
+	b, err := NewEncoder().WithGroups("public").Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"email":"a****@example.com","phone":"*******1234","card":"************1111"}` {
+		t.Fatalf("mask mismatch: %s", b)
+	}
+
+	b, _ = NewEncoder().WithGroups("admin").Marshal(c)
+	if !strings.Contains(string(b), "alice@example.com") {
+		t.Fatalf("unmask group should see raw value: %s", b)
+	}
+
+	if _, err := NewEncoder().WithGroups("debug").Marshal(c); !errors.Is(err, ErrUnknownMask) {
+		t.Fatalf("expect ErrUnknownMask, got %v", err)
+	}
+}
+
+func TestKeyDictionary(t *testing.T) {
+	dict := string(NewEncoder().WithGroups("public").KeyDictionary([]User{}))
+	want := `"id":"name":"tags":"scores":"address":"city":"line1":"created_at":`
+	if dict != want {
+		t.Fatalf("dictionary mismatch:\n got %s\nwant %s", dict, want)
+	}
+
+	// 同类型对象键序稳定：多次输出完全一致
+	u := User{ID: 1, Name: "A", Addr: Address{City: "SZ"}}
+	first, _ := NewEncoder().WithGroups("public").Marshal(u)
+	for i := 0; i < 5; i++ {
+		b, _ := NewEncoder().WithGroups("public").Marshal(u)
+		if string(b) != string(first) {
+			t.Fatalf("unstable key order: %s vs %s", b, first)
+		}
+	}
+}
+
+func TestDebugLogger(t *testing.T) {
+	prev := debugEnabled
+	debugEnabled = func() bool { return true }
+	defer func() { debugEnabled = prev }()
+
+	type Probe struct {
+		ID int `json:"id" groups:"public"`
+	}
+	var buf bytes.Buffer
+	enc := NewEncoder().WithGroups("public").WithDebugLogger(slog.New(slog.NewTextHandler(&buf, nil)))
+	_, _ = enc.Marshal(Probe{})
+	_, _ = enc.Marshal(Probe{})
+	out := buf.String()
+	if strings.Count(out, "first encode of type") != 1 {
+		t.Fatalf("should log once per type: %s", out)
+	}
+	if !strings.Contains(out, "Probe") || !strings.Contains(out, "id[public]") {
+		t.Fatalf("schema summary missing: %s", out)
+	}
+}
+
+func TestFieldTransformer(t *testing.T) {
+	var paths []string
+	enc := NewEncoder().WithGroups("public").WithFieldTransformer(func(path Path, f FieldInfo, v reflect.Value) (any, bool) {
+		paths = append(paths, path.String())
+		switch f.JSONName {
+		case "id":
+			return "user-" + strconv.Itoa(int(v.Int())), true
+		case "scores", "created_at":
+			return nil, false
+		}
+		return v.Interface(), true
+	})
+	b, err := enc.Marshal(User{ID: 7, Name: "A", Addr: Address{City: "SZ"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"id":"user-7","name":"A","address":{"city":"SZ"}}` {
+		t.Fatalf("transformer output mismatch: %s", b)
+	}
+	if !slices.Contains(paths, "address.city") {
+		t.Fatalf("nested path missing: %v", paths)
+	}
+}
+
+type Person struct {
+	First string `json:"first" groups:"public"`
+	Last  string `json:"last" groups:"public"`
+}
+
+func TestVirtualField(t *testing.T) {
+	RegisterVirtualField("full_name", []string{"public"}, func(p Person) any { return p.First + " " + p.Last })
+	RegisterVirtualField("initials", []string{"admin"}, func(p Person) any { return p.First[:1] + p.Last[:1] })
+
+	b, err := NewEncoder().WithGroups("public").Marshal(&Person{First: "Ada", Last: "Lovelace"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"first":"Ada","last":"Lovelace","full_name":"Ada Lovelace"}` {
+		t.Fatalf("virtual field mismatch: %s", b)
+	}
+
+	b, _ = NewEncoder().WithGroups("public").WithDenyFields("full_name").Marshal(Person{First: "A", Last: "B"})
+	if strings.Contains(string(b), "full_name") {
+		t.Fatalf("deny should hide virtual field: %s", b)
+	}
+}
+
+func TestPrerender(t *testing.T) {
+	enc := NewEncoder().WithGroups("admin")
+	enc.Prerender([]User{})
+	key := planKey{schemaKey: enc.schemaKey(reflect.TypeFor[Address]()), groups: "admin", mode: ModeOr}
+	p, ok := planCache.Load(key)
+	if !ok {
+		t.Fatalf("nested plan should be compiled")
+	}
+	if !p.filtered || len(p.fields) != 2 {
+		t.Fatalf("unexpected plan: %+v", p)
+	}
+
+	u := User{ID: 1, Name: "A", Email: "e", Addr: Address{City: "SZ"}}
+	b, err := enc.Marshal(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(b), `{"id":1,"name":"A","email":"e","address":{"city":"SZ"}`) {
+		t.Fatalf("planned output mismatch: %s", b)
+	}
+}
+
+func TestTagKeyFallback(t *testing.T) {
+	type Legacy struct {
+		A string `json:"a" groupjson:"public"`
+		B string `json:"b" groups:"public" groupjson:"admin"`
+		C string `json:"c" groupjson:"admin"`
+	}
+	v := Legacy{A: "a", B: "b", C: "c"}
+	enc := NewEncoder().WithGroups("public").WithTagKeyFallback("groupjson")
+	b, err := enc.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"a":"a","b":"b"}` {
+		t.Fatalf("fallback tag mismatch: %s", b)
+	}
+
+	conflicts := enc.VetTagMigration(&v)
+	if len(conflicts) != 1 || conflicts[0].Field != "B" {
+		t.Fatalf("expect one conflict on B: %v", conflicts)
+	}
+}
+
+func TestFieldConditions(t *testing.T) {
+	type Member struct {
+		Verified bool   `json:"-"`
+		Email    string `json:"email" groups:"public;if=Verified"`
+		Pending  string `json:"pending" groups:"public;if=!Verified"`
+		Phone    string `json:"phone" groups:"public"`
+		Bad      string `json:"bad" groups:"debug;if=Nope"`
+	}
+	m := Member{Email: "e", Pending: "p", Phone: "1"}
+	b, err := NewEncoder().WithGroups("public").Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"pending":"p","phone":"1"}` {
+		t.Fatalf("unverified output mismatch: %s", b)
+	}
+
+	m.Verified = true
+	b, _ = NewEncoder().WithGroups("public").
+		WithFieldPredicate("phone", func(parent reflect.Value) bool { return !parent.FieldByName("Verified").Bool() }).
+		Marshal(m)
+	if string(b) != `{"email":"e"}` {
+		t.Fatalf("verified output mismatch: %s", b)
+	}
+
+	if _, err := NewEncoder().WithGroups("debug").Marshal(m); !errors.Is(err, ErrUnknownCondition) {
+		t.Fatalf("expect ErrUnknownCondition, got %v", err)
+	}
+}
+
+func TestTemplateFuncs(t *testing.T) {
+	tpl := template.Must(template.New("t").Funcs(TemplateFuncs("public")).
+		Parse(`{{groupjsonMarshal .}}|{{(groupjsonMap .).name}}`))
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, User{ID: 1, Name: "A", Email: "secret"}); err != nil {
+		t.Fatal(err)
+	}
+	s := buf.String()
+	if strings.Contains(s, "secret") || !strings.HasSuffix(s, "|A") {
+		t.Fatalf("template output mismatch: %s", s)
+	}
+}
+
+func TestAssetHook(t *testing.T) {
+	type Photo struct {
+		ID   int    `json:"id" groups:"public"`
+		Data []byte `json:"data" groups:"public" asset:"image"`
+	}
+	type thumb struct {
+		URL  string `json:"url" groups:"public"`
+		Size int    `json:"size" groups:"public"`
+	}
+	p := Photo{ID: 3, Data: []byte("rawbytes")}
+	enc := NewEncoder().WithGroups("public").WithAssetHook(func(kind string, path Path, v reflect.Value) (any, error) {
+		return thumb{URL: "/thumb/" + kind + "/" + path.String(), Size: v.Len()}, nil
+	})
+	b, err := enc.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"id":3,"data":{"url":"/thumb/image/data","size":8}}` {
+		t.Fatalf("asset hook output mismatch: %s", b)
+	}
+}
+
+func TestInlineField(t *testing.T) {
+	type Audit struct {
+		CreatedBy string `json:"created_by" groups:"admin"`
+		Version   int    `json:"version" groups:"public"`
+	}
+	type Stamp struct {
+		At int `json:"at" groups:"public"`
+	}
+	type Doc struct {
+		Title string `json:"title" groups:"public"`
+		Audit Audit  `json:"audit,inline"`
+		Stamp *Stamp `json:",inline"`
+	}
+	b, err := NewEncoder().WithGroups("public").Marshal(Doc{Title: "t", Audit: Audit{CreatedBy: "u", Version: 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"title":"t","version":2}` {
+		t.Fatalf("inline output mismatch: %s", b)
+	}
+
+	b, _ = NewEncoder().WithGroups("public").Marshal(Doc{Stamp: &Stamp{At: 9}})
+	if string(b) != `{"title":"","version":0,"at":9}` {
+		t.Fatalf("inline pointer output mismatch: %s", b)
+	}
+}
+
+func TestInlinePrefix(t *testing.T) {
+	type Stamps struct {
+		CreatedAt int `json:"created_at" groups:"public"`
+	}
+	type Item struct {
+		Stamps `json:",inline=meta_"`
+		Source Stamps `json:"source,inline=src_"`
+		ID     int    `json:"id" groups:"public"`
+	}
+	b, err := NewEncoder().WithGroups("public").Marshal(Item{Stamps: Stamps{1}, Source: Stamps{2}, ID: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"id":3,"meta_created_at":1,"src_created_at":2}` {
+		t.Fatalf("prefixed inline output mismatch: %s", b)
+	}
+}
+
+func TestSliceToMaps(t *testing.T) {
+	users := []*User{{ID: 1, Name: "a", Email: "x", Addr: Address{City: "SZ"}, Tags: []string{"t"}}, nil}
+	maps, err := NewEncoder().WithGroups("public").SliceToMaps(users)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(maps) != 2 || maps[1] != nil {
+		t.Fatalf("unexpected maps: %v", maps)
+	}
+	m := maps[0]
+	if m["id"] != json.Number("1") || m["name"] != "a" {
+		t.Fatalf("scalar fields mismatch: %v", m)
+	}
+	if _, ok := m["email"]; ok {
+		t.Fatalf("email leaked: %v", m)
+	}
+	if addr, ok := m["address"].(map[string]any); !ok || addr["city"] != "SZ" {
+		t.Fatalf("nested struct should be a map: %v", m["address"])
+	}
+	if tags, ok := m["tags"].([]any); !ok || tags[0] != "t" {
+		t.Fatalf("slice should be []any: %v", m["tags"])
+	}
+	if m["created_at"] != "0001-01-01T00:00:00Z" {
+		t.Fatalf("marshaler values should match their JSON output: %v", m["created_at"])
+	}
+
+	if _, err := NewEncoder().SliceToMaps(User{}); err != ErrInvalidType {
+		t.Fatalf("expect ErrInvalidType, got %v", err)
+	}
+}
+
+func TestStructAsArray(t *testing.T) {
+	type Point struct {
+		TS    int64   `json:"ts" groups:"public"`
+		Value float64 `json:"value" groups:"public"`
+		Note  string  `json:"note,omitempty" groups:"public"`
+		Debug string  `json:"debug" groups:"internal"`
+	}
+	series := struct {
+		Name   string  `json:"name" groups:"public"`
+		Points []Point `json:"points" groups:"public"`
+	}{Name: "cpu", Points: []Point{{TS: 1, Value: 0.5, Note: "n"}, {TS: 2, Value: 0.7}}}
+
+	b, err := NewEncoder().WithGroups("public").WithStructAsArray(reflect.TypeFor[Point]()).Marshal(series)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"name":"cpu","points":[[1,0.5,"n"],[2,0.7,null]]}` {
+		t.Fatalf("tuple output mismatch: %s", b)
+	}
+}
+
+func TestNamingStrategy(t *testing.T) {
+	type Legacy struct {
+		UserID       int    `groups:"public"`
+		HTTPServer   string `groups:"public"`
+		DisplayName  string `json:"name" groups:"public"`
+		OAuth2Client string `groups:"public"`
+	}
+	v := Legacy{UserID: 1, HTTPServer: "h", DisplayName: "n", OAuth2Client: "c"}
+	cases := map[string]NamingStrategy{
+		`{"user_id":1,"http_server":"h","name":"n","o_auth2_client":"c"}`: SnakeCase,
+		`{"user-id":1,"http-server":"h","name":"n","o-auth2-client":"c"}`: KebabCase,
+		`{"userId":1,"httpServer":"h","name":"n","oAuth2Client":"c"}`:     CamelCase,
+		`{"USERID":1,"HTTPSERVER":"h","name":"n","OAUTH2CLIENT":"c"}`:     {Name: "upper", Fn: strings.ToUpper},
+	}
+	for want, ns := range cases {
+		b, err := NewEncoder().WithGroups("public").WithNamingStrategy(ns).Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != want {
+			t.Errorf("%s: got %s, want %s", ns.Name, b, want)
+		}
+	}
+}
+
+func TestNullAs(t *testing.T) {
+	type Profile struct {
+		Nick  *string        `json:"nick" groups:"public" nullas:"\"\""`
+		Score *int           `json:"score" groups:"public" nullas:"0"`
+		Tags  []string       `json:"tags" groups:"public" nullas:"[]"`
+		Extra map[string]int `json:"extra" groups:"public"`
+	}
+	b, err := NewEncoder().WithGroups("public").Marshal(Profile{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"nick":"","score":0,"tags":[],"extra":null}`; string(b) != want {
+		t.Errorf("got %s, want %s", b, want)
+	}
+
+	nick := "neo"
+	b, _ = NewEncoder().WithGroups("public").Marshal(Profile{Nick: &nick, Tags: []string{}})
+	if want := `{"nick":"neo","score":0,"tags":[],"extra":null}`; string(b) != want {
+		t.Errorf("got %s, want %s", b, want)
+	}
+
+	type Bad struct {
+		P *int `json:"p" groups:"public" nullas:"nope"`
+	}
+	if _, err := NewEncoder().WithGroups("public").Marshal(Bad{}); !errors.Is(err, ErrInvalidNullAs) {
+		t.Errorf("err = %v, want ErrInvalidNullAs", err)
+	}
+}
+
+func TestInt64AsString(t *testing.T) {
+	type IDs struct {
+		Small int64  `json:"small" groups:"public"`
+		Big   int64  `json:"big" groups:"public"`
+		Neg   int64  `json:"neg" groups:"public"`
+		U     uint64 `json:"u" groups:"public"`
+	}
+	v := IDs{Small: 1<<53 - 1, Big: 1<<53 + 1, Neg: -(1 << 60), U: 1 << 63}
+	b, err := NewEncoder().WithGroups("public").WithInt64AsString(true).Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"small":9007199254740991,"big":"9007199254740993","neg":"-1152921504606846976","u":"9223372036854775808"}`
+	if string(b) != want {
+		t.Errorf("got %s, want %s", b, want)
+	}
+	b, _ = NewEncoder().WithGroups("public").Marshal(v)
+	if strings.Contains(string(b), `"9007199254740993"`) {
+		t.Errorf("default should emit numbers, got %s", b)
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	cases := map[string]GroupMode{"": ModeOr, "or": ModeOr, " ANY ": ModeOr, "and": ModeAnd, "All": ModeAnd}
+	for in, want := range cases {
+		got, err := ParseMode(in)
+		if err != nil || got != want {
+			t.Errorf("ParseMode(%q) = %v, %v; want %v", in, got, err, want)
+		}
+		if back, _ := ParseMode(got.String()); back != got {
+			t.Errorf("round trip of %v failed", got)
+		}
+	}
+	if _, err := ParseMode("xor"); !errors.Is(err, ErrInvalidMode) {
+		t.Errorf("err = %v, want ErrInvalidMode", err)
+	}
+}
+
+func TestNilCollections(t *testing.T) {
+	type Page struct {
+		Items []string         `json:"items" groups:"public"`
+		Meta  map[string]int   `json:"meta" groups:"public"`
+		Raw   []byte           `json:"raw" groups:"public"`
+		Kept  []int            `json:"kept" groups:"public" nullas:"null"`
+		Inner map[string][]int `json:"inner" groups:"public"`
+	}
+	v := Page{Inner: map[string][]int{"a": nil}}
+	b, _ := NewEncoder().WithGroups("public").Marshal(v)
+	if want := `{"items":null,"meta":null,"raw":null,"kept":null,"inner":{"a":null}}`; string(b) != want {
+		t.Errorf("default: got %s, want %s", b, want)
+	}
+	b, _ = NewEncoder().WithGroups("public").WithNilCollections(NilAsEmpty).Marshal(v)
+	if want := `{"items":[],"meta":{},"raw":"","kept":null,"inner":{"a":[]}}`; string(b) != want {
+		t.Errorf("NilAsEmpty: got %s, want %s", b, want)
+	}
+}
+
+func TestFieldLevelAndGroups(t *testing.T) {
+	type Player struct {
+		Name  string `json:"name" groups:"public"`
+		Score int    `json:"score" groups:"public+stats,admin"`
+		Notes string `json:"notes"`
+	}
+	v := Player{Name: "a", Score: 9, Notes: "n"}
+	cases := []struct {
+		enc  Encoder
+		want string
+	}{
+		{NewEncoder().WithGroups("public"), `{"name":"a"}`},
+		{NewEncoder().WithGroups("stats"), `{}`},
+		{NewEncoder().WithGroups("public", "stats"), `{"name":"a","score":9}`},
+		{NewEncoder().WithGroups("admin"), `{"score":9}`},
+		{NewEncoder().WithGroups("public", "stats").WithGroupMode(ModeAnd), `{"score":9}`},
+	}
+	for _, c := range cases {
+		b, err := c.enc.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != c.want {
+			t.Errorf("groups %v: got %s, want %s", c.enc.opts.Groups, b, c.want)
+		}
+	}
+	m, _ := NewEncoder().VisibilityMatrix(v)
+	if want := []string{"admin", "public", "stats"}; !slices.Equal(m.Groups, want) {
+		t.Errorf("matrix groups = %v, want %v", m.Groups, want)
+	}
+}
+
+func TestInvariant(t *testing.T) {
+	type Member struct {
+		Email   string `json:"email" groups:"public"`
+		Deleted bool   `json:"deleted" groups:"public"`
+	}
+	type Team struct {
+		Members []Member `json:"members" groups:"public"`
+	}
+	errLeak := errors.New("deleted member exposes email")
+	enc := NewEncoder().WithGroups("public").WithInvariant(func(v any) error {
+		if m, ok := v.(Member); ok && m.Deleted && m.Email != "" {
+			return errLeak
+		}
+		return nil
+	})
+
+	if _, err := enc.Marshal(Team{Members: []Member{{Email: "a@x.io"}, {Deleted: true}}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, err := enc.Marshal(&Team{Members: []Member{{Email: "a@x.io"}, {Email: "b@x.io", Deleted: true}}})
+	var ie *InvariantError
+	if !errors.As(err, &ie) || !errors.Is(err, errLeak) {
+		t.Fatalf("err = %v, want InvariantError wrapping errLeak", err)
+	}
+	if ie.Path.String() != "members[1]" || ie.Type != reflect.TypeFor[Member]() {
+		t.Errorf("path = %q, type = %v", ie.Path.String(), ie.Type)
+	}
+}
+
+func TestOmitZeroIsZero(t *testing.T) {
+	type Window struct {
+		Start time.Time `json:"start,omitzero" groups:"public"`
+		End   time.Time `json:"end,omitzero" groups:"public"`
+		ID    evenZero  `json:"id,omitzero" groups:"public"`
+		Ref   *evenZero `json:"ref,omitzero" groups:"public"`
+		Addr  Address   `json:"addr,omitzero" groups:"public"`
+		Tags  []string  `json:"tags,omitzero" groups:"public"`
+	}
+	local := time.Date(1, 1, 1, 0, 0, 0, 0, time.FixedZone("X", 3600))
+	b, err := NewEncoder().WithGroups("public").Marshal(Window{End: local, ID: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	std, _ := json.Marshal(struct {
+		Start time.Time `json:"start,omitzero"`
+		End   time.Time `json:"end,omitzero"`
+		ID    evenZero  `json:"id,omitzero"`
+	}{End: local, ID: 2})
+	if string(b) != string(std) {
+		t.Errorf("got %s, want %s (encoding/json)", b, std)
+	}
+	b, _ = NewEncoder().WithGroups("public").Marshal(Window{ID: 3, Addr: Address{City: "x"}})
+	if want := `{"id":3,"addr":{"city":"x"}}`; string(b) != want {
+		t.Errorf("got %s, want %s", b, want)
+	}
+}
+
+// evenZero 以偶数为零值，用于验证 omitzero 调用 IsZero 方法。
+type evenZero int
+
+func (z evenZero) IsZero() bool { return z%2 == 0 }
+
+func TestSchemaRoundTrip(t *testing.T) {
+	type Node struct {
+		Name     string           `json:"name" groups:"public"`
+		Email    string           `json:"email" groups:"public,admin;mask=email;unmask=admin"`
+		Secret   string           `json:"secret" groups:"-"`
+		Score    int              `json:"score" groups:"public+stats"`
+		Children []*Node          `json:"children" groups:"public"`
+		Index    map[string]*Node `json:"index" groups:"admin"`
+		When     time.Time        `json:"when" groups:"public"`
+	}
+	v := Node{
+		Name: "root", Email: "root@example.com", Secret: "s", Score: 5,
+		Children: []*Node{{Name: "kid", Email: "kid@example.com"}},
+		Index:    map[string]*Node{"k": {Name: "k", Secret: "x"}},
+	}
+	enc := NewEncoder()
+	s, err := enc.Schema(&v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadSchema(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Types) != 1 {
+		t.Fatalf("self-referencing type should be defined once: %s", data)
+	}
+
+	// sidecar 对完整 JSON 执行筛选，应与直接编码一致
+	full, _ := json.Marshal(v)
+	for _, groups := range [][]string{nil, {"public"}, {"admin"}, {"public", "stats"}} {
+		got, err := loaded.Filter(full, groups, ModeOr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, _ := enc.WithGroups(groups...).Marshal(v)
+		if string(got) != string(want) {
+			t.Errorf("%v:\n got %s\nwant %s", groups, got, want)
+		}
+	}
+
+	if _, err := LoadSchema([]byte(`{"version":99,"root":"x","types":{"x":[]}}`)); !errors.Is(err, ErrInvalidSchema) {
+		t.Errorf("err = %v, want ErrInvalidSchema", err)
+	}
+}
+
+func TestSchemaNestedContainers(t *testing.T) {
+	type Secret struct {
+		Name string `json:"name" groups:"public"`
+		Role string `json:"role" groups:"admin"`
+	}
+	type Outer struct {
+		Grid  [][]Secret           `json:"grid" groups:"public"`
+		ByK   map[string][]Secret  `json:"by_k" groups:"public"`
+		Pages []map[string]*Secret `json:"pages" groups:"public"`
+	}
+	sec := Secret{Name: "n", Role: "root"}
+	v := Outer{
+		Grid:  [][]Secret{{sec}, {sec, sec}},
+		ByK:   map[string][]Secret{"k": {sec}},
+		Pages: []map[string]*Secret{{"p": &sec}},
+	}
+	s, err := NewEncoder().Schema(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range s.Types[s.Root] {
+		if f.Ref == "" {
+			t.Errorf("%s: nested container should reference the element type", f.Name)
+		}
+	}
+	if c := s.Types[s.Root][1].Container; c != "map/array" {
+		t.Errorf("by_k container = %q, want map/array", c)
+	}
+
+	full, _ := json.Marshal(v)
+	got, err := s.Filter(full, []string{"public"}, ModeOr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := Marshal(v, "public")
+	if string(got) != string(want) {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestDeepOmitEmpty(t *testing.T) {
+	type Inner struct {
+		Secret string `json:"secret" groups:"admin"`
+	}
+	type Middle struct {
+		In  Inner  `json:"in" groups:"public,admin"`
+		Ptr *Inner `json:"ptr" groups:"public,admin"`
+	}
+	type Outer struct {
+		ID   int    `json:"id" groups:"public"`
+		Mid  Middle `json:"mid" groups:"public,admin"`
+		Last Inner  `json:"last" groups:"public,admin"`
+	}
+	v := Outer{ID: 1, Mid: Middle{Ptr: &Inner{}}}
+	enc := NewEncoder().WithGroups("public")
+	b, _ := enc.Marshal(v)
+	if want := `{"id":1,"mid":{"in":{},"ptr":{}},"last":{}}`; string(b) != want {
+		t.Errorf("default: got %s, want %s", b, want)
+	}
+	b, _ = enc.WithDeepOmitEmpty(true).Marshal(v)
+	if want := `{"id":1}`; string(b) != want {
+		t.Errorf("deep: got %s, want %s", b, want)
+	}
+	b, _ = enc.WithGroups("admin").WithDeepOmitEmpty(true).Marshal(v)
+	if want := `{"mid":{"in":{"secret":""},"ptr":{"secret":""}},"last":{"secret":""}}`; string(b) != want {
+		t.Errorf("admin: got %s, want %s", b, want)
+	}
+	maps, _ := enc.WithDeepOmitEmpty(true).SliceToMaps([]Outer{v})
+	if len(maps[0]) != 1 {
+		t.Errorf("SliceToMaps: got %v", maps[0])
+	}
+}
+
+func TestSkipLockFields(t *testing.T) {
+	type noCopy struct{}
+	type Counter struct {
+		sync.Mutex
+		noCopy noCopy
+		Mu     sync.RWMutex   `groups:"public"`
+		Once   *sync.Once     `groups:"public"`
+		WG     sync.WaitGroup `groups:"public"`
+		Hits   atomic.Int64   `groups:"public"`
+		Name   string         `json:"name" groups:"public"`
+	}
+	c := &Counter{Name: "c", Once: new(sync.Once)}
+	c.Lock()
+	defer c.Unlock()
+	b, err := NewEncoder().WithGroups("public").Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"name":"c"}`; string(b) != want {
+		t.Errorf("got %s, want %s", b, want)
+	}
+}
+
+func TestStringOption(t *testing.T) {
+	type Item struct {
+		Price float64 `json:"price,string" groups:"public"`
+		Qty   *int    `json:"qty,string" groups:"public"`
+		Name  string  `json:"name,string" groups:"public"`
+		On    bool    `json:"on,string" groups:"public"`
+		Big   int64   `json:"big,string" groups:"public"`
+		When  *int    `json:"when,string" groups:"public"`
+		Tags  []int   `json:"tags,string" groups:"public"`
+	}
+	qty := 3
+	v := Item{Price: 12.5, Qty: &qty, Name: "a", On: true, Big: 1 << 60, Tags: []int{1}}
+	std, _ := json.Marshal(v)
+	for _, enc := range []Encoder{NewEncoder(), NewEncoder().WithInt64AsString(true)} {
+		b, err := enc.WithGroups("public").Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != string(std) {
+			t.Errorf("got %s, want %s", b, std)
+		}
+	}
+}
+
+func TestProfilesNegotiate(t *testing.T) {
+	p := Profiles{
+		"compact":                          {"public"},
+		"full":                             {"public", "admin"},
+		"https://example.com/profiles/std": {"public", "stats"},
+	}
+	cases := map[string]string{
+		"application/json;view=compact":                                         "compact",
+		`application/json;profile="urn:x https://example.com/profiles/std"`:     "https://example.com/profiles/std",
+		"text/html, application/json;view=compact;q=0.5, */*;view=full;q=0.9":   "full",
+		"application/json;view=full;q=0, application/vnd.api+json;view=compact": "compact",
+		"text/html;view=full": "",
+		"application/json":    "",
+	}
+	for accept, want := range cases {
+		got, _, _ := p.Negotiate(accept)
+		if got != want {
+			t.Errorf("Negotiate(%q) = %q, want %q", accept, got, want)
+		}
+	}
+
+	u := User{ID: 1, Name: "A", Email: "e"}
+	h := p.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := NewEncoder().MarshalContext(r.Context(), u)
+		w.Write(b)
+	}))
+	serve := func(accept string, granted ...string) string {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Accept", accept)
+		if granted != nil {
+			r = r.WithContext(ContextWithGroups(r.Context(), granted...))
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Body.String()
+	}
+	if got := serve("application/json;view=full"); !strings.Contains(got, `"email"`) {
+		t.Errorf("full view should include email: %s", got)
+	}
+	if got := serve("application/json;view=full", "public"); strings.Contains(got, `"email"`) {
+		t.Errorf("view must not widen granted groups: %s", got)
+	}
+}
+
+func TestKeyTables(t *testing.T) {
+	type Row struct {
+		ID    int    `json:"id" groups:"public"`
+		Name  string `json:"name,omitempty" groups:"public"`
+		Email string `json:"email" groups:"admin"`
+	}
+	type Report struct {
+		Title string `json:"title" groups:"public"`
+		Rows  []*Row `json:"rows" groups:"public"`
+	}
+	v := Report{Title: "t", Rows: []*Row{{ID: 1, Name: "a", Email: "x"}, nil, {ID: 2}}}
+	enc := NewEncoder().WithGroups("public").WithKeyTables(true)
+	b, err := enc.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"title":"t","rows":{"keys":["id","name"],"rows":[[1,"a"],null,[2,null]]}}`
+	if string(b) != want {
+		t.Errorf("got %s, want %s", b, want)
+	}
+	b, _ = enc.Marshal([]Row{})
+	if want := `{"keys":["id","name"],"rows":[]}`; string(b) != want {
+		t.Errorf("empty: got %s, want %s", b, want)
+	}
+	b, _ = enc.Marshal([]int{1, 2})
+	if string(b) != "[1,2]" {
+		t.Errorf("scalar slices are unaffected: %s", b)
+	}
+}
+
+func TestMarshalColumnar(t *testing.T) {
+	rows := []*User{
+		{ID: 1, Name: "A", Email: "a@x", Tags: []string{"t"}},
+		nil,
+		{ID: 2, Name: "B"},
+	}
+	b, err := NewEncoder().WithGroups("public").MarshalColumnar(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cols map[string][]any
+	if err := json.Unmarshal(b, &cols); err != nil {
+		t.Fatalf("invalid JSON %s: %v", b, err)
+	}
+	if _, ok := cols["email"]; ok {
+		t.Errorf("email column should be filtered: %s", b)
+	}
+	if got := cols["id"]; len(got) != 3 || got[0] != 1.0 || got[1] != nil || got[2] != 2.0 {
+		t.Errorf("id column = %v", got)
+	}
+	if got := cols["tags"]; len(got) != 3 || got[2] != nil {
+		t.Errorf("omitted values should be null: %v", got)
+	}
+	if _, err := NewEncoder().MarshalColumnar([]int{1}); !errors.Is(err, ErrInvalidType) {
+		t.Errorf("err = %v, want ErrInvalidType", err)
+	}
+}
+
+func TestGroupedMap(t *testing.T) {
+	type Settings struct {
+		Flags GroupedMap[any] `json:"flags" groups:"public,admin"`
+	}
+	s := Settings{Flags: GroupedMap[any]{}}
+	s.Flags.Set("dark_mode", true, "public", "admin")
+	s.Flags.Set("billing", Address{City: "SZ", Line1: "L"}, "admin")
+	s.Flags.Set("both", 1, "public+beta")
+	s.Flags.Set("secret", "x", "-")
+
+	enc := NewEncoder().WithSortKeys(true)
+	cases := []struct {
+		groups []string
+		want   string
+	}{
+		{[]string{"public"}, `{"flags":{"dark_mode":true}}`},
+		{[]string{"admin"}, `{"flags":{"billing":{"city":"SZ","line1":"L"},"dark_mode":true}}`},
+		{[]string{"public", "beta"}, `{"flags":{"both":1,"dark_mode":true}}`},
+	}
+	for _, c := range cases {
+		b, err := enc.MarshalGroups(s, c.groups...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != c.want {
+			t.Errorf("%v: got %s, want %s", c.groups, b, c.want)
+		}
+	}
+	b, _ := enc.WithGroups("admin").WithDenyFields("flags.billing").Marshal(s)
+	if strings.Contains(string(b), "billing") {
+		t.Errorf("deny rule should hide entry: %s", b)
+	}
+}
+
+type shape interface{ area() float64 }
+
+type circle struct {
+	R      float64 `json:"r" groups:"public"`
+	Secret string  `json:"secret" groups:"admin"`
+}
+
+func (c circle) area() float64 { return 3 * c.R * c.R }
+
+type square struct {
+	Side float64 `json:"side" groups:"public"`
+	Kind string  `json:"type" groups:"public"`
+}
+
+func (s *square) area() float64 { return s.Side * s.Side }
+
+func TestTypeDiscriminator(t *testing.T) {
+	RegisterType[circle]("circle")
+	RegisterType[square]("square")
+	type Canvas struct {
+		Main   shape            `json:"main" groups:"public"`
+		Shapes []shape          `json:"shapes" groups:"public"`
+		ByName map[string]shape `json:"by_name" groups:"public"`
+	}
+	v := Canvas{
+		Main:   circle{R: 1, Secret: "s"},
+		Shapes: []shape{&square{Side: 2, Kind: "legacy"}, nil},
+		ByName: map[string]shape{"c": circle{R: 3}},
+	}
+	b, err := NewEncoder().WithGroups("public").WithTypeDiscriminator("type").Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"main":{"type":"circle","r":1},"shapes":[{"type":"square","side":2},null],"by_name":{"c":{"type":"circle","r":3}}}`
+	if string(b) != want {
+		t.Errorf("got %s\nwant %s", b, want)
+	}
+	b, _ = NewEncoder().WithGroups("public").Marshal(v)
+	if strings.Contains(string(b), `"circle"`) {
+		t.Errorf("discriminator should be opt-in: %s", b)
+	}
+}
+
+func TestPinnedFields(t *testing.T) {
+	type Event struct {
+		Msg  string `json:"msg" groups:"public"`
+		Type string `json:"type" groups:"public" order:"2"`
+		ID   int    `json:"id" groups:"public" order:"1"`
+		At   int    `json:"at" groups:"public"`
+	}
+	type Batch struct {
+		Events []Event `json:"events" groups:"public"`
+		ID     string  `json:"id" groups:"public"`
+	}
+	v := Batch{Events: []Event{{Msg: "m", Type: "t", ID: 1, At: 2}}, ID: "b"}
+	b, _ := NewEncoder().WithGroups("public").Marshal(v)
+	if want := `{"events":[{"id":1,"type":"t","msg":"m","at":2}],"id":"b"}`; string(b) != want {
+		t.Errorf("order tag: got %s, want %s", b, want)
+	}
+	for _, enc := range []Encoder{NewEncoder(), NewEncoder().WithGroups("public"), NewEncoder().WithGroups("public").WithAllowFields("x")} {
+		b, _ = enc.WithPinnedFields("at", "id").Marshal(v)
+		if want := `{"id":"b","events":[{"at":2,"id":1,"type":"t","msg":"m"}]}`; string(b) != want {
+			t.Errorf("pinned: got %s, want %s", b, want)
+		}
+	}
+}
+
+func TestSyncMapAndSeq(t *testing.T) {
+	type Secret struct {
+		ID    int    `json:"id" groups:"public"`
+		Token string `json:"token" groups:"admin"`
+	}
+	type Repo struct {
+		Cache *sync.Map                  `json:"cache" groups:"public"`
+		Items iter.Seq[Secret]           `json:"items" groups:"public"`
+		ByID  iter.Seq2[string, *Secret] `json:"by_id" groups:"public"`
+		Bad   iter.Seq[func()]           `json:"bad,omitempty" groups:"admin"`
+	}
+	cache := &sync.Map{}
+	cache.Store("b", Secret{ID: 2, Token: "x"})
+	cache.Store("a", 1)
+	v := Repo{
+		Cache: cache,
+		Items: slices.Values([]Secret{{ID: 1, Token: "t1"}, {ID: 2}}),
+		ByID: func(yield func(string, *Secret) bool) {
+			_ = yield("x", &Secret{ID: 3, Token: "t3"}) && yield("y", nil)
+		},
+	}
+	b, err := NewEncoder().WithGroups("public").WithSortKeys(true).Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"cache":{"a":1,"b":{"id":2}},"items":[{"id":1},{"id":2}],"by_id":{"x":{"id":3},"y":null}}`
+	if string(b) != want {
+		t.Errorf("got %s\nwant %s", b, want)
+	}
+
+	v.Bad = func(yield func(func()) bool) { yield(func() {}) }
+	if _, err := NewEncoder().WithGroups("admin").Marshal(v); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("err = %v, want ErrUnsupportedType", err)
+	}
+}
+
+func TestChannelEncoding(t *testing.T) {
+	type Item struct {
+		ID    int    `json:"id" groups:"public"`
+		Token string `json:"token" groups:"admin"`
+	}
+	produce := func(n int) <-chan Item {
+		ch := make(chan Item)
+		go func() {
+			defer close(ch)
+			for i := 1; i <= n; i++ {
+				ch <- Item{ID: i, Token: "t"}
+			}
+		}()
+		return ch
+	}
+	type Page struct {
+		Items <-chan Item `json:"items" groups:"public"`
+	}
+
+	if _, err := NewEncoder().WithGroups("public").Marshal(Page{Items: produce(0)}); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("err = %v, want ErrUnsupportedType without opt-in", err)
+	}
+
+	enc := NewEncoder().WithGroups("public").WithChannelEncoding(true)
+	b, err := enc.Marshal(Page{Items: produce(3)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"items":[{"id":1},{"id":2},{"id":3}]}`; string(b) != want {
+		t.Errorf("got %s, want %s", b, want)
+	}
+	if b, _ := enc.Marshal(Page{}); string(b) != `{"items":null}` {
+		t.Errorf("nil channel: got %s", b)
+	}
+
+	ch := make(chan Item, 5)
+	for i := 0; i < 5; i++ {
+		ch <- Item{ID: i}
+	}
+	close(ch)
+	if _, err := enc.WithMaxChannelItems(3).Marshal(Page{Items: ch}); !errors.Is(err, ErrChannelLimit) {
+		t.Errorf("err = %v, want ErrChannelLimit", err)
+	}
+	if len(ch) != 2 {
+		t.Errorf("%d items left in the channel, want 2 untouched", len(ch))
+	}
+}
+
+func TestExampleTag(t *testing.T) {
+	type Profile struct {
+		Email string    `json:"email" groups:"admin" example:"alice@example.com"`
+		Age   int       `json:"age" groups:"public" example:"30"`
+		Tags  []string  `json:"tags" groups:"public" example:"[\"a\",\"b\"]"`
+		Seen  time.Time `json:"seen" groups:"public" example:"2024-05-01T08:00:00Z"`
+	}
+	s, err := NewEncoder().Schema(Profile{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	for _, f := range s.Types[s.Root] {
+		got[f.Name] = string(f.Example)
+	}
+	want := map[string]string{
+		"email": `"alice@example.com"`,
+		"age":   `30`,
+		"tags":  `["a","b"]`,
+		"seen":  `"2024-05-01T08:00:00Z"`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("examples = %v, want %v", got, want)
+	}
+
+	type Bad struct {
+		Age int `json:"age" example:"thirty"`
+	}
+	if _, err := NewEncoder().Schema(Bad{}); !errors.Is(err, ErrInvalidExample) {
+		t.Errorf("err = %v, want ErrInvalidExample", err)
+	}
+}
+
+func TestEncodeLines(t *testing.T) {
+	users := []User{{ID: 1, Name: "a", Email: "a@x"}, {ID: 2, Name: "b", Email: "b@x"}}
+	enc := NewEncoder().WithGroups("public")
+
+	var want bytes.Buffer
+	for _, u := range users {
+		b, err := enc.Marshal(u)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want.Write(b)
+		want.WriteByte('\n')
+	}
+
+	for name, v := range map[string]any{
+		"slice":     users,
+		"slice ptr": &users,
+		"array":     &[2]User{users[0], users[1]},
+		"seq":       slices.Values(users),
+	} {
+		var got bytes.Buffer
+		if err := enc.WithTopLevelKey("data").EncodeLines(&got, v); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if got.String() != want.String() {
+			t.Errorf("%s:\ngot  %q\nwant %q", name, got.String(), want.String())
+		}
+		if strings.Contains(got.String(), "a@x") {
+			t.Errorf("%s: public lines leaked email", name)
+		}
+	}
+
+	if err := enc.EncodeLines(io.Discard, users[0]); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("err = %v, want ErrUnsupportedType for non-sequence", err)
+	}
+}
+
+// flushRecorder 记录每次 Flush 时已写出的内容。
+type flushRecorder struct {
+	bytes.Buffer
+	flushes []string
+}
+
+func (f *flushRecorder) Flush() { f.flushes = append(f.flushes, f.String()) }
+
+func TestEncodeSeq(t *testing.T) {
+	users := []User{{ID: 1, Name: "a", Email: "a@x"}, {ID: 2, Name: "b"}}
+	cursor := func() func() (any, bool) {
+		i := 0
+		return func() (any, bool) {
+			if i == len(users) {
+				return nil, false
+			}
+			i++
+			return &users[i-1], true
+		}
+	}
+
+	enc := NewEncoder().WithGroups("public").WithTopLevelKey("data")
+	want, err := enc.Marshal(users)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var w flushRecorder
+	if err := enc.EncodeSeq(&w, cursor()); err != nil {
+		t.Fatal(err)
+	}
+	if w.String() != string(want) {
+		t.Errorf("got %s, want %s", w.String(), want)
+	}
+	if len(w.flushes) != len(users)+1 || strings.Contains(w.flushes[0], `"name":"b"`) {
+		t.Errorf("expected a flush per element, got %q", w.flushes)
+	}
+
+	var empty bytes.Buffer
+	if err := NewEncoder().EncodeSeq(&empty, func() (any, bool) { return nil, false }); err != nil || empty.String() != "[]" {
+		t.Errorf("empty seq = %q, %v", empty.String(), err)
+	}
+}
+
+func TestSampler(t *testing.T) {
+	var metas []SampleMeta
+	var bodies [][]byte
+	sink := func(m SampleMeta, b []byte) { metas = append(metas, m); bodies = append(bodies, b) }
+
+	u := &User{ID: 1, Name: "a", Email: "a@x"}
+	enc := NewEncoder().WithGroups("public").WithSampler(1, sink)
+	out, err := enc.Marshal(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.Encode(io.Discard, u); err != nil {
+		t.Fatal(err)
+	}
+	if len(metas) != 2 {
+		t.Fatalf("sampled %d outputs, want 2", len(metas))
+	}
+	want := SampleMeta{Type: "*groupjson.User", Groups: []string{"public"}, Mode: ModeOr, Size: len(out)}
+	if !reflect.DeepEqual(metas[0], want) || string(bodies[0]) != string(out) {
+		t.Errorf("sample = %+v %s, want %+v %s", metas[0], bodies[0], want, out)
+	}
+
+	metas = nil
+	for i := 0; i < 100; i++ {
+		if _, err := enc.WithSampler(0, sink).Marshal(u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(metas) != 0 {
+		t.Errorf("rate 0 sampled %d outputs", len(metas))
+	}
+}
+
+func TestEnvelope(t *testing.T) {
+	users := []User{{ID: 1, Name: "a", Email: "a@x"}, {ID: 2, Name: "b"}}
+	generated := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	enc := NewEncoder().WithGroups("public").WithTopLevelKey("ignored").WithEnvelope(Envelope{
+		CountKey: "count",
+		Meta:     map[string]any{"generated_at": generated, "owner": User{ID: 9, Email: "o@x"}},
+	})
+	data, err := NewEncoder().WithGroups("public").Marshal(users)
+	if err != nil {
+		t.Fatal(err)
+	}
+	owner, _ := NewEncoder().WithGroups("public").Marshal(User{ID: 9})
+
+	got, err := enc.Marshal(users)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"data":` + string(data) + `,"meta":{"count":2,"generated_at":"2024-05-01T00:00:00Z","owner":` + string(owner) + `}}`
+	if string(got) != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+
+	var seq bytes.Buffer
+	i := 0
+	next := func() (any, bool) { i++; return users[i-1], i <= 1 }
+	if err := enc.EncodeSeq(&seq, next); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(seq.String(), `"meta":{"count":1,`) {
+		t.Errorf("EncodeSeq should count written elements: %s", seq.String())
+	}
+
+	got, err = NewEncoder().WithEnvelope(Envelope{DataKey: "item", CountKey: "count"}).Marshal(struct{}{})
+	if err != nil || string(got) != `{"item":{}}` {
+		t.Errorf("non-collection without meta = %s, %v", got, err)
+	}
+}
+
+func TestMultiMarshal(t *testing.T) {
+	u := User{ID: 1, Name: "a", Email: "a@x", Password: "p"}
+	pub, _ := NewEncoder().WithGroups("public").Marshal(u)
+	adm, _ := NewEncoder().WithGroups("admin").Marshal(u)
+
+	got, err := NewMultiMarshal().
+		Add("user", u, "public").
+		Add("audit", &u, "admin").
+		Add("user", u, "public").
+		Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"user":` + string(pub) + `,"audit":` + string(adm) + `}`
+	if string(got) != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+
+	got, err = NewEncoder().WithTopLevelKey("dashboard").Multi().Add("n", 1).Marshal()
+	if err != nil || string(got) != `{"dashboard":{"n":1}}` {
+		t.Errorf("wrapped = %s, %v", got, err)
+	}
+}
+
+func TestSortFields(t *testing.T) {
+	type Doc struct {
+		Zeta  int               `json:"zeta" groups:"public"`
+		Alpha string            `json:"alpha" groups:"public"`
+		ID    int               `json:"id" groups:"public" order:"1"`
+		Extra map[string]string `json:"extra" groups:"public"`
+	}
+	v := Doc{Zeta: 1, Alpha: "a", ID: 7, Extra: map[string]string{"b": "2", "a": "1"}}
+	enc := NewEncoder().WithGroups("public").WithSortKeys(true).WithSortFields(true)
+	got, err := enc.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"alpha":"a","extra":{"a":"1","b":"2"},"id":7,"zeta":1}`; string(got) != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	got, _ = enc.WithPinnedFields("zeta").Marshal(v)
+	if want := `{"zeta":1,"alpha":"a","extra":{"a":"1","b":"2"},"id":7}`; string(got) != want {
+		t.Errorf("pinned: got %s, want %s", got, want)
+	}
+	got, _ = enc.WithGroups().Marshal(v)
+	if !strings.HasPrefix(string(got), `{"alpha":"a","extra"`) {
+		t.Errorf("no groups: got %s", got)
+	}
+	got, _ = NewEncoder().WithGroups("public").Marshal(v)
+	if !strings.HasPrefix(string(got), `{"id":7,"zeta":1`) {
+		t.Errorf("SortFields must not leak into other encoders' plans: %s", got)
+	}
+}
+
+func TestMaxBytes(t *testing.T) {
+	users := make([]User, 100)
+	for i := range users {
+		users[i] = User{ID: i, Name: strings.Repeat("x", 50)}
+	}
+	full, err := NewEncoder().WithGroups("public").Marshal(users)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc := NewEncoder().WithGroups("public").WithMaxBytes(1024)
+	if _, err := enc.Marshal(users); !errors.Is(err, ErrMaxBytes) {
+		t.Errorf("err = %v, want ErrMaxBytes", err)
+	}
+	if _, err := enc.Marshal(strings.Repeat("y", 2000)); !errors.Is(err, ErrMaxBytes) {
+		t.Errorf("scalar: err = %v, want ErrMaxBytes", err)
+	}
+	if got, err := enc.WithMaxBytes(len(full)).Marshal(users); err != nil || len(got) != len(full) {
+		t.Errorf("exact limit: len %d, err %v", len(got), err)
+	}
+	if err := enc.EncodeLines(io.Discard, users); err != nil {
+		t.Errorf("EncodeLines applies the limit per line: %v", err)
+	}
+}
+
+// 自行组织顶层输出的入口同样执行 StrictGroups、MaxBytes 与采样
+func TestBulkTopLevelOptions(t *testing.T) {
+	users := []User{{ID: 1, Name: strings.Repeat("x", 50)}, {ID: 2, Name: strings.Repeat("y", 50)}}
+	entries := map[string]struct {
+		run     func(enc Encoder) ([]byte, error)
+		samples int
+	}{
+		"MarshalColumnar": {func(enc Encoder) ([]byte, error) { return enc.MarshalColumnar(users) }, 1},
+		"MultiMarshal": {func(enc Encoder) ([]byte, error) {
+			return enc.Multi().Add("users", users, enc.opts.Groups...).Add("count", len(users)).Marshal()
+		}, 1},
+		"EncodeLines": {func(enc Encoder) ([]byte, error) {
+			var out bytes.Buffer
+			err := enc.EncodeLines(&out, users)
+			return out.Bytes(), err
+		}, 2},
+		"EncodeSeq": {func(enc Encoder) ([]byte, error) {
+			var out bytes.Buffer
+			i := 0
+			err := enc.EncodeSeq(&out, func() (any, bool) {
+				i++
+				return users[min(i, len(users))-1], i <= len(users)
+			})
+			return out.Bytes(), err
+		}, 2},
+	}
+	for name, c := range entries {
+		if _, err := c.run(NewEncoder().WithGroups("no-such-group").WithStrictGroups(true)); !errors.Is(err, ErrUnknownGroup) {
+			t.Errorf("%s: strict groups: err = %v, want ErrUnknownGroup", name, err)
+		}
+		if _, err := c.run(NewEncoder().WithGroups("public").WithMaxBytes(60)); !errors.Is(err, ErrMaxBytes) {
+			t.Errorf("%s: max bytes: err = %v, want ErrMaxBytes", name, err)
+		}
+		var bodies [][]byte
+		out, err := c.run(NewEncoder().WithGroups("public").WithSampler(1, func(m SampleMeta, b []byte) { bodies = append(bodies, b) }))
+		if err != nil || len(bodies) != c.samples || !bytes.Contains(out, bodies[0]) {
+			t.Errorf("%s: sampled %q from %s, %v", name, bodies, out, err)
+		}
+	}
+}
+
+// Comment 用于深度限制测试的递归评论树。
+type Comment struct {
+	Text    string     `json:"text" groups:"public"`
+	Replies []*Comment `json:"replies,omitempty" groups:"public"`
+}
+
+func TestScopedMaxDepth(t *testing.T) {
+	tree := &Comment{Text: "a", Replies: []*Comment{{Text: "b", Replies: []*Comment{{Text: "c"}}}}}
+	type Page struct {
+		Title    string   `json:"title" groups:"public"`
+		Thread   *Comment `json:"thread" groups:"public"`
+		Shallow  *Comment `json:"shallow" groups:"public" gjdepth:"2"`
+		Untagged *Comment `json:"untagged" groups:"public"`
+	}
+	page := Page{Title: "t", Thread: tree, Shallow: tree, Untagged: tree}
+	enc := NewEncoder().WithGroups("public").WithDepthPolicy(DepthTruncateNull)
+
+	got, err := enc.WithMaxDepthFor(reflect.TypeFor[*Comment](), 2).Marshal(Page{Title: "t", Thread: tree})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"title":"t","thread":{"text":"a","replies":[{"text":"b","replies":[null]}]},"shallow":null,"untagged":null}`
+	if string(got) != want {
+		t.Errorf("per type:\ngot  %s\nwant %s", got, want)
+	}
+
+	got, err = enc.Marshal(page)
+	if err != nil {
+		t.Fatal(err)
+	}
+	full := `{"text":"a","replies":[{"text":"b","replies":[{"text":"c"}]}]}`
+	want = `{"title":"t","thread":` + full + `,"shallow":{"text":"a","replies":[null]},"untagged":` + full + `}`
+	if string(got) != want {
+		t.Errorf("per field:\ngot  %s\nwant %s", got, want)
+	}
+
+	if _, err := NewEncoder().WithGroups("public").WithMaxDepthFor(reflect.TypeFor[Comment](), 1).Marshal(tree); !errors.Is(err, ErrMaxDepth) {
+		t.Errorf("err = %v, want ErrMaxDepth", err)
+	}
+}
+
+func TestToMap(t *testing.T) {
+	u := User{ID: 1, Name: "a", Email: "a@x", Addr: Address{City: "SZ"}}
+	m, err := ToMap(&u, "public")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m["email"]; ok || m["id"] != json.Number("1") || m["address"].(map[string]any)["city"] != "SZ" {
+		t.Errorf("ToMap = %v", m)
+	}
+	if _, err := ToMap([]User{u}, "public"); !errors.Is(err, ErrInvalidType) {
+		t.Errorf("err = %v, want ErrInvalidType for slice input", err)
+	}
+
+	list, err := ToSlice([]any{u, 2, nil}, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 3 || list[0].(map[string]any)["email"] != "a@x" || list[1] != json.Number("2") || list[2] != nil {
+		t.Errorf("ToSlice = %v", list)
+	}
+	if list, err := ToSlice([]User(nil)); list != nil || err != nil {
+		t.Errorf("nil slice = %v, %v", list, err)
+	}
+}
+
+func TestToMapParity(t *testing.T) {
+	type Holder struct {
+		Ratio  float64   `json:"ratio,string" groups:"public"`
+		Big    int64     `json:"big" groups:"public"`
+		Nick   *string   `json:"nick" groups:"public" nullas:"\"\""`
+		Cache  *sync.Map `json:"cache" groups:"public"`
+		Shape  shape     `json:"shape" groups:"public"`
+		Thread *Comment  `json:"thread" groups:"public" gjdepth:"2"`
+		Fn     func()    `json:"fn" groups:"public"`
+		User   User      `json:"user" groups:"public"`
+	}
+	cache := &sync.Map{}
+	cache.Store("k", Address{City: "SZ"})
+	v := Holder{
+		Ratio: 1.5, Big: 1 << 60, Cache: cache, Shape: circle{R: 1, Secret: "s"},
+		Thread: &Comment{Text: "a", Replies: []*Comment{{Text: "b", Replies: []*Comment{{Text: "c"}}}}},
+		User:   User{ID: 1, Name: "a", Email: "a@x", Tags: []string{"t"}},
+	}
+	encs := map[string]Encoder{
+		"skip":          NewEncoder().WithErrorPolicy(ErrorSkipField),
+		"null":          NewEncoder().WithErrorPolicy(ErrorEmitNull).WithInt64AsString(true),
+		"discriminator": NewEncoder().WithErrorPolicy(ErrorSkipField).WithTypeDiscriminator("type"),
+		"deny":          NewEncoder().WithErrorPolicy(ErrorSkipField).WithDenyFields("**.cache.k", "**.email"),
+		"transformer": NewEncoder().WithErrorPolicy(ErrorSkipField).WithFieldTransformer(func(path Path, f FieldInfo, v reflect.Value) (any, bool) {
+			if f.Name == "Name" {
+				return strings.ToUpper(v.String()), true
+			}
+			return v.Interface(), true
+		}),
+	}
+	for name, enc := range encs {
+		enc = enc.WithGroups("public").WithDepthPolicy(DepthTruncateNull)
+		b, merr := enc.Marshal(v)
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.UseNumber()
+		var want map[string]any
+		if err := dec.Decode(&want); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		got, err := enc.ToMap(&v)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s:\n got %v\nwant %v", name, got, want)
+		}
+		var me *MultiError
+		if !errors.As(err, &me) || merr.Error() != err.Error() {
+			t.Errorf("%s: err = %v, want %v", name, err, merr)
+		}
+		list, _ := enc.ToSlice([]Holder{v})
+		maps, _ := enc.SliceToMaps([]*Holder{&v})
+		if len(list) != 1 || !reflect.DeepEqual(list[0], want) || len(maps) != 1 || !reflect.DeepEqual(maps[0], want) {
+			t.Errorf("%s: ToSlice = %v, SliceToMaps = %v", name, list, maps)
+		}
+	}
+	_, err := NewEncoder().ToMap(struct {
+		Items []any `json:"items"`
+	}{[]any{1, func() {}}})
+	var ee *EncodeError
+	if !errors.As(err, &ee) || ee.Path.String() != "items[1]" {
+		t.Errorf("err = %v, want path items[1]", err)
+	}
+}
+
+func TestFilterJSON(t *testing.T) {
+	u := User{ID: 1, Name: "a", Email: "a@x", Password: "p", Addr: Address{City: "SZ"}}
+	full, err := json.Marshal([]User{u, u})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := FilterJSON(full, &User{}, "public")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := Marshal([]User{u, u}, "public")
+	var g, w any
+	if err := json.Unmarshal(got, &g); err != nil {
+		t.Fatalf("invalid output %s: %v", got, err)
+	}
+	json.Unmarshal(want, &w)
+	if !reflect.DeepEqual(g, w) {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+	if _, err := FilterJSON(full, 42); !errors.Is(err, ErrInvalidType) {
+		t.Errorf("err = %v, want ErrInvalidType", err)
+	}
+
+	// 嵌套容器中的结构体同样按分组过滤
+	type Team struct {
+		Grid [][]User          `json:"grid" groups:"public"`
+		ByK  map[string][]User `json:"by_k" groups:"public"`
+	}
+	team := Team{Grid: [][]User{{u}}, ByK: map[string][]User{"k": {u, u}}}
+	full, _ = json.Marshal(team)
+	got, err = FilterJSON(full, Team{}, "public")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ = Marshal(team, "public")
+	if string(got) != string(want) {
+		t.Errorf("nested:\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestSanitizeRequest(t *testing.T) {
+	type Profile struct {
+		Name  string   `json:"name" groups:"self,admin"`
+		Email string   `json:"email" groups:"self,admin;mask=email"`
+		Role  string   `json:"role" groups:"admin"`
+		Addr  *Address `json:"addr" groups:"self"`
+	}
+	in := []byte(`{"name":"n","EMAIL":"x@y","email":"a@b.com","role":"root","addr":{"city":"SZ","zip":"1"}}`)
+	got, err := SanitizeRequest(in, &Profile{}, "self", "public")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"name":"n","email":"a@b.com","addr":{"city":"SZ"}}`; string(got) != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	var p Profile
+	if err := json.Unmarshal(got, &p); err != nil || p.Role != "" || p.Email != "a@b.com" {
+		t.Errorf("unmarshal = %+v, %v", p, err)
+	}
+
+	// 嵌套容器中的无权写入键同样删除
+	type Batch struct {
+		Grid [][]Profile          `json:"grid" groups:"self"`
+		ByK  map[string][]Profile `json:"by_k" groups:"self"`
+	}
+	in = []byte(`{"grid":[[{"name":"a","role":"root"}]],"by_k":{"k":[{"name":"b","role":"root"}]}}`)
+	got, err = SanitizeRequest(in, &Batch{}, "self")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"grid":[[{"name":"a"}]],"by_k":{"k":[{"name":"b"}]}}`; string(got) != want {
+		t.Errorf("nested: got %s, want %s", got, want)
+	}
+}
+
+func TestMarshalMerged(t *testing.T) {
+	type Item struct {
+		ID     int    `json:"id" groups:"base"`
+		Beta   string `json:"beta" groups:"beta+flag"`
+		Owner  string `json:"owner" groups:"admin;mask=email;unmask=audit"`
+		Secret string `json:"secret" groups:"admin+audit"`
+	}
+	v := Item{ID: 1, Beta: "b", Owner: "owner@example.com", Secret: "s"}
+	enc := NewEncoder()
+
+	got, err := enc.MarshalMerged(v, []string{"base"}, []string{"beta", "flag"}, []string{"audit"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"id":1,"beta":"b"}`; string(got) != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+
+	got, _ = enc.MarshalMerged(v, []string{"admin"}, []string{"audit"})
+	if want := `{"owner":"owner@example.com"}`; string(got) != want {
+		t.Errorf("unmask in any view: got %s, want %s", got, want)
+	}
+	got, _ = enc.MarshalGroups(v, "admin")
+	if strings.Contains(string(got), "owner@example.com") {
+		t.Errorf("merged views must not leak into plain encodes: %s", got)
+	}
+}
+
+func TestDiffViews(t *testing.T) {
+	type Team struct {
+		Name    string `json:"name" groups:"public,admin"`
+		Members []User `json:"members" groups:"public,admin"`
+		Budget  int    `json:"budget" groups:"admin"`
+	}
+	v := Team{Name: "t", Members: []User{{ID: 1, Email: "a@x"}}, Budget: 10}
+	added, removed, err := DiffViews(v, []string{"public"}, []string{"admin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"budget", "members[0].email", "members[0].updated_at"}; !reflect.DeepEqual(added, want) {
+		t.Errorf("added = %v, want %v", added, want)
+	}
+	if len(removed) != 0 {
+		t.Errorf("removed = %v, want none", removed)
+	}
+	_, removed, _ = DiffViews(v, []string{"admin"}, []string{"public"})
+	if len(removed) != 3 {
+		t.Errorf("reverse diff removed = %v", removed)
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	type Doc struct {
+		Title string            `json:"title" groups:"public"`
+		Tags  []string          `json:"tags,omitempty" groups:"public"`
+		Attrs map[string]string `json:"attrs" groups:"public"`
+		Notes string            `json:"notes" groups:"admin"`
+	}
+	old := Doc{Title: "a", Tags: []string{"x"}, Attrs: map[string]string{"k": "1", "gone": "2"}, Notes: "n1"}
+	cur := Doc{Title: "b", Attrs: map[string]string{"k": "1", "new": "3"}, Notes: "n2"}
+
+	got, err := MergePatch(old, cur, "public")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"attrs":{"gone":null,"new":"3"},"tags":null,"title":"b"}`; string(got) != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	if got, _ := MergePatch(old, old, "public"); string(got) != "{}" {
+		t.Errorf("identical values: got %s", got)
+	}
+}
+
+func TestJSONSchema(t *testing.T) {
+	type Node struct {
+		Name     string    `json:"name" groups:"public" example:"root"`
+		Secret   string    `json:"secret" groups:"admin"`
+		Email    string    `json:"email,omitempty" groups:"public;mask=email"`
+		Count    int64     `json:"count,string" groups:"public"`
+		Children []*Node   `json:"children" groups:"public"`
+		When     time.Time `json:"when" groups:"public"`
+	}
+	b, err := JSONSchema(&Node{}, "public")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Schema string `json:"$schema"`
+		Ref    string `json:"$ref"`
+		Defs   map[string]struct {
+			Properties           map[string]map[string]any `json:"properties"`
+			Required             []string                  `json:"required"`
+			AdditionalProperties bool                      `json:"additionalProperties"`
+		} `json:"$defs"`
+	}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Schema != JSONSchemaDraft || doc.Ref != "#/$defs/Node" || len(doc.Defs) != 1 {
+		t.Fatalf("unexpected document: %s", b)
+	}
+	node := doc.Defs["Node"]
+	if _, ok := node.Properties["secret"]; ok {
+		t.Errorf("admin-only field in public schema: %s", b)
+	}
+	if want := []string{"name", "count", "children", "when"}; !reflect.DeepEqual(node.Required, want) {
+		t.Errorf("required = %v, want %v", node.Required, want)
+	}
+	if node.Properties["count"]["type"] != "string" || node.Properties["when"]["format"] != "date-time" {
+		t.Errorf("unexpected property schemas: %v", node.Properties)
+	}
+	items := node.Properties["children"]["items"].(map[string]any)["anyOf"].([]any)[0].(map[string]any)
+	if items["$ref"] != "#/$defs/Node" {
+		t.Errorf("children items = %v", node.Properties["children"])
+	}
+	if !strings.Contains(string(b), `"examples":["root"]`) {
+		t.Errorf("example not exported: %s", b)
+	}
+}
+
+func TestOpenAPIComponents(t *testing.T) {
+	b, err := OpenAPIComponents([]any{User{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]map[string]any `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		t.Fatal(err)
+	}
+	schemas := doc.Components.Schemas
+	for _, name := range []string{"UserPublic", "UserAdmin", "UserInternal", "AddressPublic", "AddressAdmin"} {
+		if _, ok := schemas[name]; !ok {
+			t.Fatalf("missing component %s: %s", name, b)
+		}
+	}
+	if _, ok := schemas["UserPublic"].Properties["email"]; ok {
+		t.Errorf("UserPublic exposes email: %s", b)
+	}
+	if _, ok := schemas["UserAdmin"].Properties["email"]; !ok {
+		t.Errorf("UserAdmin missing email: %s", b)
+	}
+	if ref := schemas["UserPublic"].Properties["address"]["$ref"]; ref != "#/components/schemas/AddressPublic" {
+		t.Errorf("address ref = %v", ref)
+	}
+
+	b, err = OpenAPIComponents([]any{&User{}}, View{Name: "read-only", Groups: []string{"public"}})
+	if err != nil || !strings.Contains(string(b), `"UserReadOnly"`) {
+		t.Errorf("custom view: %s, %v", b, err)
+	}
+}
+
+func TestTypeScript(t *testing.T) {
+	b, err := TypeScript([]any{User{}}, View{Name: "public", Groups: []string{"public"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(b)
+	for _, want := range []string{"export interface UserPublic {\n  id: number;\n", "address: AddressPublic;", "export interface AddressPublic {"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "email") {
+		t.Errorf("public view exposes email:\n%s", out)
+	}
+	if strings.Index(out, "UserPublic {") > strings.Index(out, "AddressPublic {") {
+		t.Errorf("nested interface emitted before its parent:\n%s", out)
+	}
+
+	if _, err := TypeScript([]any{1}); !errors.Is(err, ErrInvalidType) {
+		t.Errorf("non-struct: %v", err)
+	}
+}
+
+func TestGroupRegistry(t *testing.T) {
+	type Ticket struct {
+		ID    int    `json:"id" groups:"registry-public"`
+		Notes string `json:"notes" groups:"registry-staff+registry-audit"`
+	}
+	if err := ValidateGroups(Ticket{}); !errors.Is(err, ErrUnknownGroup) {
+		t.Fatalf("unregistered tags: %v", err)
+	}
+	RegisterGroups("registry-public", "registry-staff", "registry-audit")
+	if err := ValidateGroups(&Ticket{}); err != nil {
+		t.Fatalf("registered tags: %v", err)
+	}
+
+	enc := NewEncoder().WithStrictGroups(true)
+	if _, err := enc.WithGroups("registry-publc").Marshal(Ticket{}); !errors.Is(err, ErrUnknownGroup) {
+		t.Errorf("typo accepted: %v", err)
+	}
+	if b, err := enc.WithGroups("registry-public").Marshal(Ticket{ID: 1}); err != nil || string(b) != `{"id":1}` {
+		t.Errorf("strict marshal = %s, %v", b, err)
+	}
+
+	src, err := GroupConstants("model", Ticket{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"package model", `GroupRegistryPublic = "registry-public"`, "groupjson.RegisterGroups(GroupRegistryAudit, GroupRegistryPublic, GroupRegistryStaff)"} {
+		if !strings.Contains(string(src), want) {
+			t.Errorf("missing %q in:\n%s", want, src)
+		}
+	}
+}
+
+func TestAudit(t *testing.T) {
+	type Secret struct {
+		Token string `json:"token" groups:"internal,public"`
+	}
+	type Account struct {
+		ID     int     `json:"id" groups:"public"`
+		Notes  string  `json:"notes"`
+		Hash   string  `json:"hash" groups:"-"`
+		Secret *Secret `json:"secret" groups:"admin"`
+	}
+	findings := Audit(Account{})
+	if len(findings) != 2 {
+		t.Fatalf("findings = %v", findings)
+	}
+	if f := findings[0]; f.Kind != FindingUntagged || f.Field != "Notes" || f.Key != "notes" {
+		t.Errorf("untagged finding = %+v", f)
+	}
+	if f := findings[1]; f.Kind != FindingSuspicious || f.Field != "Token" || !strings.HasSuffix(f.Type, "Secret") {
+		t.Errorf("suspicious finding = %+v", f)
+	}
+}
+
+func TestPlan(t *testing.T) {
+	type Item struct {
+		SKU  string `json:"sku" groups:"public"`
+		Cost int    `json:"cost" groups:"admin"`
+	}
+	type Node struct {
+		Name  string           `json:"name" groups:"public"`
+		Items []Item           `json:"items" groups:"public"`
+		Attrs map[string]Item  `json:"attrs,omitempty" groups:"public"`
+		Next  *Node            `json:"next" groups:"public"`
+		Notes map[string]int   `json:"notes" groups:"admin"`
+		When  time.Time        `json:"when" groups:"public"`
+		Extra map[string][]int `json:"extra" groups:"internal"`
+	}
+	paths, err := Plan(Node{}, "public")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"name", "items", "items[*].sku", "attrs", "attrs.*", "attrs.*.sku", "next", "when"}
+	if !slices.Equal(paths, want) {
+		t.Errorf("paths = %q, want %q", paths, want)
+	}
+
+	paths, _ = NewEncoder().WithGroups("public").WithDenyFields("items[*].sku").WithAllowFields("items[*].cost").Plan(&Node{})
+	if !slices.Contains(paths, "items[*].cost") || slices.Contains(paths, "items[*].sku") {
+		t.Errorf("path rules not applied: %q", paths)
+	}
+	if _, err := Plan(42); !errors.Is(err, ErrInvalidType) {
+		t.Errorf("non-struct: %v", err)
+	}
+}
+
+func TestTrace(t *testing.T) {
+	type Node struct {
+		Name  string `json:"name" groups:"public"`
+		Email string `json:"email" groups:"admin"`
+		Bio   string `json:"bio,omitempty" groups:"public"`
+		Next  *Node  `json:"next" groups:"public"`
+	}
+	n := &Node{Name: "a"}
+	n.Next = n
+
+	events := map[string]TraceEvent{}
+	_, err := NewEncoder().WithGroups("public").WithCycleHandling(CycleOmit).
+		WithTrace(func(ev TraceEvent) {
+			key := ev.Path.String() + "/" + string(ev.Reason)
+			if _, ok := events[key]; !ok {
+				events[key] = TraceEvent{Path: ev.Path.Clone(), Type: ev.Type, Field: ev.Field, Included: ev.Included, Reason: ev.Reason}
+			}
+		}).Marshal(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		key      string
+		included bool
+	}{
+		{"name/group-match", true},
+		{"email/group-mismatch", false},
+		{"bio/omitempty", false},
+		{"next/group-match", true},
+		{"next/cycle", false},
+	}
+	for _, c := range cases {
+		ev, ok := events[c.key]
+		if !ok {
+			t.Errorf("missing event %s in %v", c.key, events)
+			continue
+		}
+		if ev.Included != c.included {
+			t.Errorf("%s included = %v", c.key, ev.Included)
+		}
+	}
+	if ev := events["email/group-mismatch"]; ev.Field != "Email" || !strings.HasSuffix(ev.Type, "Node") {
+		t.Errorf("email event = %+v", ev)
+	}
+}
+
+func TestEncodeErrorPath(t *testing.T) {
+	type Comment struct {
+		Body string `json:"body" groups:"public"`
+		Time any    `json:"time" groups:"public"`
+	}
+	type Article struct {
+		Comments []Comment `json:"comments" groups:"public"`
+	}
+	a := Article{Comments: []Comment{{Body: "ok"}, {}, {Time: make(chan int)}}}
+	_, err := Marshal(a, "public")
+	var ee *EncodeError
+	if !errors.As(err, &ee) {
+		t.Fatalf("err = %v, want *EncodeError", err)
+	}
+	if got := ee.Path.String(); got != "comments[2].time" {
+		t.Errorf("path = %q", got)
+	}
+	if ee.FieldType != reflect.TypeFor[any]() {
+		t.Errorf("field type = %v", ee.FieldType)
+	}
+	if !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("err does not wrap ErrUnsupportedType: %v", err)
+	}
+	if _, err := Marshal(func() {}); err == nil || errors.As(err, &ee) {
+		t.Errorf("root errors stay unwrapped, got %v", err)
+	}
+
+	// 动态容器、GroupedMap、按列输出与信封 meta 中的错误同样带有路径
+	type Dynamic struct {
+		Cache  *sync.Map              `json:"cache,omitempty"`
+		Items  iter.Seq[any]          `json:"items,omitempty"`
+		ByName iter.Seq2[string, any] `json:"by_name,omitempty"`
+		Flags  GroupedMap[any]        `json:"flags,omitempty"`
+	}
+	cache := &sync.Map{}
+	cache.Store("bad", func() {})
+	queue := make(chan any, 2)
+	queue <- 1
+	queue <- func() {}
+	close(queue)
+	flags := GroupedMap[any]{}
+	flags.Set("bad", func() {})
+	type Row struct {
+		V any `json:"v"`
+	}
+	cases := []struct {
+		name string
+		enc  Encoder
+		v    any
+		want string
+	}{
+		{"sync.Map", NewEncoder(), Dynamic{Cache: cache}, "cache.bad"},
+		{"iter.Seq", NewEncoder(), Dynamic{Items: slices.Values([]any{1, func() {}})}, "items[1]"},
+		{"iter.Seq2", NewEncoder(), Dynamic{ByName: func(yield func(string, any) bool) { yield("bad", func() {}) }}, "by_name.bad"},
+		{"chan", NewEncoder().WithChannelEncoding(true), struct {
+			Queue chan any `json:"queue"`
+		}{queue}, "queue[1]"},
+		{"GroupedMap", NewEncoder(), Dynamic{Flags: flags}, "flags.bad"},
+		{"envelope meta", NewEncoder().WithEnvelope(Envelope{Meta: map[string]any{"bad": func() {}}}), 1, "meta.bad"},
+	}
+	for _, c := range cases {
+		_, err := c.enc.Marshal(c.v)
+		if !errors.As(err, &ee) || ee.Path.String() != c.want {
+			t.Errorf("%s: err = %v, want path %s", c.name, err, c.want)
+		}
+	}
+	_, err = NewEncoder().MarshalColumnar([]Row{{V: 1}, {V: func() {}}})
+	if !errors.As(err, &ee) || ee.Path.String() != "[1].v" {
+		t.Errorf("columnar: err = %v, want path [1].v", err)
+	}
+	_, err = NewEncoder().WithKeyTables(true).Marshal(struct {
+		Rows []Row `json:"rows"`
+	}{[]Row{{V: 1}, {V: func() {}}}})
+	if !errors.As(err, &ee) || ee.Path.String() != "rows[1].v" {
+		t.Errorf("key table: err = %v, want path rows[1].v", err)
+	}
+}
+
+type failingMarshaler struct{}
+
+func (failingMarshaler) MarshalJSON() ([]byte, error) { return nil, errors.New("boom") }
+
+func TestErrorPolicy(t *testing.T) {
+	type Row struct {
+		ID    int              `json:"id" groups:"public"`
+		Fn    func()           `json:"fn" groups:"public"`
+		Ratio float64          `json:"ratio" groups:"public"`
+		Ext   failingMarshaler `json:"ext" groups:"public"`
+	}
+	rows := []Row{{ID: 1, Ratio: math.NaN()}, {ID: 2, Ratio: 0.5}}
+	enc := NewEncoder().WithGroups("public")
+	if _, err := enc.Marshal(rows); err == nil {
+		t.Fatal("ErrorFail should fail")
+	}
+	var me *MultiError
+	b, err := enc.WithErrorPolicy(ErrorSkipField).Marshal(rows)
+	if string(b) != `[{"id":1},{"id":2,"ratio":0.5}]` || !errors.As(err, &me) {
+		t.Errorf("skip = %s, %v", b, err)
+	}
+	b, err = enc.WithErrorPolicy(ErrorEmitNull).Marshal(rows)
+	if string(b) != `[{"id":1,"fn":null,"ratio":null,"ext":null},{"id":2,"fn":null,"ratio":0.5,"ext":null}]` || !errors.As(err, &me) {
+		t.Errorf("null = %s, %v", b, err)
+	}
+	b, err = enc.WithErrorPolicy(ErrorSkipField).Marshal(map[string]any{"a": 1, "f": func() {}})
+	if string(b) != `{"a":1}` || !errors.As(err, &me) {
+		t.Errorf("map skip = %s, %v", b, err)
+	}
+	if b, err := enc.WithErrorPolicy(ErrorSkipField).Marshal(User{ID: 1}); err != nil || b == nil {
+		t.Errorf("no problems should mean no error: %s, %v", b, err)
+	}
+}
+
+func TestMultiError(t *testing.T) {
+	type Row struct {
+		ID    int              `json:"id" groups:"public"`
+		Ratio float64          `json:"ratio" groups:"public"`
+		Ext   failingMarshaler `json:"ext" groups:"public"`
+	}
+	rows := []Row{{ID: 1, Ratio: math.Inf(1)}, {ID: 2}}
+	var out bytes.Buffer
+	err := NewEncoder().WithGroups("public").WithErrorPolicy(ErrorEmitNull).Encode(&out, rows)
+	var me *MultiError
+	if !errors.As(err, &me) {
+		t.Fatalf("err = %v, want *MultiError", err)
+	}
+	if out.String() != `[{"id":1,"ratio":null,"ext":null},{"id":2,"ratio":0,"ext":null}]` {
+		t.Errorf("output = %s", out.String())
+	}
+	var paths []string
+	for _, e := range me.Errors {
+		paths = append(paths, e.Path.String())
+	}
+	if want := []string{"[0].ratio", "[0].ext", "[1].ext"}; !slices.Equal(paths, want) {
+		t.Errorf("paths = %q, want %q", paths, want)
+	}
+	var uv *json.UnsupportedValueError
+	if !errors.As(err, &uv) {
+		t.Errorf("MultiError should unwrap to the NaN/Inf cause: %v", err)
+	}
+}
+
+func TestMultiErrorEntryPoints(t *testing.T) {
+	type Row struct {
+		ID    int     `json:"id" groups:"public"`
+		Ratio float64 `json:"ratio" groups:"public"`
+	}
+	rows := []Row{{ID: 1, Ratio: math.NaN()}, {ID: 2, Ratio: 0.5}}
+	enc := NewEncoder().WithGroups("public").WithErrorPolicy(ErrorSkipField)
+	check := func(name, out string, err error, wantOut, wantPath string) {
+		t.Helper()
+		var me *MultiError
+		if !errors.As(err, &me) {
+			t.Fatalf("%s: err = %v, want *MultiError", name, err)
+		}
+		if out != wantOut {
+			t.Errorf("%s: output = %s", name, out)
+		}
+		if len(me.Errors) != 1 || me.Errors[0].Path.String() != wantPath {
+			t.Errorf("%s: errors = %v, want one at %s", name, me.Errors, wantPath)
+		}
+	}
+
+	var out bytes.Buffer
+	err := enc.EncodeLines(&out, rows)
+	check("EncodeLines", out.String(), err, "{\"id\":1}\n{\"id\":2,\"ratio\":0.5}\n", "[0].ratio")
+
+	out.Reset()
+	i := 0
+	err = enc.EncodeSeq(&out, func() (any, bool) {
+		if i == len(rows) {
+			return nil, false
+		}
+		i++
+		return rows[i-1], true
+	})
+	check("EncodeSeq", out.String(), err, `[{"id":1},{"id":2,"ratio":0.5}]`, "[0].ratio")
+
+	b, err := enc.Multi().Add("rows", rows, "public").Add("n", 2).Marshal()
+	check("Multi", string(b), err, `{"rows":[{"id":1},{"id":2,"ratio":0.5}],"n":2}`, "rows[0].ratio")
+
+	b, err = enc.MarshalColumnar(rows)
+	check("MarshalColumnar", string(b), err, `{"id":[1,2],"ratio":[null,0.5]}`, "[0].ratio")
+
+	if _, err := enc.MarshalColumnar([]Row{{ID: 1}}); err != nil {
+		t.Errorf("no problems should mean no error: %v", err)
+	}
+}
+
+func TestFloatSpecials(t *testing.T) {
+	type Metrics struct {
+		Mean  float64   `json:"mean" groups:"public"`
+		Max   float32   `json:"max" groups:"public"`
+		Min   float64   `json:"min,string" groups:"public"`
+		Ratio []float64 `json:"ratio" groups:"public"`
+	}
+	m := Metrics{Mean: math.NaN(), Max: float32(math.Inf(1)), Min: math.Inf(-1), Ratio: []float64{0.5, math.NaN()}}
+	enc := NewEncoder().WithGroups("public")
+	var uv *json.UnsupportedValueError
+	if _, err := enc.Marshal(m); !errors.As(err, &uv) {
+		t.Errorf("default err = %v", err)
+	}
+	b, err := enc.WithFloatSpecials(FloatSpecialAsNull).Marshal(m)
+	if err != nil || string(b) != `{"mean":null,"max":null,"min":null,"ratio":[0.5,null]}` {
+		t.Errorf("as null = %s, %v", b, err)
+	}
+	b, err = enc.WithFloatSpecials(FloatSpecialAsString).Marshal(m)
+	if err != nil || string(b) != `{"mean":"NaN","max":"Infinity","min":"-Infinity","ratio":[0.5,"NaN"]}` {
+		t.Errorf("as string = %s, %v", b, err)
+	}
+}
+
+func TestFloatFormat(t *testing.T) {
+	type Quote struct {
+		Price  float64   `json:"price" gjprec:"2" groups:"public"`
+		Rate   *float32  `json:"rate" gjprec:"1" groups:"public"`
+		Points []float64 `json:"points" gjprec:"0" groups:"public"`
+		Ratio  float64   `json:"ratio" groups:"public"`
+	}
+	rate := float32(0.25)
+	q := Quote{Price: 19.9, Rate: &rate, Points: []float64{1.6, 2.4}, Ratio: 1.0 / 3}
+	b, err := Marshal(q, "public")
+	if err != nil || string(b) != `{"price":19.90,"rate":0.2,"points":[2,2],"ratio":0.3333333333333333}` {
+		t.Errorf("gjprec = %s, %v", b, err)
+	}
+	b, err = NewEncoder().WithGroups("public").WithFloatFormat('f', 3).Marshal(q)
+	if err != nil || string(b) != `{"price":19.90,"rate":0.2,"points":[2,2],"ratio":0.333}` {
+		t.Errorf("WithFloatFormat = %s, %v", b, err)
+	}
+	for _, format := range []byte{'b', 'x', 'X', 'q'} {
+		b, err = NewEncoder().WithGroups("public").WithFloatFormat(format, 2).Marshal(q)
+		if err != nil || string(b) != `{"price":19.90,"rate":0.2,"points":[2,2],"ratio":0.3333333333333333}` {
+			t.Errorf("WithFloatFormat(%q) = %s, %v", format, b, err)
+		}
+	}
+}
+
+// ptrLabel 仅指针接收者实现 TextMarshaler，是否生效取决于值是否可寻址。
+type ptrLabel string
+
+func (l *ptrLabel) MarshalText() ([]byte, error) { return []byte("label:" + string(*l)), nil }
+
+type compiledTree struct {
+	Name     string         `json:"name"`
+	Children []compiledTree `json:"children,omitempty"`
+	Label    ptrLabel       `json:"label"`
+	Any      any            `json:"any,omitempty"`
+}
+
+func TestCompiledEncoders(t *testing.T) {
+	// 预编译的编码函数须与 encoding/json 的逐值语义一致：递归类型、接口、指针接收者的 Marshaler
+	tree := &compiledTree{Name: "root", Label: "r", Children: []compiledTree{
+		{Name: "a", Label: "x", Any: []any{1, "s", nil, map[string]any{"k": true}}},
+		{Name: "b", Label: "y", Any: ptrLabel("v")},
+	}}
+	for _, v := range []any{tree, *tree, []any{tree, ptrLabel("z")}, map[string]compiledTree{"t": *tree}} {
+		want, _ := json.Marshal(v)
+		got, err := Marshal(v)
+		if err != nil || string(got) != string(want) {
+			t.Errorf("Marshal(%T) = %s, %v; want %s", v, got, err, want)
+		}
+	}
+}
+
+func TestGroupMasks(t *testing.T) {
+	// 位运算判断须与字符串比较逐一一致，包括 a+b 组合项、未出现在标签中的分组与合并视图
+	fieldGroups := [][]string{nil, {"public"}, {"admin", "public"}, {"admin+internal"}, {"public", "admin+internal"}, {"gm-x"}}
+	requests := [][]string{{"public"}, {"admin"}, {"admin", "internal"}, {"public", "admin"}, {"unknown"}, {"public", "unknown"}, {"internal"}}
+	for _, fg := range fieldGroups {
+		bits, union, ok := internGroups(fg)
+		if !ok {
+			t.Fatalf("internGroups(%v) failed", fg)
+		}
+		f := &fieldInfo{groups: fg, groupBits: bits, groupUnion: union, groupBitsOK: ok}
+		for _, mode := range []GroupMode{ModeOr, ModeAnd} {
+			for _, req := range requests {
+				for _, merged := range [][][]string{nil, {{"admin", "internal"}}} {
+					e := NewEncoder().WithGroups(req...).WithGroupMode(mode)
+					e.opts.MergedViews = merged
+					q := compileGroups(e.opts)
+					if got, want := e.fieldVisible(f, &q), e.includeField(fg); got != want {
+						t.Errorf("field %v, mode %v, groups %v, merged %v: mask = %v, strings = %v", fg, mode, req, merged, got, want)
+					}
+				}
+			}
+		}
+	}
+
+	// 编译请求之后才分配位的分组回退到字符串比较
+	q := compileGroups(NewEncoder().WithGroups("gm-late").opts)
+	bits, union, _ := internGroups([]string{"gm-late"})
+	if !NewEncoder().WithGroups("gm-late").fieldVisible(&fieldInfo{groups: []string{"gm-late"}, groupBits: bits, groupUnion: union, groupBitsOK: true}, &q) {
+		t.Error("group interned after compileGroups should still match")
+	}
+}
+
+func TestPlanCacheBound(t *testing.T) {
+	type Item struct {
+		ID   int    `json:"id" groups:"a"`
+		Name string `json:"name" groups:"b"`
+	}
+	// 分组顺序与重复不影响计划
+	if planGroupKey(NewEncoder().WithGroups("b", "a", "a").opts) != planGroupKey(NewEncoder().WithGroups("a", "b").opts) {
+		t.Error("equivalent group lists should share a plan key")
+	}
+
+	old := MaxCachedPlans
+	MaxCachedPlans = 8
+	defer func() { MaxCachedPlans = old }()
+	for i := 0; i < 50; i++ {
+		b, err := Marshal(Item{ID: 1, Name: "x"}, "a", "g"+strconv.Itoa(i))
+		if err != nil || string(b) != `{"id":1}` {
+			t.Fatalf("Marshal = %s, %v", b, err)
+		}
+	}
+	n := 0
+	planCache.m.Range(func(_, _ any) bool { n++; return true })
+	if n > MaxCachedPlans {
+		t.Errorf("plan cache holds %d entries, limit %d", n, MaxCachedPlans)
+	}
+}
+
+// escapeSamples 覆盖控制字符、HTML 字符、U+2028/U+2029 与多字节字符。
+var escapeSamples = []string{
+	"", "plain", `quote " and \\ backslash`, "\b\f\n\r\t\x00\x1f\x7f",
+	"<script>&amp;</script>", "line\u2028sep\u2029", "中文 émoji 😀",
+}
+
+func TestAppendString(t *testing.T) {
+	for _, s := range escapeSamples {
+		want, _ := json.Marshal(s)
+		var buf bytes.Buffer
+		appendString(&buf, s, true)
+		if buf.String() != string(want) {
+			t.Errorf("appendString(%q, html) = %s, want %s", s, buf.String(), want)
+		}
+
+		var std bytes.Buffer
+		enc := json.NewEncoder(&std)
+		enc.SetEscapeHTML(false)
+		_ = enc.Encode(s)
+		buf.Reset()
+		appendString(&buf, s, false)
+		if buf.String() != strings.TrimSuffix(std.String(), "\n") {
+			t.Errorf("appendString(%q) = %s, want %s", s, buf.String(), std.String())
+		}
+	}
+
+	// 非法 UTF-8 替换为 U+FFFD（标准库不同版本对替换字符是否写成 \ufffd 不一致，按解码结果比较）
+	var buf bytes.Buffer
+	appendString(&buf, "bad \xff\xfe utf8 \xe4\xb8", false)
+	var got string
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil || got != "bad \ufffd\ufffd utf8 \ufffd\ufffd" {
+		t.Errorf("invalid UTF-8 = %s (%q), %v", buf.String(), got, err)
+	}
+}
+
+func TestPrimitiveFastPaths(t *testing.T) {
+	type Level int8
+	type Telemetry struct {
+		Ints    []int             `json:"ints"`
+		Big     []int64           `json:"big"`
+		Floats  []float64         `json:"floats"`
+		Tags    []string          `json:"tags"`
+		Flags   [2]bool           `json:"flags"`
+		Levels  []Level           `json:"levels"`
+		Labels  map[string]string `json:"labels"`
+		Counts  map[string]int    `json:"counts"`
+		Nil     []string          `json:"nil"`
+		NilMap  map[string]int    `json:"nil_map"`
+		Escaped []string          `json:"escaped"`
+	}
+	v := Telemetry{
+		Ints: []int{1, -2, 3}, Big: []int64{1 << 60}, Floats: []float64{0.5, 1e21, 3},
+		Tags: []string{"a", "b"}, Flags: [2]bool{true, false}, Levels: []Level{-1, 2},
+		Labels: map[string]string{"env": "prod"}, Counts: map[string]int{"hits": 3},
+		Escaped: []string{"<a>\n"},
+	}
+	want, _ := json.Marshal(v)
+	got, err := NewEncoder().WithEscapeHTML(true).Marshal(v)
+	if err != nil || string(got) != string(want) {
+		t.Errorf("Marshal = %s, %v; want %s", got, err, want)
+	}
+
+	// 回退路径与快速路径共用同样的选项语义
+	got, _ = NewEncoder().WithInt64AsString(true).Marshal([]int64{1 << 60, 1})
+	if string(got) != `["1152921504606846976",1]` {
+		t.Errorf("Int64AsString = %s", got)
+	}
+	got, _ = NewEncoder().WithSortKeys(true).Marshal(map[string]int{"b": 2, "a": 1})
+	if string(got) != `{"a":1,"b":2}` {
+		t.Errorf("SortKeys = %s", got)
+	}
+	_, err = Marshal(map[string][]float64{"x": {1, math.NaN()}})
+	var ee *EncodeError
+	if !errors.As(err, &ee) || ee.Path.String() != "x[1]" {
+		t.Errorf("NaN error = %v", err)
+	}
+}
+
+func TestBufferPool(t *testing.T) {
+	for _, hint := range []int{0, 100, 5 << 10, 100 << 10} {
+		buf := getBuffer(hint)
+		if buf.Len() != 0 || buf.Cap() < hint {
+			t.Errorf("getBuffer(%d): len %d cap %d", hint, buf.Len(), buf.Cap())
+		}
+		buf.WriteString("x")
+		putBuffer(buf)
+	}
+	if bufferTier(0) != 0 || bufferTier(4<<10) != 1 || bufferTier(1<<20) != len(bufferTiers)-1 {
+		t.Error("unexpected buffer tiers")
+	}
+
+	// 超大缓冲不回池：之后取到的缓冲不会是它
+	huge := getBuffer(2 * maxPooledBuffer)
+	putBuffer(huge)
+	if b := getBuffer(64 << 10); b == huge {
+		t.Error("oversized buffer was pooled")
+	}
+
+	b, err := NewEncoder().WithBufferSize(1 << 10).Marshal(User{ID: 1, Name: "A"})
+	if err != nil || !strings.Contains(string(b), `"id":1`) {
+		t.Errorf("WithBufferSize Marshal = %s, %v", b, err)
+	}
+}
+
+func TestCompile(t *testing.T) {
+	enc := NewEncoder().WithGroups("public").WithTopLevelKey("data")
+	c, err := enc.Compile([]User(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	users := []User{{ID: 1, Name: "A", Email: "a@x"}, {ID: 2, Name: "B"}}
+	want, _ := enc.Marshal(users)
+	got, err := c.Marshal(users)
+	if err != nil || string(got) != string(want) {
+		t.Errorf("Compiled.Marshal = %s, %v; want %s", got, err, want)
+	}
+	var sb strings.Builder
+	if err := c.Encode(&sb, users); err != nil || sb.String() != string(want) {
+		t.Errorf("Compiled.Encode = %s, %v", sb.String(), err)
+	}
+	if _, err := c.Marshal(users[0]); !errors.Is(err, ErrInvalidType) {
+		t.Errorf("wrong root type: %v", err)
+	}
+
+	if _, err := NewEncoder().WithGroups("no-such-group").WithStrictGroups(true).Compile(User{}); !errors.Is(err, ErrUnknownGroup) {
+		t.Errorf("strict groups: %v", err)
+	}
+}
+
+func TestPrecompile(t *testing.T) {
+	type Inner struct {
+		X int `json:"x" groups:"public"`
+	}
+	type Warm struct {
+		A  string  `json:"a" groups:"public"`
+		In []Inner `json:"in" groups:"admin"`
+	}
+	Precompile(&Warm{})
+	PrecompileGroups([]string{"public"}, Warm{})
+	enc := NewEncoder().WithGroups("public")
+	for _, typ := range []reflect.Type{reflect.TypeFor[Warm](), reflect.TypeFor[Inner]()} {
+		if _, ok := schemaCache.Load(enc.schemaKey(typ)); !ok {
+			t.Errorf("schema of %v not cached", typ)
+		}
+	}
+	if _, ok := planCache.Load(planKey{schemaKey: enc.schemaKey(reflect.TypeFor[Warm]()), groups: planGroupKey(enc.opts), mode: ModeOr}); !ok {
+		t.Error("public plan not cached")
+	}
+	if _, ok := encoderCache.Load(reflect.TypeFor[*Warm]()); !ok {
+		t.Error("root encoder not compiled")
+	}
+}
+
+func TestCacheStats(t *testing.T) {
+	type Stat struct {
+		A int `json:"a" groups:"public"`
+	}
+	before := CacheStats()
+	for range 2 {
+		if _, err := Marshal([]Stat{{A: 1}, {A: 2}}, "public"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	after := CacheStats()
+	if after.Misses <= before.Misses || after.Hits <= before.Hits || after.Entries == 0 || after.Bytes <= 0 {
+		t.Errorf("stats before %+v, after %+v", before, after)
+	}
+
+	ResetCaches()
+	if s := CacheStats(); s.Entries != 0 || s.Plans != 0 || s.Bytes != 0 {
+		t.Errorf("after ResetCaches: %+v", s)
+	}
+	if b, _ := Marshal(Stat{A: 3}, "public"); string(b) != `{"a":3}` {
+		t.Errorf("Marshal after reset = %s", b)
+	}
+
+	old := MaxCachedSchemas
+	MaxCachedSchemas = 2
+	defer func() { MaxCachedSchemas = old }()
+	for _, v := range []any{User{}, Address{}, Meta{}, Stat{}, compiledTree{}} {
+		if _, err := Marshal(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if s := CacheStats(); s.Entries > 2 {
+		t.Errorf("Entries = %d, limit 2", s.Entries)
+	}
+}
+
+func TestDeepEmbeddedAccess(t *testing.T) {
+	for _, v := range []embedRoot{
+		{ID: 1, EmbedLevel1: EmbedLevel1{Shallow: 2, EmbedLevel2: EmbedLevel2{Mid: "m", EmbedLevel3: &EmbedLevel3{Deep: "d", Deep2: 3}}}},
+		{ID: 1}, // nil 嵌入指针：其提升字段被跳过
+	} {
+		want, _ := json.Marshal(v)
+		got, err := Marshal(v, "public")
+		var wm, gm map[string]any
+		_ = json.Unmarshal(want, &wm)
+		_ = json.Unmarshal(got, &gm)
+		if err != nil || !reflect.DeepEqual(gm, wm) {
+			t.Errorf("Marshal = %s, %v; want %s", got, err, want)
+		}
+	}
+
+	// 经由值嵌入与指针嵌入的字段访问不分配
+	v := reflect.ValueOf(embedRoot{EmbedLevel1: EmbedLevel1{EmbedLevel2: EmbedLevel2{EmbedLevel3: &EmbedLevel3{}}}})
+	sch := NewEncoder().schemaFor(v.Type())
+	if n := testing.AllocsPerRun(100, func() {
+		for i := range sch.fields {
+			_ = fieldByIndex(v, sch.fields[i].index)
+		}
+	}); n != 0 {
+		t.Errorf("fieldByIndex allocs = %v", n)
+	}
+}
+
+func TestBackend(t *testing.T) {
+	type Series struct {
+		Name   string              `json:"name" groups:"public"`
+		Points []float64           `json:"points" groups:"public"`
+		Tags   map[string][]string `json:"tags" groups:"public"`
+		Body   string              `json:"body" groups:"public"`
+		Secret string              `json:"secret" groups:"admin"`
+	}
+	var calls []string
+	backend := BackendFunc(func(v any) ([]byte, error) {
+		calls = append(calls, reflect.TypeOf(v).String())
+		return json.Marshal(v)
+	})
+	long := strings.Repeat("x", backendStringMin)
+	v := Series{Name: "cpu", Points: []float64{1.5, 2}, Tags: map[string][]string{"host": {"a"}}, Body: long, Secret: "s"}
+
+	want, _ := NewEncoder().WithGroups("public").Marshal(v)
+	got, err := NewEncoder().WithGroups("public").WithBackend(backend).Marshal(v)
+	if err != nil || string(got) != string(want) {
+		t.Errorf("Marshal = %s, %v; want %s", got, err, want)
+	}
+	if strings.Join(calls, " ") != "[]float64 map[string][]string string" {
+		t.Errorf("backend calls = %v", calls)
+	}
+
+	// 改变输出格式或需要逐值路径的配置不委托
+	for name, enc := range map[string]Encoder{
+		"float format": NewEncoder().WithFloatFormat('f', 2),
+		"max bytes":    NewEncoder().WithMaxBytes(1 << 20),
+		"trace":        NewEncoder().WithTrace(func(TraceEvent) {}),
+	} {
+		calls = nil
+		if _, err := enc.WithGroups("public").WithBackend(backend).Marshal(v); err != nil {
+			t.Fatal(err)
+		}
+		if len(calls) != 0 {
+			t.Errorf("%s: backend calls = %v", name, calls)
+		}
+	}
+
+	// 后端的错误原样返回
+	failing := BackendFunc(func(any) ([]byte, error) { return nil, errors.New("boom") })
+	if _, err := NewEncoder().WithBackend(failing).Marshal([]int{1}); err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("err = %v", err)
+	}
+
+	for typ, ok := range map[reflect.Type]bool{
+		reflect.TypeFor[[]int]():               true,
+		reflect.TypeFor[map[string]*float64](): true,
+		reflect.TypeFor[[][]byte]():            true,
+		reflect.TypeFor[[]User]():              false,
+		reflect.TypeFor[[]any]():               false,
+		reflect.TypeFor[map[int]string]():      false,
+		reflect.TypeFor[[]time.Time]():         false,
+	} {
+		if backendPlain(typ) != ok {
+			t.Errorf("backendPlain(%v) = %v", typ, !ok)
+		}
+	}
+}
+
+func TestTypedEncoder(t *testing.T) {
+	enc := NewEncoder().WithGroups("public").WithTopLevelKey("data")
+	te, err := NewTypedEncoder[[]User](enc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	users := []User{{ID: 1, Name: "A", Email: "a@x"}, {ID: 2, Name: "B"}}
+	want, _ := enc.Marshal(users)
+	got, err := te.Marshal(users)
+	if err != nil || string(got) != string(want) {
+		t.Errorf("TypedEncoder.Marshal = %s, %v; want %s", got, err, want)
+	}
+	var sb strings.Builder
+	if err := te.Encode(&sb, users); err != nil || sb.String() != string(want) {
+		t.Errorf("TypedEncoder.Encode = %s, %v", sb.String(), err)
+	}
+	if b, err := MarshalT(users[0], "public"); err != nil || string(b) != `{"id":1,"name":"A","address":{"city":""},"created_at":"0001-01-01T00:00:00Z"}` {
+		t.Errorf("MarshalT = %s, %v", b, err)
+	}
+
+	// 接口类型按动态类型编码
+	ti, err := NewTypedEncoder[any](NewEncoder().WithGroups("public"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []any{nil, users[0], &users[1], []int{1}} {
+		got, err := ti.Marshal(v)
+		if want, _ := Marshal(v, "public"); err != nil || string(got) != string(want) {
+			t.Errorf("TypedEncoder[any].Marshal(%T) = %s, %v; want %s", v, got, err, want)
+		}
+	}
+
+	if _, err := NewTypedEncoder[User](NewEncoder().WithGroups("no-such-group").WithStrictGroups(true)); !errors.Is(err, ErrUnknownGroup) {
+		t.Errorf("strict groups: %v", err)
+	}
+}
+
+// genPoint 模拟 cmd/groupjson 生成的编码代码
+type genPoint struct {
+	X int `json:"x" groups:"public,admin"`
+	Y int `json:"y" groups:"admin"`
+}
+
+var genPointCalls atomic.Int64
+
+func (p *genPoint) AppendGroupJSON(dst []byte, groups []string, mode GroupMode) ([]byte, error) {
+	genPointCalls.Add(1)
+	if p.X < 0 {
+		return dst, errors.New("negative x")
+	}
+	dst = append(dst, `{"x":`...)
+	dst = strconv.AppendInt(dst, int64(p.X), 10)
+	if slices.Contains(groups, "admin") {
+		dst = append(dst, `,"y":`...)
+		dst = strconv.AppendInt(dst, int64(p.Y), 10)
+	}
+	return append(dst, '}'), nil
+}
+
+func TestGroupEncoderAppender(t *testing.T) {
+	type Shape struct {
+		Name   string               `json:"name" groups:"public"`
+		Origin genPoint             `json:"origin" groups:"public"`
+		Path   []genPoint           `json:"path" groups:"public"`
+		Marks  map[string]*genPoint `json:"marks" groups:"public"`
+	}
+	v := Shape{Name: "s", Origin: genPoint{1, 2}, Path: []genPoint{{3, 4}, {5, 6}}, Marks: map[string]*genPoint{"a": {7, 8}}}
+	for _, groups := range [][]string{{"public"}, {"public", "admin"}} {
+		// WithMaxBytes 不改变输出但禁用生成代码，作为反射路径的参照
+		want, _ := NewEncoder().WithGroups(groups...).WithMaxBytes(1 << 20).Marshal(v)
+		genPointCalls.Store(0)
+		got, err := NewEncoder().WithGroups(groups...).Marshal(&v)
+		if err != nil || string(got) != string(want) {
+			t.Errorf("groups %v: Marshal = %s, %v; want %s", groups, got, err, want)
+		}
+		if genPointCalls.Load() != 4 {
+			t.Errorf("groups %v: AppendGroupJSON calls = %d, want 4", groups, genPointCalls.Load())
+		}
+	}
+
+	// 改变输出格式的配置回退到反射路径
+	genPointCalls.Store(0)
+	if _, err := NewEncoder().WithGroups("public").WithNamingStrategy(SnakeCase).Marshal(v); err != nil || genPointCalls.Load() != 0 {
+		t.Errorf("naming strategy: err %v, calls %d", err, genPointCalls.Load())
+	}
+	// 生成代码按默认深度上限计算嵌套层数，调整深度上限同样回退
+	if _, err := NewEncoder().WithGroups("public").WithMaxDepth(64).Marshal(v); err != nil || genPointCalls.Load() != 0 {
+		t.Errorf("max depth: err %v, calls %d", err, genPointCalls.Load())
+	}
+
+	// 生成代码的错误按 ErrorPolicy 处理
+	if _, err := Marshal(&Shape{Origin: genPoint{X: -1}}, "public"); err == nil || !strings.Contains(err.Error(), "negative x") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+// fieldTableUser FieldTable 测试用的类型，覆盖分组项、修饰与嵌套引用。
+type fieldTableUser struct {
+	ID     int                       `json:"id" groups:"public,admin"`
+	Email  string                    `json:"email,omitempty" groups:"admin;mask=email;unmask=internal"`
+	Salary int                       `json:"salary" groups:"admin+internal"`
+	Secret string                    `json:"secret" groups:"-"`
+	Note   string                    `json:"note"`
+	Home   *Address                  `json:"home" groups:"public"`
+	ByName map[string]fieldTableUser `json:"by_name,omitzero" groups:"internal"`
+}
+
+func TestFieldTable(t *testing.T) {
+	var buf bytes.Buffer
+	if err := FieldTable(&buf, fieldTableUser{}, &fieldTableUser{}); err != nil {
+		t.Fatal(err)
+	}
+	want := `github.com/JieBaiYou/groupjson.fieldTableUser
+  FIELD    public  admin  internal  FLAGS
+  id       ✓       ✓
+  email            ✓                omitempty mask=email unmask=internal
+  salary           +      +         admin+internal
+  secret                            never
+  note                              ungrouped
+  home     ✓                        ref=github.com/JieBaiYou/groupjson.Address
+  by_name                 ✓         omitzero ref=map[string]github.com/JieBaiYou/groupjson.fieldTableUser
+
+github.com/JieBaiYou/groupjson.Address
+`
+	// 根类型只写一次，引用的类型排在其后
+	if got := buf.String(); !strings.HasPrefix(got, want) || strings.Count(got, "FIELD") != 2 {
+		t.Fatalf("got:\n%s", got)
+	}
+	if err := FieldTable(&buf, 1); err != ErrInvalidType {
+		t.Fatalf("expect ErrInvalidType, got %v", err)
+	}
+}
+
 // Benchmarks -> 基准测试
 
 func makeUsers(n int) []User {
@@ -328,6 +3231,24 @@ func BenchmarkMarshalSmall(b *testing.B) {
 	}
 }
 
+func BenchmarkCompiledSmall(b *testing.B) {
+	u := User{ID: 1, Name: "A", Email: "e", Password: "p", Tags: []string{"x"}, Scores: []int{1, 2, 3}, Addr: Address{City: "SZ"}, Meta: Meta{CreatedAt: time.Now()}}
+	c, _ := NewEncoder().WithGroups("public", "admin").Compile(u)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = c.Marshal(u)
+	}
+}
+
+func BenchmarkTypedSmall(b *testing.B) {
+	u := User{ID: 1, Name: "A", Email: "e", Password: "p", Tags: []string{"x"}, Scores: []int{1, 2, 3}, Addr: Address{City: "SZ"}, Meta: Meta{CreatedAt: time.Now()}}
+	te, _ := NewTypedEncoder[User](NewEncoder().WithGroups("public", "admin"))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = te.Marshal(u)
+	}
+}
+
 func BenchmarkMarshalLargeSlice(b *testing.B) {
 	users := makeUsers(2000)
 	enc := NewEncoder().WithGroups("public")
@@ -352,3 +3273,98 @@ func BenchmarkStdlibLargeSlice(b *testing.B) {
 		_, _ = json.Marshal(users)
 	}
 }
+
+func BenchmarkWriteString(b *testing.B) {
+	e := NewEncoder()
+	var buf bytes.Buffer
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf.Reset()
+		for _, s := range escapeSamples {
+			e.writeString(&buf, s)
+		}
+	}
+}
+
+func BenchmarkStdlibString(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for _, s := range escapeSamples {
+			_, _ = json.Marshal(s)
+		}
+	}
+}
+
+func BenchmarkMarshalPrimitives(b *testing.B) {
+	type Sample struct {
+		Values []float64         `json:"values"`
+		Tags   []string          `json:"tags"`
+		Labels map[string]string `json:"labels"`
+		Counts []int             `json:"counts"`
+	}
+	s := Sample{Values: make([]float64, 256), Tags: make([]string, 64), Labels: map[string]string{"env": "prod", "region": "cn"}, Counts: make([]int, 256)}
+	for i := range s.Values {
+		s.Values[i], s.Counts[i] = float64(i)/3, i*7
+	}
+	for i := range s.Tags {
+		s.Tags[i] = "tag" + strconv.Itoa(i)
+	}
+	enc := NewEncoder()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = enc.Marshal(s)
+	}
+}
+
+type EmbedLevel3 struct {
+	Deep  string `json:"deep" groups:"public"`
+	Deep2 int    `json:"deep2" groups:"public"`
+}
+
+type EmbedLevel2 struct {
+	*EmbedLevel3
+	Mid string `json:"mid" groups:"public"`
+}
+
+type EmbedLevel1 struct {
+	EmbedLevel2
+	Shallow int `json:"shallow" groups:"public"`
+}
+
+type embedRoot struct {
+	EmbedLevel1
+	ID int `json:"id" groups:"public"`
+}
+
+func BenchmarkFieldAccessDeepEmbedded(b *testing.B) {
+	v := reflect.ValueOf(embedRoot{EmbedLevel1: EmbedLevel1{EmbedLevel2: EmbedLevel2{EmbedLevel3: &EmbedLevel3{Deep: "d"}}}})
+	sch := NewEncoder().schemaFor(v.Type())
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for j := range sch.fields {
+			_ = fieldByIndex(v, sch.fields[j].index)
+		}
+	}
+}
+
+func BenchmarkMarshalDeepEmbedded(b *testing.B) {
+	items := make([]embedRoot, 500)
+	for i := range items {
+		items[i].ID, items[i].Shallow, items[i].Mid = i, i, "m"
+		items[i].EmbedLevel3 = &EmbedLevel3{Deep: "d", Deep2: i}
+	}
+	enc := NewEncoder().WithGroups("public")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = enc.Marshal(items)
+	}
+}
+
+func BenchmarkSliceToMaps(b *testing.B) {
+	users := makeUsers(2000)
+	enc := NewEncoder().WithGroups("public")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = enc.SliceToMaps(users)
+	}
+}
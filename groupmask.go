@@ -0,0 +1,128 @@
+package groupjson
+
+import (
+	"strings"
+	"sync"
+)
+
+// groupMask 以位集合表示一组分组名，每个分组名在首次出现于结构体标签时分配一个位。
+type groupMask uint64
+
+// maxGroupIDs 可分配位的分组名上限；超出后相关字段回退到字符串比较。
+const maxGroupIDs = 64
+
+// groupIDs 分组名 -> 位序号。只由结构体标签填充（标签数量有限），请求的分组只查询不分配，
+// 以免来自外部输入的分组名耗尽位空间。
+var groupIDs struct {
+	sync.RWMutex
+	ids map[string]uint8
+}
+
+// internGroups 返回分组项列表中各项（"a+b" 为其各部分之并）的位集合以及它们的并集；
+// 分组名超出 maxGroupIDs 时 ok 为 false。
+func internGroups(entries []string) (masks []groupMask, union groupMask, ok bool) {
+	groupIDs.Lock()
+	defer groupIDs.Unlock()
+	if groupIDs.ids == nil {
+		groupIDs.ids = map[string]uint8{}
+	}
+	masks = make([]groupMask, 0, len(entries))
+	for _, entry := range entries {
+		var m groupMask
+		for _, g := range strings.Split(entry, "+") {
+			if g == "" {
+				continue
+			}
+			id, found := groupIDs.ids[g]
+			if !found {
+				if len(groupIDs.ids) >= maxGroupIDs {
+					return nil, 0, false
+				}
+				id = uint8(len(groupIDs.ids))
+				groupIDs.ids[g] = id
+			}
+			m |= 1 << id
+		}
+		masks = append(masks, m)
+		union |= m
+	}
+	return masks, union, true
+}
+
+// groupView 编译后的单个请求视图。
+type groupView struct {
+	// mask 请求的分组
+	mask groupMask
+	// impossible 请求了从未出现在任何标签中的分组，AND 模式下不可能满足
+	impossible bool
+}
+
+// groupQuery 本次编码请求的分组（含合并视图）编译成的位集合，每次编码只计算一次。
+type groupQuery struct {
+	// views 主视图在前，其后为 MergedViews
+	views []groupView
+	// mode 分组匹配模式
+	mode GroupMode
+	// known 编译时已分配的全部位；字段引用了此后才分配的分组时回退到字符串比较
+	known groupMask
+}
+
+// compileGroups 将请求的分组编译为 groupQuery；未请求分组时返回零值（不分配）。
+func compileGroups(o Options) groupQuery {
+	if len(o.Groups) == 0 {
+		return groupQuery{}
+	}
+	groupIDs.RLock()
+	defer groupIDs.RUnlock()
+	q := groupQuery{views: make([]groupView, 0, 1+len(o.MergedViews)), mode: o.Mode}
+	if n := len(groupIDs.ids); n >= maxGroupIDs {
+		q.known = ^groupMask(0)
+	} else {
+		q.known = 1<<n - 1
+	}
+	for _, groups := range append([][]string{o.Groups}, o.MergedViews...) {
+		var v groupView
+		for _, g := range groups {
+			if id, ok := groupIDs.ids[g]; ok {
+				v.mask |= 1 << id
+			} else {
+				v.impossible = true
+			}
+		}
+		q.views = append(q.views, v)
+	}
+	return q
+}
+
+// fieldVisible 判断字段在请求的分组下是否可见，语义与 includeField 相同：
+// 字段分组位集合均已编译时按位运算判断，否则回退到字符串比较。
+func (e Encoder) fieldVisible(f *fieldInfo, q *groupQuery) bool {
+	if !f.groupBitsOK || f.groupUnion&^q.known != 0 || len(q.views) == 0 {
+		return e.includeField(f.groups)
+	}
+	for _, v := range q.views {
+		if v.include(q.mode, f.groupBits) {
+			return true
+		}
+	}
+	return false
+}
+
+// include 对应 includeIn：OR 模式下任一分组项的各部分均被请求即可见；
+// AND 模式下由被满足的分组项覆盖全部请求的分组才可见。
+func (v groupView) include(mode GroupMode, entries []groupMask) bool {
+	if v.mask == 0 && !v.impossible {
+		return false
+	}
+	var covered groupMask
+	for _, m := range entries {
+		if m == 0 || m&^v.mask != 0 {
+			continue
+		}
+		if mode != ModeAnd {
+			return true
+		}
+		covered |= m
+	}
+	return mode == ModeAnd && !v.impossible && covered == v.mask
+}
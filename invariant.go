@@ -0,0 +1,52 @@
+package groupjson
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Invariant 在每个结构体实例编码前执行的一致性检查，v 为结构体值（非指针）。
+// 返回非 nil 错误时编码中止，错误被包装为带路径的 *InvariantError。
+type Invariant func(v any) error
+
+// InvariantError 描述一次不变量检查失败。
+type InvariantError struct {
+	// Path 违反不变量的结构体实例所在路径，根对象为空路径
+	Path Path
+	// Type 结构体类型
+	Type reflect.Type
+	// Err 不变量返回的原始错误
+	Err error
+}
+
+func (e *InvariantError) Error() string {
+	path := e.Path.String()
+	if path == "" {
+		path = "$"
+	}
+	return fmt.Sprintf("groupjson: invariant violated at %s (%s): %v", path, e.Type, e.Err)
+}
+
+func (e *InvariantError) Unwrap() error { return e.Err }
+
+// WithInvariant 追加结构体级不变量，如"已删除用户的公开视图不得包含 Email"，
+// 让依赖数据的策略在编码时统一校验，而不是散落在各个 handler 中。
+// 不变量按添加顺序执行，对所有嵌套的结构体实例生效，由调用方按类型自行筛选。
+func (e Encoder) WithInvariant(fn Invariant) Encoder {
+	e.opts.Invariants = append(append([]Invariant(nil), e.opts.Invariants...), fn)
+	return e
+}
+
+// checkInvariants 对结构体实例依次执行不变量。
+func (e Encoder) checkInvariants(v reflect.Value, ctx *encodeContext) error {
+	if !v.CanInterface() {
+		return nil
+	}
+	iv := v.Interface()
+	for _, fn := range e.opts.Invariants {
+		if err := fn(iv); err != nil {
+			return &InvariantError{Path: ctx.path.Clone(), Type: v.Type(), Err: err}
+		}
+	}
+	return nil
+}
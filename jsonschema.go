@@ -0,0 +1,249 @@
+package groupjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// JSONSchemaDraft 生成的 JSON Schema 所声明的规范版本。
+const JSONSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+var timeType = reflect.TypeFor[time.Time]()
+
+// JSONSchema 使用默认配置生成 v 的类型在 groups 下的 JSON Schema，见 Encoder.JSONSchema。
+func JSONSchema(v any, groups ...string) ([]byte, error) {
+	return NewEncoder().WithGroups(groups...).JSONSchema(v)
+}
+
+// JSONSchema 生成 draft 2020-12 JSON Schema，只描述当前分组下可见的字段，嵌套结构体放在 $defs 中，
+// 供 API 使用方校验分组视图的响应。不带 omitempty/omitzero 与 if 条件的字段列为 required；
+// 脱敏字段与 ,string 字段描述为字符串。路径规则、字段谓词等运行期条件无法静态表达，不参与生成。
+func (e Encoder) JSONSchema(v any) ([]byte, error) {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, ErrInvalidType
+	}
+	g := newSchemaGen("#/$defs/")
+	g.view(e, "")
+	root := g.typeSchema(t)
+	doc := orderedObject{}
+	doc.set("$schema", JSONSchemaDraft)
+	doc.set("$ref", root["$ref"])
+	doc.set("$defs", g.defs)
+	return json.Marshal(doc)
+}
+
+// schemaGen 按分组视图把 Go 类型转换为 JSON Schema 节点，OpenAPI 生成复用同一实现。
+type schemaGen struct {
+	e Encoder
+	// refPrefix $ref 前缀，如 "#/$defs/" 或 "#/components/schemas/"
+	refPrefix string
+	// suffix 追加在定义名之后，区分同一类型的不同视图，如 "Public"
+	suffix string
+	// defs 已生成的结构体定义
+	defs orderedObject
+	// names 类型 -> 定义名
+	names map[reflect.Type]string
+	// used 已占用的定义名
+	used map[string]bool
+}
+
+func newSchemaGen(refPrefix string) *schemaGen {
+	return &schemaGen{refPrefix: refPrefix, used: map[string]bool{}}
+}
+
+// view 切换到新的分组视图：之后生成的定义使用 e 的分组并带有 suffix 后缀，已生成的定义保留。
+func (g *schemaGen) view(e Encoder, suffix string) {
+	g.e, g.suffix = e, suffix
+	g.names = map[reflect.Type]string{}
+}
+
+// typeSchema 返回类型 t 的 schema 节点。
+func (g *schemaGen) typeSchema(t reflect.Type) map[string]any {
+	if t.Kind() == reflect.Pointer {
+		return nullable(g.typeSchema(t.Elem()))
+	}
+	switch {
+	case t == timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case t == syncMapType:
+		return map[string]any{"type": "object"}
+	case t.Implements(groupMarshalerType) || reflect.PointerTo(t).Implements(groupMarshalerType),
+		t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType):
+		return map[string]any{}
+	case t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType):
+		return map[string]any{"type": "string"}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]any{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return map[string]any{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 && t.Kind() == reflect.Slice {
+			return map[string]any{"type": "string", "contentEncoding": "base64"}
+		}
+		s := map[string]any{"type": "array", "items": g.typeSchema(t.Elem())}
+		if t.Kind() == reflect.Array {
+			s["minItems"], s["maxItems"] = t.Len(), t.Len()
+		} else if g.e.opts.NilCollections != NilAsEmpty {
+			s = nullable(s)
+		}
+		return s
+	case reflect.Map:
+		s := map[string]any{"type": "object", "additionalProperties": g.typeSchema(t.Elem())}
+		if g.e.opts.NilCollections != NilAsEmpty {
+			s = nullable(s)
+		}
+		return s
+	case reflect.Func:
+		switch seqKind(t) {
+		case 1:
+			return nullable(map[string]any{"type": "array", "items": g.typeSchema(t.In(0).In(0))})
+		case 2:
+			return nullable(map[string]any{"type": "object", "additionalProperties": g.typeSchema(t.In(0).In(1))})
+		}
+	case reflect.Chan:
+		return map[string]any{"type": "array", "items": g.typeSchema(t.Elem())}
+	case reflect.Struct:
+		return map[string]any{"$ref": g.refPrefix + g.structDef(t)}
+	}
+	return map[string]any{}
+}
+
+// structDef 生成结构体 t 的定义并返回定义名，自引用类型只生成一次。
+func (g *schemaGen) structDef(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+	name := g.defName(t)
+	g.names[t] = name
+
+	e := g.e
+	sch := e.schemaFor(t)
+	p := e.getPlan(t, sch, planGroupKey(e.opts))
+	props := orderedObject{}
+	var required []string
+	for _, f := range p.fields {
+		if !e.tupleColumn(p, f) {
+			continue
+		}
+		ft := t.FieldByIndex(f.index).Type
+		var s map[string]any
+		switch {
+		case e.masked(f), f.asString && isQuotable(ft):
+			s = map[string]any{"type": "string"}
+		default:
+			s = g.typeSchema(ft)
+		}
+		if ex, err := exampleJSON(t.FieldByIndex(f.index)); err == nil && ex != nil {
+			s["examples"] = []json.RawMessage{ex}
+		}
+		props.set(f.jsonName, s)
+		if !f.omitEmpty && !f.omitZero && f.cond == "" {
+			required = append(required, f.jsonName)
+		}
+	}
+	if list, ok := virtualFields.Load(t); ok {
+		for _, vf := range list {
+			if len(e.opts.Groups) == 0 || e.includeField(vf.groups) {
+				props.set(vf.name, map[string]any{})
+			}
+		}
+	}
+	def := orderedObject{}
+	def.set("type", "object")
+	def.set("properties", props)
+	if len(required) > 0 {
+		def.set("required", required)
+	}
+	def.set("additionalProperties", false)
+	g.defs.set(name, def)
+	return name
+}
+
+// defName 返回结构体的定义名（类型名加视图后缀），不同包的同名类型追加序号区分。
+func (g *schemaGen) defName(t reflect.Type) string {
+	base := t.Name()
+	if base == "" {
+		base = "Anonymous"
+	}
+	base += g.suffix
+	name := base
+	for i := 2; g.used[name]; i++ {
+		name = base + strconv.Itoa(i)
+	}
+	g.used[name] = true
+	return name
+}
+
+// isQuotable 判断 ,string 选项是否作用于该类型（解引用指针后的标量）。
+func isQuotable(t reflect.Type) bool {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	_, ok := quotableScalar(reflect.New(t).Elem())
+	return ok
+}
+
+// nullable 允许节点取 null。
+func nullable(s map[string]any) map[string]any {
+	if typ, ok := s["type"].(string); ok {
+		c := make(map[string]any, len(s))
+		for k, v := range s {
+			c[k] = v
+		}
+		c["type"] = []string{typ, "null"}
+		return c
+	}
+	return map[string]any{"anyOf": []any{s, map[string]any{"type": "null"}}}
+}
+
+// orderedObject 按插入顺序序列化的 JSON 对象，使生成的 schema 保持字段声明顺序。
+type orderedObject struct {
+	keys []string
+	vals map[string]any
+}
+
+func (o *orderedObject) set(k string, v any) {
+	if o.vals == nil {
+		o.vals = map[string]any{}
+	}
+	if _, ok := o.vals[k]; !ok {
+		o.keys = append(o.keys, k)
+	}
+	o.vals[k] = v
+}
+
+// MarshalJSON 按插入顺序输出键值。
+func (o orderedObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		kb, _ := json.Marshal(k)
+		buf.Write(kb)
+		buf.WriteByte(':')
+		vb, err := json.Marshal(o.vals[k])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(vb)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,82 @@
+package groupjson
+
+import (
+	"bytes"
+	"reflect"
+)
+
+// 键表输出中的两个固定键名。
+const (
+	KeyTableKeys = "keys"
+	KeyTableRows = "rows"
+)
+
+// keyTableElem 判断切片/数组类型是否以键表输出，返回元素的结构体类型。
+// 自定义序列化的元素类型保持原有输出。
+func keyTableElem(t reflect.Type) (reflect.Type, bool) {
+	et := t.Elem()
+	for et.Kind() == reflect.Pointer {
+		et = et.Elem()
+	}
+	if et.Kind() != reflect.Struct || opaqueSchemaType(et) {
+		return nil, false
+	}
+	return et, true
+}
+
+// encodeKeyTable 写出 {"keys":[...],"rows":[[...],...]}；nil 元素对应的行为 null。
+func (e Encoder) encodeKeyTable(buf *bytes.Buffer, v reflect.Value, et reflect.Type, ctx *encodeContext) error {
+	if v.Kind() == reflect.Slice && v.IsNil() && e.opts.NilCollections != NilAsEmpty {
+		buf.WriteString("null")
+		return nil
+	}
+	if err := ctx.incDepth(); err != nil {
+		return ctx.truncate(buf, err)
+	}
+	defer ctx.decDepth()
+
+	p := e.getPlan(et, e.schemaFor(et), ctx.groupKey)
+	buf.WriteString(`{"` + KeyTableKeys + `":[`)
+	first := true
+	for _, f := range p.fields {
+		if !e.tupleColumn(p, f) {
+			continue
+		}
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		buf.Write(f.keyBytes[:len(f.keyBytes)-1])
+	}
+	buf.WriteString(`],"` + KeyTableRows + `":[`)
+
+	first = true
+	for i := 0; i < v.Len(); i++ {
+		mark, wasFirst := buf.Len(), first
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		el := v.Index(i)
+		for el.Kind() == reflect.Pointer && !el.IsNil() {
+			el = el.Elem()
+		}
+		ctx.pushPath(PathSegment{Kind: SegmentIndex, Index: i})
+		var err error
+		if el.Kind() == reflect.Pointer {
+			buf.WriteString("null")
+		} else {
+			err = e.encodeStructAs(buf, el, ctx, true, "")
+		}
+		ctx.popPath()
+		if err != nil {
+			if err != errOmit {
+				return withSegment(err, PathSegment{Kind: SegmentIndex, Index: i}, v.Type().Elem())
+			}
+			buf.Truncate(mark)
+			first = wasFirst
+		}
+	}
+	buf.WriteString("]}")
+	return nil
+}
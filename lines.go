@@ -0,0 +1,168 @@
+package groupjson
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+)
+
+// EncodeLines 以 NDJSON 形式写出 v 的元素：每个元素单独筛选编码为一行 JSON。
+// v 可以是切片、数组、iter.Seq[T]（或它们的指针），开启 WithChannelEncoding 时也可以是 channel；
+// 其他类型返回 ErrUnsupportedType。每行编码完成后立即写入 w，Envelope 与 TopLevelKey 不参与按行输出。
+// 某个元素编码失败时返回带行号的错误，此前的行已写入 w；非 ErrorFail 的 ErrorPolicy 下跳过或置空的值
+// 不中断输出，全部行写出后以 *MultiError 返回，路径以元素下标开头。
+func (e Encoder) EncodeLines(w io.Writer, v any) error {
+	rv := reflect.ValueOf(v)
+	for (rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface) && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	e.opts.TopLevelKey, e.opts.Envelope = "", nil
+	if err := e.checkTop(); err != nil {
+		return err
+	}
+
+	buf := getBuffer(e.opts.BufferSize)
+	defer putBuffer(buf)
+	line, index := 0, 0
+	var errs []*EncodeError
+	emit := func(elem reflect.Value) error {
+		buf.Reset()
+		ctx := acquireContext(e.opts)
+		ctx.pushPath(PathSegment{Kind: SegmentIndex, Index: index})
+		index++
+		err := e.encode(buf, elem, ctx)
+		if err == nil {
+			err = ctx.checkSize(buf)
+		}
+		errs = append(errs, ctx.errs...)
+		releaseContext(ctx)
+		if err == errOmit {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("groupjson: line %d: %w", line, err)
+		}
+		if e.opts.SampleSink != nil {
+			e.sample(elem.Interface(), buf.Bytes())
+		}
+		line++
+		buf.WriteByte('\n')
+		_, err = w.Write(buf.Bytes())
+		return err
+	}
+
+	switch {
+	case rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			if err := emit(rv.Index(i)); err != nil {
+				return err
+			}
+		}
+		return multiError(errs)
+	case rv.Kind() == reflect.Chan && e.opts.ChannelEncoding && rv.Type().ChanDir()&reflect.RecvDir != 0:
+		if rv.IsNil() {
+			return nil
+		}
+		for {
+			x, ok := rv.Recv()
+			if !ok {
+				return multiError(errs)
+			}
+			if err := emit(x); err != nil {
+				return err
+			}
+		}
+	case rv.Kind() == reflect.Func && seqKind(rv.Type()) == 1:
+		if rv.IsNil() {
+			return nil
+		}
+		var emitErr error
+		yield := reflect.MakeFunc(rv.Type().In(0), func(args []reflect.Value) []reflect.Value {
+			emitErr = emit(args[0])
+			return []reflect.Value{reflect.ValueOf(emitErr == nil)}
+		})
+		rv.Call([]reflect.Value{yield})
+		if emitErr != nil {
+			return emitErr
+		}
+		return multiError(errs)
+	}
+	return ErrUnsupportedType
+}
+
+// EncodeSeq 从拉取函数 next 逐个获取元素，增量写出 JSON 数组（Envelope/TopLevelKey 照常包裹，计数为实际写出的元素数），
+// 适合数据库游标等结果集无法一次性载入内存的场景；next 返回 false 表示结束。
+// 每个元素写入后，若 w 实现了 Flush（如 http.Flusher、*bufio.Writer）则立即刷新。
+// 出错时已写出的部分不会回滚，调用方应中止响应；非 ErrorFail 的 ErrorPolicy 下跳过或置空的值
+// 在完整写出后以 *MultiError 返回。
+func (e Encoder) EncodeSeq(w io.Writer, next func() (any, bool)) error {
+	if err := e.checkTop(); err != nil {
+		return err
+	}
+	buf := getBuffer(e.opts.BufferSize)
+	defer putBuffer(buf)
+
+	e.openTop(buf)
+	buf.WriteByte('[')
+	first, count := true, 0
+	var errs []*EncodeError
+	for i := 0; ; i++ {
+		v, ok := next()
+		if !ok {
+			break
+		}
+		mark := buf.Len()
+		if !first {
+			buf.WriteByte(',')
+		}
+		start := buf.Len()
+		ctx := acquireContext(e.opts)
+		ctx.pushPath(PathSegment{Kind: SegmentIndex, Index: i})
+		err := e.encode(buf, reflect.ValueOf(v), ctx)
+		if err == nil {
+			err = ctx.checkSize(buf)
+		}
+		errs = append(errs, ctx.errs...)
+		releaseContext(ctx)
+		if err == errOmit {
+			buf.Truncate(mark)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("groupjson: element %d: %w", i, err)
+		}
+		e.sample(v, buf.Bytes()[start:])
+		first = false
+		count++
+		if err := writeFlush(w, buf); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte(']')
+	ctx := acquireContext(e.opts)
+	defer releaseContext(ctx)
+	if err := e.closeTop(buf, count, ctx); err != nil {
+		return err
+	}
+	if err := writeFlush(w, buf); err != nil {
+		return err
+	}
+	return multiError(slices.Concat(errs, ctx.errs))
+}
+
+// writeFlush 将 buf 写入 w 并清空，w 支持时随即刷新。
+func writeFlush(w io.Writer, buf *bytes.Buffer) error {
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	buf.Reset()
+	switch f := w.(type) {
+	case interface{ Flush() error }:
+		return f.Flush()
+	case interface{ Flush() }:
+		f.Flush()
+	}
+	return nil
+}
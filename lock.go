@@ -0,0 +1,34 @@
+package groupjson
+
+import (
+	"reflect"
+	"sync"
+)
+
+var lockerType = reflect.TypeFor[sync.Locker]()
+
+// isLockType 判断字段类型是否为锁或禁止拷贝的标记类型（sync.Mutex、sync.RWMutex、noCopy、
+// 以及 sync.WaitGroup、sync.Once、atomic.Int64 等内部含锁且无导出字段的类型）。
+// 这类字段没有可输出的数据，schema 构建时直接跳过，判定规则与 go vet 的 copylocks 检查一致。
+func isLockType(t reflect.Type) bool {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || t == syncMapType {
+		return false
+	}
+	if reflect.PointerTo(t).Implements(lockerType) {
+		return true
+	}
+	hasLock := false
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.IsExported() {
+			return false
+		}
+		if sf.Type.Kind() != reflect.Pointer && isLockType(sf.Type) {
+			hasLock = true
+		}
+	}
+	return hasLock
+}
@@ -0,0 +1,109 @@
+package groupjson
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// MaskFunc 将字段值的文本形式转换为部分脱敏后的文本。
+type MaskFunc func(s string) string
+
+// masks 已注册的脱敏函数，key 为名称。
+var masks cache[string, MaskFunc]
+
+func init() {
+	RegisterMask("email", MaskEmail)
+	RegisterMask("phone", MaskLast4)
+	RegisterMask("last4", MaskLast4)
+	RegisterMask("hash", MaskHash)
+}
+
+// RegisterMask 注册（或覆盖）名为 name 的脱敏函数，供标签 mask=name 引用。
+func RegisterMask(name string, fn MaskFunc) {
+	masks.Store(name, fn)
+}
+
+func lookupMask(name string) (MaskFunc, bool) {
+	return masks.Load(name)
+}
+
+// MaskEmail 保留首字符与域名，如 alice@example.com -> a****@example.com。
+func MaskEmail(s string) string {
+	at := strings.LastIndexByte(s, '@')
+	if at <= 0 {
+		return strings.Repeat("*", len(s))
+	}
+	return s[:1] + strings.Repeat("*", at-1) + s[at:]
+}
+
+// MaskLast4 仅保留最后 4 个字符，如 13800001234 -> *******1234。
+func MaskLast4(s string) string {
+	r := []rune(s)
+	if len(r) <= 4 {
+		return strings.Repeat("*", len(r))
+	}
+	return strings.Repeat("*", len(r)-4) + string(r[len(r)-4:])
+}
+
+// MaskHash 输出 SHA-256 十六进制摘要，可用于关联但不可还原。
+func MaskHash(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// masked 判断字段在本次请求中是否需要脱敏：请求命中 unmask 分组时输出原值。
+func (e Encoder) masked(f *fieldInfo) bool {
+	if f.mask == "" {
+		return false
+	}
+	for _, g := range e.opts.Groups {
+		if slices.Contains(f.unmask, g) {
+			return false
+		}
+	}
+	for _, view := range e.opts.MergedViews {
+		for _, g := range view {
+			if slices.Contains(f.unmask, g) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// writeMasked 以脱敏后的字符串写入字段值；nil 指针/接口仍输出 null。
+func (e Encoder) writeMasked(buf *bytes.Buffer, v reflect.Value, name string) error {
+	s, ok, err := maskValue(v, name)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		buf.WriteString("null")
+		return nil
+	}
+	e.writeString(buf, s)
+	return nil
+}
+
+// maskValue 返回字段值脱敏后的文本；值为 nil 指针/接口时 ok 为 false。
+func maskValue(v reflect.Value, name string) (string, bool, error) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return "", false, nil
+		}
+		v = v.Elem()
+	}
+	fn, ok := lookupMask(name)
+	if !ok {
+		return "", false, fmt.Errorf("%w: %q", ErrUnknownMask, name)
+	}
+	if v.Kind() == reflect.String {
+		return fn(v.String()), true, nil
+	}
+	return fn(fmt.Sprint(v.Interface())), true, nil
+}
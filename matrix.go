@@ -0,0 +1,107 @@
+package groupjson
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Matrix 描述某个结构体类型在所有分组组合与两种模式下的字段可见性真值表。
+type Matrix struct {
+	// Type 结构体类型名
+	Type string `json:"type"`
+	// Groups 该类型标签中出现的全部分组（已排序）
+	Groups []string `json:"groups"`
+	// Fields 顶层字段的 JSON 键名，按声明顺序
+	Fields []string `json:"fields"`
+	// Rows 每种 (模式, 分组子集) 组合下的可见性
+	Rows []MatrixRow `json:"rows"`
+}
+
+// MatrixRow 真值表中的一行。
+type MatrixRow struct {
+	// Mode 分组匹配模式
+	Mode GroupMode `json:"mode"`
+	// Groups 本行请求的分组子集
+	Groups []string `json:"requested"`
+	// Visible 与 Matrix.Fields 一一对应，表示字段是否输出
+	Visible []bool `json:"visible"`
+}
+
+// VisibilityMatrix 枚举 v 的类型上出现的全部分组子集（含空集）与 OR/AND 两种模式，
+// 生成顶层字段可见性真值表。行数为 2^分组数 * 2，仅适合分组数量有限的类型。
+func (e Encoder) VisibilityMatrix(v any) (Matrix, error) {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return Matrix{}, ErrInvalidType
+	}
+	sch := e.schemaFor(t)
+
+	set := map[string]struct{}{}
+	m := Matrix{Type: t.String()}
+	for _, f := range sch.fields {
+		m.Fields = append(m.Fields, f.jsonName)
+		for _, entry := range f.groups {
+			for _, g := range strings.Split(entry, "+") {
+				if g != "" && g != NeverGroup {
+					set[g] = struct{}{}
+				}
+			}
+		}
+	}
+	for g := range set {
+		m.Groups = append(m.Groups, g)
+	}
+	sort.Strings(m.Groups)
+
+	for _, mode := range []GroupMode{ModeOr, ModeAnd} {
+		for mask := 0; mask < 1<<len(m.Groups); mask++ {
+			var groups []string
+			for i, g := range m.Groups {
+				if mask&(1<<i) != 0 {
+					groups = append(groups, g)
+				}
+			}
+			sub := e.WithGroups(groups...).WithGroupMode(mode)
+			row := MatrixRow{Mode: mode, Groups: groups, Visible: make([]bool, len(sch.fields))}
+			for i, f := range sch.fields {
+				row.Visible[i] = !f.never && (len(groups) == 0 || sub.includeField(f.groups))
+			}
+			m.Rows = append(m.Rows, row)
+		}
+	}
+	return m, nil
+}
+
+// Markdown 将真值表渲染为 Markdown 表格。
+func (m Matrix) Markdown() string {
+	var sb strings.Builder
+	sb.WriteString("| mode | groups |")
+	for _, f := range m.Fields {
+		sb.WriteString(" " + f + " |")
+	}
+	sb.WriteString("\n|---|---|")
+	for range m.Fields {
+		sb.WriteString("---|")
+	}
+	sb.WriteByte('\n')
+	for _, r := range m.Rows {
+		groups := strings.Join(r.Groups, ",")
+		if groups == "" {
+			groups = "(none)"
+		}
+		sb.WriteString("| " + r.Mode.String() + " | " + groups + " |")
+		for _, vis := range r.Visible {
+			if vis {
+				sb.WriteString(" ✓ |")
+			} else {
+				sb.WriteString("   |")
+			}
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
@@ -0,0 +1,14 @@
+package groupjson
+
+// MarshalMerged 输出多个分组视图的并集：字段在任一视图中可见即输出，
+// 脱敏字段只要任一视图可见原值即输出原值。适合“基础视图 + 特性开关附加字段”的响应，
+// 无需为组合场景新增分组标签。每个视图按当前 GroupMode 单独求值，结果保持字段声明顺序。
+// 未给出视图时等同于不指定分组的 Marshal。
+func (e Encoder) MarshalMerged(v any, groupSets ...[]string) ([]byte, error) {
+	if len(groupSets) == 0 {
+		return e.MarshalGroups(v)
+	}
+	e.opts.Groups = groupSets[0]
+	e.opts.MergedViews = groupSets[1:]
+	return e.Marshal(v)
+}
@@ -0,0 +1,99 @@
+package groupjson
+
+import (
+	"bytes"
+	"reflect"
+)
+
+// MultiMarshal 构建由多个区块组成的 JSON 对象，每个区块使用各自的分组筛选，
+// 适合仪表盘等一次返回多种视图的接口：
+//
+//	out, err := groupjson.NewMultiMarshal().
+//		Add("user", user, "public").
+//		Add("audit", log, "admin").
+//		Marshal()
+type MultiMarshal struct {
+	// enc 共享的编码配置，区块仅替换分组
+	enc Encoder
+	// sections 按添加顺序排列的区块
+	sections []multiSection
+}
+
+// multiSection 一个顶层区块。
+type multiSection struct {
+	key    string
+	value  any
+	groups []string
+}
+
+// NewMultiMarshal 使用默认配置创建 MultiMarshal。
+func NewMultiMarshal() *MultiMarshal {
+	return NewEncoder().Multi()
+}
+
+// Multi 基于当前配置创建 MultiMarshal，Envelope/TopLevelKey 包裹整个对象。
+func (e Encoder) Multi() *MultiMarshal {
+	return &MultiMarshal{enc: e}
+}
+
+// Add 添加以 key 为键、按 groups 筛选的区块；key 已存在时替换原区块并保留其位置。
+func (m *MultiMarshal) Add(key string, v any, groups ...string) *MultiMarshal {
+	s := multiSection{key: key, value: v, groups: groups}
+	for i := range m.sections {
+		if m.sections[i].key == key {
+			m.sections[i] = s
+			return m
+		}
+	}
+	m.sections = append(m.sections, s)
+	return m
+}
+
+// Marshal 按添加顺序输出全部区块。非 ErrorFail 的 ErrorPolicy 下跳过或置空了部分值时，
+// 同时返回完整输出与汇总这些问题的 *MultiError，路径以区块的键开头。
+func (m *MultiMarshal) Marshal() ([]byte, error) {
+	var buf bytes.Buffer
+	e := m.enc
+	e.openTop(&buf)
+	buf.WriteByte('{')
+	first := true
+	var errs []*EncodeError
+	for _, s := range m.sections {
+		opts := e.opts
+		opts.Groups = s.groups
+		se := e
+		se.opts = opts
+		if err := se.checkTop(); err != nil {
+			return nil, err
+		}
+		ctx := acquireContext(opts)
+		ctx.pushPath(PathSegment{Kind: SegmentKey, Name: s.key})
+		mark, wasFirst := buf.Len(), first
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		e.writeString(&buf, s.key)
+		buf.WriteByte(':')
+		err := se.encode(&buf, reflect.ValueOf(s.value), ctx)
+		errs = append(errs, ctx.errs...)
+		releaseContext(ctx)
+		if err != nil {
+			if err != errOmit {
+				return nil, err
+			}
+			buf.Truncate(mark)
+			first = wasFirst
+		}
+	}
+	buf.WriteByte('}')
+	ctx := acquireContext(e.opts)
+	defer releaseContext(ctx)
+	ctx.errs = errs
+	err := e.finishTop(&buf, reflect.Value{}, ctx, nil)
+	if err != nil && !isMultiError(err) {
+		return nil, err
+	}
+	e.sample(m, buf.Bytes())
+	return buf.Bytes(), err
+}
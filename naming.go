@@ -0,0 +1,97 @@
+package groupjson
+
+import (
+	"strings"
+	"unicode"
+)
+
+// NamingStrategy 为未在 json 标签中显式命名的字段生成键名。
+// Name 参与 schema 缓存键，不同的转换函数必须使用不同的 Name。
+type NamingStrategy struct {
+	// Name 策略名称，唯一标识该策略
+	Name string
+	// Fn 由 Go 字段名生成 JSON 键名
+	Fn func(field string) string
+}
+
+// 内置命名策略；零值 NamingStrategy 表示沿用 Go 字段名（与 encoding/json 一致）。
+var (
+	SnakeCase = NamingStrategy{Name: "snake", Fn: func(s string) string { return joinWords(s, '_') }}
+	KebabCase = NamingStrategy{Name: "kebab", Fn: func(s string) string { return joinWords(s, '-') }}
+	CamelCase = NamingStrategy{Name: "camel", Fn: toLowerCamel}
+)
+
+// WithNamingStrategy 设置未显式命名字段的键名转换策略，如 SnakeCase 将 UserID 输出为 user_id。
+func (e Encoder) WithNamingStrategy(s NamingStrategy) Encoder { e.opts.Naming = s; return e }
+
+// apply 对字段名应用策略，零值策略原样返回。
+func (s NamingStrategy) apply(field string) string {
+	if s.Fn == nil {
+		return field
+	}
+	return s.Fn(field)
+}
+
+// splitWords 按大小写边界拆分标识符，连续大写视为缩写：HTTPServerID -> HTTP, Server, ID。
+func splitWords(s string) []string {
+	r := []rune(s)
+	var words []string
+	start := 0
+	for i := 1; i < len(r); i++ {
+		prev, cur := r[i-1], r[i]
+		boundary := false
+		switch {
+		case cur == '_' || cur == '-':
+			words = append(words, string(r[start:i]))
+			start = i + 1
+			continue
+		case unicode.IsLower(prev) && unicode.IsUpper(cur):
+			boundary = true
+		case unicode.IsUpper(prev) && unicode.IsUpper(cur) && i+1 < len(r) && unicode.IsLower(r[i+1]):
+			boundary = true
+		case unicode.IsDigit(prev) != unicode.IsDigit(cur) && unicode.IsUpper(cur):
+			boundary = true
+		}
+		if boundary && i > start {
+			words = append(words, string(r[start:i]))
+			start = i
+		}
+	}
+	if start < len(r) {
+		words = append(words, string(r[start:]))
+	}
+	return words
+}
+
+func joinWords(s string, sep byte) string {
+	words := splitWords(s)
+	var sb strings.Builder
+	for i, w := range words {
+		if w == "" {
+			continue
+		}
+		if i > 0 && sb.Len() > 0 {
+			sb.WriteByte(sep)
+		}
+		sb.WriteString(strings.ToLower(w))
+	}
+	return sb.String()
+}
+
+func toLowerCamel(s string) string {
+	words := splitWords(s)
+	var sb strings.Builder
+	for i, w := range words {
+		if w == "" {
+			continue
+		}
+		if i == 0 {
+			sb.WriteString(strings.ToLower(w))
+			continue
+		}
+		r := []rune(strings.ToLower(w))
+		r[0] = unicode.ToUpper(r[0])
+		sb.WriteString(string(r))
+	}
+	return sb.String()
+}
@@ -0,0 +1,92 @@
+package groupjson
+
+import (
+	"mime"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Profiles 将 Accept 头中的视图名映射到分组，视图名取自媒体类型的 view 参数
+// 或 RFC 6906 的 profile 参数（空格分隔的多个 URI，逐个匹配），如：
+//
+//	Accept: application/json;view=compact
+//	Accept: application/json;profile="https://example.com/profiles/compact"
+type Profiles map[string][]string
+
+// Negotiate 按 q 值从高到低选择第一个可识别的视图，返回视图名与对应分组。
+// 仅考虑 JSON 相关的媒体范围（application/json、*+json、application/*、*/*），q=0 的范围被忽略。
+func (p Profiles) Negotiate(accept string) (view string, groups []string, ok bool) {
+	type candidate struct {
+		q     float64
+		names []string
+	}
+	var cands []candidate
+	for _, r := range splitAccept(accept) {
+		mt, params, err := mime.ParseMediaType(r)
+		if err != nil || !jsonMediaRange(mt) {
+			continue
+		}
+		q := 1.0
+		if s, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(s, 64); err != nil || q <= 0 {
+				continue
+			}
+		}
+		var names []string
+		if v := params["view"]; v != "" {
+			names = append(names, v)
+		}
+		names = append(names, strings.Fields(params["profile"])...)
+		if len(names) > 0 {
+			cands = append(cands, candidate{q: q, names: names})
+		}
+	}
+	slices.SortStableFunc(cands, func(a, b candidate) int {
+		switch {
+		case a.q > b.q:
+			return -1
+		case a.q < b.q:
+			return 1
+		}
+		return 0
+	})
+	for _, c := range cands {
+		for _, name := range c.names {
+			if g, ok := p[name]; ok {
+				return name, g, true
+			}
+		}
+	}
+	return "", nil, false
+}
+
+// jsonMediaRange 判断媒体范围是否可能接受 JSON 响应。
+func jsonMediaRange(mt string) bool {
+	return mt == "application/json" || mt == "application/*" || mt == "*/*" || strings.HasSuffix(mt, "+json")
+}
+
+// splitAccept 按逗号拆分 Accept 头，忽略引号内的逗号。
+func splitAccept(s string) []string {
+	var out []string
+	start, quoted := 0, false
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			quoted = !quoted
+		case '\\':
+			if quoted {
+				i++
+			}
+		case ',':
+			if !quoted {
+				out = append(out, strings.TrimSpace(s[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	if rest := strings.TrimSpace(s[start:]); rest != "" {
+		out = append(out, rest)
+	}
+	return out
+}
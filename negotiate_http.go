@@ -0,0 +1,30 @@
+//go:build !tinygo
+
+package groupjson
+
+import (
+	"net/http"
+	"slices"
+)
+
+// Middleware 返回按 Accept 头协商视图的 http 中间件，协商成功时将分组写入请求上下文，
+// 处理函数通过 MarshalContext/EncodeContext 输出，并为响应添加 Vary: Accept。
+//
+// Accept 头由客户端控制，视图只能收窄而不能扩大可见范围：若上游（如鉴权中间件）
+// 已通过 ContextWithGroups 设置分组，结果取两者交集，因此应放在鉴权中间件之后。
+func (p Profiles) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept")
+		_, groups, ok := p.Negotiate(r.Header.Get("Accept"))
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if granted, has := GroupsFromContext(r.Context()); has {
+			groups = slices.DeleteFunc(slices.Clone(groups), func(g string) bool {
+				return !slices.Contains(granted, g)
+			})
+		}
+		next.ServeHTTP(w, r.WithContext(ContextWithGroups(r.Context(), groups...)))
+	})
+}
@@ -0,0 +1,45 @@
+package groupjson
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// NullAsTagKey 指定字段为 nil 时输出的替代 JSON 字面量，
+// 如 nullas:"\"\"" 输出空字符串、nullas:"0" 输出 0，供无法处理 null 的下游使用。
+const NullAsTagKey = "nullas"
+
+// parseNullAs 读取 nullas 标签，返回字面量字节；标签不是合法 JSON 时返回 ErrInvalidNullAs。
+func parseNullAs(sf reflect.StructField) ([]byte, error) {
+	lit, ok := sf.Tag.Lookup(NullAsTagKey)
+	if !ok {
+		return nil, nil
+	}
+	if !json.Valid([]byte(lit)) {
+		return nil, ErrInvalidNullAs
+	}
+	return []byte(lit), nil
+}
+
+// isNilValue 判断值是否会被编码为 null。
+func isNilValue(v reflect.Value) bool {
+	if !v.IsValid() {
+		return true
+	}
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice:
+		return v.IsNil()
+	}
+	return false
+}
+
+// isStructValue 判断值（解引用非 nil 指针/接口后）是否为结构体。
+func isStructValue(v reflect.Value) bool {
+	for v.IsValid() && (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return false
+		}
+		v = v.Elem()
+	}
+	return v.IsValid() && v.Kind() == reflect.Struct
+}
@@ -0,0 +1,86 @@
+package groupjson
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// View 描述生成文档或类型定义（OpenAPI、TypeScript）时的一个分组视图。
+type View struct {
+	// Name 视图名，首字母大写后追加在类型名之后，如 "public" -> UserPublic
+	Name string
+	// Groups 该视图使用的分组
+	Groups []string
+}
+
+// OpenAPIComponents 使用默认配置生成 OpenAPI 组件，见 Encoder.OpenAPIComponents。
+func OpenAPIComponents(types []any, views ...View) ([]byte, error) {
+	return NewEncoder().OpenAPIComponents(types, views...)
+}
+
+// OpenAPIComponents 为每个（类型, 视图）组合生成 OpenAPI 3.1 组件 schema，如 UserPublic、UserAdmin，
+// 输出 {"components":{"schemas":{...}}} 片段，可合并进现有的 API 文档。
+// 嵌套类型同样按视图生成独立组件并以 $ref 引用。未指定视图时，为类型标签中出现的每个分组各生成一个视图。
+// 各视图的分组会替换 e 的分组，其余配置（TagKey、命名策略等）沿用 e。
+func (e Encoder) OpenAPIComponents(types []any, views ...View) ([]byte, error) {
+	ts, views, err := e.resolveViews(types, views)
+	if err != nil {
+		return nil, err
+	}
+
+	g := newSchemaGen("#/components/schemas/")
+	for _, v := range views {
+		g.view(e.WithGroups(v.Groups...), exportedName(v.Name))
+		for _, t := range ts {
+			g.structDef(t)
+		}
+	}
+	return json.Marshal(map[string]any{"components": map[string]any{"schemas": g.defs}})
+}
+
+// resolveViews 校验 types 均为结构体（或其指针），并在未指定视图时为类型标签中出现的每个分组各生成一个视图（按名称排序）。
+func (e Encoder) resolveViews(types []any, views []View) ([]reflect.Type, []View, error) {
+	var ts []reflect.Type
+	for _, v := range types {
+		t := reflect.TypeOf(v)
+		for t != nil && t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if t == nil || t.Kind() != reflect.Struct {
+			return nil, nil, ErrInvalidType
+		}
+		ts = append(ts, t)
+	}
+	if len(views) == 0 {
+		groups := map[string]struct{}{}
+		for _, v := range types {
+			m, err := e.VisibilityMatrix(v)
+			if err != nil {
+				return nil, nil, err
+			}
+			for _, g := range m.Groups {
+				groups[g] = struct{}{}
+			}
+		}
+		for g := range groups {
+			views = append(views, View{Name: g, Groups: []string{g}})
+		}
+		slices.SortFunc(views, func(a, b View) int { return strings.Compare(a.Name, b.Name) })
+	}
+	return ts, views, nil
+}
+
+// exportedName 将视图名转换为组件名后缀：按非字母数字字符分词，每段首字母大写，如 "read-only" -> ReadOnly。
+func exportedName(s string) string {
+	var sb strings.Builder
+	for _, part := range strings.FieldsFunc(s, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }) {
+		r, n := utf8.DecodeRuneInString(part)
+		sb.WriteRune(unicode.ToUpper(r))
+		sb.WriteString(part[n:])
+	}
+	return sb.String()
+}
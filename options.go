@@ -1,5 +1,13 @@
 package groupjson
 
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+)
+
 // GroupMode 定义分组筛选逻辑。
 type GroupMode int
 
@@ -10,27 +18,200 @@ const (
 	ModeAnd
 )
 
+// DepthPolicy 定义超过 MaxDepth 时的处理方式。
+type DepthPolicy int
+
+const (
+	// DepthError 返回 ErrMaxDepth（默认）。
+	DepthError DepthPolicy = iota
+	// DepthTruncateNull 将超深的对象/数组输出为 null。
+	DepthTruncateNull
+	// DepthTruncateOmit 省略超深的字段、map 项或数组元素。
+	DepthTruncateOmit
+)
+
+// ErrorPolicy 定义遇到无法编码的值（chan、func 等不支持的类型，NaN/Inf 浮点数，
+// 返回错误的自定义 Marshaler）时的处理方式。
+type ErrorPolicy int
+
+const (
+	// ErrorFail 中止编码并返回错误（默认）。
+	ErrorFail ErrorPolicy = iota
+	// ErrorSkipField 省略该字段、数组元素或 map 项，继续编码其余部分。
+	ErrorSkipField
+	// ErrorEmitNull 以 null 代替该值，继续编码其余部分。
+	ErrorEmitNull
+)
+
+// FloatSpecialPolicy 定义 NaN 与 ±Inf 浮点数的输出方式。
+type FloatSpecialPolicy int
+
+const (
+	// FloatSpecialError 返回 *json.UnsupportedValueError（默认，与 encoding/json 一致）。
+	FloatSpecialError FloatSpecialPolicy = iota
+	// FloatSpecialAsNull 输出 null。
+	FloatSpecialAsNull
+	// FloatSpecialAsString 输出字符串 "NaN"、"Infinity" 或 "-Infinity"，JavaScript 的 Number() 与 Python 的 float() 均可解析。
+	FloatSpecialAsString
+)
+
+// NilCollectionPolicy 定义 nil 切片与 nil map 的输出方式。
+type NilCollectionPolicy int
+
+const (
+	// NilAsNull 与 encoding/json 一致输出 null（默认）。
+	NilAsNull NilCollectionPolicy = iota
+	// NilAsEmpty nil 切片输出 []、nil map 输出 {}、nil []byte 输出 ""。
+	NilAsEmpty
+)
+
+// CycleHandling 定义遇到循环引用时的处理方式。
+type CycleHandling int
+
+const (
+	// CycleError 返回 ErrCircularReference（默认）。
+	CycleError CycleHandling = iota
+	// CycleNull 将回指输出为 null。
+	CycleNull
+	// CycleOmit 省略回指所在的字段、map 项或数组元素。
+	CycleOmit
+	// CycleRef 输出 {"$ref":"#/..."}，值为首次出现位置的 JSON Pointer。
+	CycleRef
+)
+
+// GroupMarshaler 由需要参与分组筛选的自定义类型实现。
+// 编码器优先于 json.Marshaler 调用它，并传入本次请求的分组与模式。
+type GroupMarshaler interface {
+	MarshalJSONGroups(groups []string, mode GroupMode) ([]byte, error)
+}
+
+// String 返回模式名称 "or" 或 "and"。
+func (m GroupMode) String() string {
+	if m == ModeAnd {
+		return "and"
+	}
+	return "or"
+}
+
+// ParseMode 解析分组模式名称，不区分大小写并忽略首尾空白：
+// "or"/"any" 为 ModeOr，"and"/"all" 为 ModeAnd，空串返回默认的 ModeOr。
+// 其余取值返回 ErrInvalidMode，便于统一解析 query 参数或请求头。
+func ParseMode(s string) (GroupMode, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "or", "any":
+		return ModeOr, nil
+	case "and", "all":
+		return ModeAnd, nil
+	}
+	return ModeOr, fmt.Errorf("%w: %q", ErrInvalidMode, s)
+}
+
 const (
 	DefaultTagKey   = "groups"
 	DefaultMaxDepth = 32
+	// DefaultMaxChannelItems 开启 channel 编码时单个 channel 默认最多读取的元素数。
+	DefaultMaxChannelItems = 100000
+	// NeverGroup 分组标签中的保留值，如 groups:"-"，标记字段永不输出，
+	// 即便请求了同一标签中的其它分组，适用于密码哈希等敏感字段。
+	NeverGroup = "-"
 )
 
 // Options 控制序列化行为。
 type Options struct {
 	// Groups 需要包含的分组名称列表；为空表示不输出任何分组受控字段。
 	Groups []string
+	// MergedViews 与 Groups 合并输出的其它视图（每项为一组分组），见 Encoder.MarshalMerged。
+	MergedViews [][]string
+	// StrictGroups 请求的分组必须已通过 RegisterGroups 注册，见 Encoder.WithStrictGroups。
+	StrictGroups bool
 	// Mode 分组匹配模式：ModeOr（任一命中）或 ModeAnd（全部命中）。
 	Mode GroupMode
 	// TagKey 字段上用于声明分组的结构体标签键名，默认 "groups"。
 	TagKey string
+	// TagKeyFallback 迁移期的旧标签键，字段缺少 TagKey 标签时使用。
+	TagKeyFallback string
+	// Naming 未显式命名字段的键名策略，零值沿用 Go 字段名。
+	Naming NamingStrategy
 	// TopLevelKey 非空时，最终结果以该键包裹为顶层对象。
 	TopLevelKey string
+	// Envelope 顶层响应信封，设置后取代 TopLevelKey，见 Encoder.WithEnvelope。
+	Envelope *Envelope
 	// MaxDepth 最大递归深度（含根层，最小为 1），防止深嵌套或环导致资源耗尽。
 	MaxDepth int
+	// MaxBytes 单次编码的输出上限（字节），<= 0 表示不限制，见 Encoder.WithMaxBytes。
+	MaxBytes int
+	// Backend 可选的编码后端，与分组无关的子树交给它编码，见 Encoder.WithBackend。
+	Backend Backend
+	// BufferSize 输出缓冲的初始容量提示（字节），<= 0 表示不提示，见 Encoder.WithBufferSize。
+	BufferSize int
+	// TypeDepths 按类型限制自身嵌套层数，见 Encoder.WithMaxDepthFor。
+	TypeDepths map[reflect.Type]int
+	// DepthPolicy 超过 MaxDepth 时的处理方式，默认 DepthError。
+	DepthPolicy DepthPolicy
+	// CycleHandling 遇到循环引用时的处理方式，默认 CycleError。
+	CycleHandling CycleHandling
+	// FloatFormat 浮点数格式（同 strconv.FormatFloat），0 表示默认的 'g'，见 Encoder.WithFloatFormat。
+	FloatFormat byte
+	// FloatPrec 浮点数精度，仅在设置了 FloatFormat 时生效，-1 表示最短表示。
+	FloatPrec int
+	// FloatSpecials NaN 与 ±Inf 的输出方式，默认 FloatSpecialError，见 Encoder.WithFloatSpecials。
+	FloatSpecials FloatSpecialPolicy
+	// ErrorPolicy 遇到无法编码的值时的处理方式，默认 ErrorFail，见 Encoder.WithErrorPolicy。
+	ErrorPolicy ErrorPolicy
 	// EscapeHTML 是否对 HTML 字符进行转义，保持与 encoding/json 行为一致可关闭。
 	EscapeHTML bool
 	// SortKeys 是否对 map 键进行排序（仅为测试/可读性，默认关闭）。
 	SortKeys bool
+	// SortFields 是否按 JSON 键名对结构体字段排序，见 Encoder.WithSortFields。
+	SortFields bool
+	// NilCollections nil 切片与 nil map 的输出方式，默认 NilAsNull。
+	NilCollections NilCollectionPolicy
+	// DeepOmitEmpty 筛选后输出为 {} 的结构体字段整体省略，见 Encoder.WithDeepOmitEmpty。
+	DeepOmitEmpty bool
+	// KeyTables 结构体切片以键表形式输出，见 Encoder.WithKeyTables。
+	KeyTables bool
+	// TypeDiscriminator 接口类型字段输出具体类型名时使用的键，见 Encoder.WithTypeDiscriminator。
+	TypeDiscriminator string
+	// PinnedFields 每个对象中最先输出的 JSON 键，见 Encoder.WithPinnedFields。
+	PinnedFields []string
+	// Int64AsString 超出 JavaScript 安全整数范围（±(2^53-1)）的整数以 JSON 字符串输出。
+	Int64AsString bool
+	// ChannelEncoding 是否将可接收的 channel 读取至关闭并输出为数组，见 Encoder.WithChannelEncoding。
+	ChannelEncoding bool
+	// MaxChannelItems 单个 channel 最多读取的元素数，<= 0 时使用 DefaultMaxChannelItems。
+	MaxChannelItems int
+	// SampleRate 输出采样比例（0~1），见 Encoder.WithSampler。
+	SampleRate float64
+	// SampleSink 接收采样输出的回调，为空时不采样。
+	SampleSink func(meta SampleMeta, body []byte)
+	// GroupResolver 非空时，MarshalContext/EncodeContext 通过它从上下文解析分组。
+	GroupResolver func(ctx context.Context) []string
+	// AllowFields 强制输出的字段路径规则（glob 风格），见 Encoder.WithAllowFields。
+	AllowFields []string
+	// DenyFields 强制隐藏的字段路径规则（glob 风格），优先级最高，见 Encoder.WithDenyFields。
+	DenyFields []string
+	// FilterMarshalers 是否按声明类型的 schema 过滤 json.Marshaler 输出的对象键。
+	FilterMarshalers bool
+	// ScratchArena 实验性：编码上下文的临时数据在编码结束时整体归还复用池，
+	// 而非每次重新分配，用于降低高分配场景下的 GC 压力。
+	ScratchArena bool
+	// Trace 字段取舍追踪回调，见 Encoder.WithTrace。
+	Trace func(TraceEvent)
+	// DebugLogger GROUPJSON_DEBUG=1 时调试输出使用的 logger，为空则使用 slog.Default()。
+	DebugLogger *slog.Logger
+	// FieldTransformer 字段转换钩子，见 Encoder.WithFieldTransformer。
+	FieldTransformer FieldTransformer
+	// FieldPredicates 按路径附加的字段条件，见 Encoder.WithFieldPredicate。
+	FieldPredicates []FieldPredicate
+	// AssetHook 资源字段钩子，见 Encoder.WithAssetHook。
+	AssetHook AssetHook
+	// TupleTypes 以 JSON 数组（元组）形式编码的结构体类型，见 Encoder.WithStructAsArray。
+	TupleTypes []reflect.Type
+	// Invariants 结构体级不变量，见 Encoder.WithInvariant。
+	Invariants []Invariant
+
+	// allowPatterns、denyPatterns AllowFields 与 DenyFields 的编译结果
+	allowPatterns, denyPatterns []*fieldPattern
 }
 
 // DefaultOptions 返回默认选项。
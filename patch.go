@@ -0,0 +1,78 @@
+package groupjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+)
+
+// MergePatch 使用默认配置按 groups 生成 old 到 new 的合并补丁，见 Encoder.MergePatch。
+func MergePatch(old, new any, groups ...string) ([]byte, error) {
+	return NewEncoder().WithGroups(groups...).MergePatch(old, new)
+}
+
+// MergePatch 生成 RFC 7386 JSON Merge Patch：将 old 的分组视图变为 new 的分组视图所需的最小补丁，
+// 只涉及当前分组可见的字段，适合审计日志与增量同步接口。两者相同时返回 {}。
+// 按 RFC 7386 的约定，补丁中的 null 表示删除该键，因此 new 中取值为 null 的键与缺失的键无法区分。
+func (e Encoder) MergePatch(old, new any) ([]byte, error) {
+	a, err := e.decodeView(old)
+	if err != nil {
+		return nil, err
+	}
+	b, err := e.decodeView(new)
+	if err != nil {
+		return nil, err
+	}
+	var patch any = map[string]any{}
+	if !reflect.DeepEqual(a, b) {
+		patch = mergePatch(a, b)
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(e.opts.EscapeHTML)
+	if err := enc.Encode(patch); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// decodeView 编码 v 的当前视图并解析为通用值，数字保留原始文本。
+func (e Encoder) decodeView(v any) (any, error) {
+	e.opts.TopLevelKey, e.opts.Envelope = "", nil
+	b, err := e.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var doc any
+	err = dec.Decode(&doc)
+	return doc, err
+}
+
+// mergePatch 计算 a 到 b 的补丁：对象逐键比较，删除的键为 null；其余情况整体替换为 b。
+func mergePatch(a, b any) any {
+	am, aok := a.(map[string]any)
+	bm, bok := b.(map[string]any)
+	if !aok || !bok {
+		return b
+	}
+	patch := map[string]any{}
+	for k := range am {
+		if _, ok := bm[k]; !ok {
+			patch[k] = nil
+		}
+	}
+	for k, bv := range bm {
+		av, ok := am[k]
+		if ok && reflect.DeepEqual(av, bv) {
+			continue
+		}
+		if !ok {
+			patch[k] = bv
+			continue
+		}
+		patch[k] = mergePatch(av, bv)
+	}
+	return patch
+}
@@ -0,0 +1,71 @@
+package groupjson
+
+import (
+	"strconv"
+	"strings"
+)
+
+// SegmentKind 路径段类型。
+type SegmentKind uint8
+
+const (
+	// SegmentField 结构体字段，Name 为 JSON 键名
+	SegmentField SegmentKind = iota
+	// SegmentKey map 键，Name 为键值
+	SegmentKey
+	// SegmentIndex 切片/数组下标，Index 为下标
+	SegmentIndex
+)
+
+// PathSegment 路径中的单个段。
+type PathSegment struct {
+	Kind  SegmentKind
+	Name  string
+	Index int
+}
+
+// Path 从根值到当前值的结构化路径，统一用于错误、钩子与规则匹配，
+// 调用方可直接按段处理，无需解析 "users[0].email" 这样的字符串。
+type Path []PathSegment
+
+// String 返回形如 users[0].email 的文本表示；含 "." 或 "[" 的 map 键写作 ["a.b"]。
+func (p Path) String() string {
+	var sb strings.Builder
+	for i, s := range p {
+		switch {
+		case s.Kind == SegmentIndex:
+			sb.WriteByte('[')
+			sb.WriteString(strconv.Itoa(s.Index))
+			sb.WriteByte(']')
+		case s.Kind == SegmentKey && strings.ContainsAny(s.Name, ".[]"):
+			sb.WriteByte('[')
+			sb.WriteString(strconv.Quote(s.Name))
+			sb.WriteByte(']')
+		default:
+			if i > 0 {
+				sb.WriteByte('.')
+			}
+			sb.WriteString(s.Name)
+		}
+	}
+	return sb.String()
+}
+
+// JSONPointer 返回 RFC 6901 形式的 JSON Pointer，如 /users/0/email；根路径为空串。
+func (p Path) JSONPointer() string {
+	var sb strings.Builder
+	for _, s := range p {
+		sb.WriteByte('/')
+		if s.Kind == SegmentIndex {
+			sb.WriteString(strconv.Itoa(s.Index))
+			continue
+		}
+		sb.WriteString(pointerEscaper.Replace(s.Name))
+	}
+	return sb.String()
+}
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// Clone 返回路径副本，便于在钩子中长期持有。
+func (p Path) Clone() Path { return append(Path(nil), p...) }
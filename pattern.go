@@ -0,0 +1,132 @@
+package groupjson
+
+import (
+	"strconv"
+	"strings"
+)
+
+// segKind 模式段类型。
+type segKind uint8
+
+const (
+	// segLiteral 精确匹配键名
+	segLiteral segKind = iota
+	// segAny "*"，匹配任意单段（键名或下标）
+	segAny
+	// segAnyDeep "**"，匹配零个或多个段
+	segAnyDeep
+	// segIndex "[i]"，精确匹配下标
+	segIndex
+	// segAnyIndex "[*]"，匹配任意下标
+	segAnyIndex
+)
+
+// patternSeg 预编译后的单个模式段。
+type patternSeg struct {
+	kind segKind
+	// name segLiteral 的键名
+	name string
+	// index segIndex 的下标
+	index int
+}
+
+// fieldPattern 预编译的字段路径模式，供 Allow/Deny 等基于路径的规则共享。
+//
+// 语法：段之间以 "." 分隔；"*" 匹配单段，"**" 匹配任意多段；
+// "[i]" 与 "[*]" 匹配切片下标，可紧跟在键名后，如 "users[*].email"。
+type fieldPattern struct {
+	segs []patternSeg
+}
+
+// compilePatterns 编译一组模式。模式在 With* 构建器中编译一次并随 Options 保存，
+// 编码时不再解析，也不进入全局缓存，来自请求的模式不会在进程内累积。
+func compilePatterns(patterns []string) []*fieldPattern {
+	out := make([]*fieldPattern, len(patterns))
+	for i, p := range patterns {
+		out[i] = compilePattern(p)
+	}
+	return out
+}
+
+func compilePattern(p string) *fieldPattern {
+	fp := &fieldPattern{}
+	for _, part := range strings.Split(p, ".") {
+		// 拆出紧随键名的下标段，如 users[0][*]
+		name := part
+		var idx []string
+		if i := strings.IndexByte(part, '['); i >= 0 && strings.HasSuffix(part, "]") {
+			name = part[:i]
+			idx = strings.SplitAfter(part[i:], "]")
+			idx = idx[:len(idx)-1] // 末尾空串
+		}
+		switch name {
+		case "":
+		case "*":
+			fp.segs = append(fp.segs, patternSeg{kind: segAny})
+		case "**":
+			fp.segs = append(fp.segs, patternSeg{kind: segAnyDeep})
+		default:
+			fp.segs = append(fp.segs, patternSeg{kind: segLiteral, name: name})
+		}
+		for _, s := range idx {
+			inner := strings.TrimSuffix(strings.TrimPrefix(s, "["), "]")
+			if inner == "*" {
+				fp.segs = append(fp.segs, patternSeg{kind: segAnyIndex})
+			} else if n, err := strconv.Atoi(inner); err == nil {
+				fp.segs = append(fp.segs, patternSeg{kind: segIndex, index: n})
+			} else {
+				// 非法下标按字面量处理，永远不会命中下标段
+				fp.segs = append(fp.segs, patternSeg{kind: segLiteral, name: s})
+			}
+		}
+	}
+	return fp
+}
+
+// match 判断路径段是否命中该模式。
+func (fp *fieldPattern) match(path Path) bool {
+	return matchSegs(fp.segs, path)
+}
+
+func matchSegs(segs []patternSeg, path Path) bool {
+	for len(segs) > 0 {
+		s := segs[0]
+		if s.kind == segAnyDeep {
+			for i := 0; i <= len(path); i++ {
+				if matchSegs(segs[1:], path[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(path) == 0 || !s.matchOne(path[0]) {
+			return false
+		}
+		segs, path = segs[1:], path[1:]
+	}
+	return len(path) == 0
+}
+
+func (s patternSeg) matchOne(seg PathSegment) bool {
+	isIndex := seg.Kind == SegmentIndex
+	switch s.kind {
+	case segAny:
+		return true
+	case segAnyIndex:
+		return isIndex
+	case segIndex:
+		return isIndex && seg.Index == s.index
+	default:
+		return !isIndex && seg.Name == s.name
+	}
+}
+
+// matchAnyPattern 判断 path 是否命中任一模式。
+func matchAnyPattern(patterns []*fieldPattern, path Path) bool {
+	for _, p := range patterns {
+		if p.match(path) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package groupjson
+
+import (
+	"reflect"
+	"slices"
+	"strconv"
+)
+
+// OrderTagKey 字段输出顺序标签，如 order:"1"；带该标签的字段按取值升序排在最前，
+// 其余字段保持声明顺序。
+const OrderTagKey = "order"
+
+// WithPinnedFields 让列出的 JSON 键（如 "id"、"type"）在每个对象中按给定顺序最先输出，
+// 与声明顺序及 order 标签无关；对象中不存在的键忽略。
+func (e Encoder) WithPinnedFields(keys ...string) Encoder {
+	e.opts.PinnedFields = append([]string(nil), keys...)
+	return e
+}
+
+// pinFields 返回按置顶键重排后的字段副本，不修改共享的 fields。
+func pinFields(fields []*fieldInfo, pinned []string) []*fieldInfo {
+	out := make([]*fieldInfo, 0, len(fields))
+	for _, k := range pinned {
+		for _, f := range fields {
+			if f.jsonName == k {
+				out = append(out, f)
+				break
+			}
+		}
+	}
+	for _, f := range fields {
+		if !slices.Contains(pinned, f.jsonName) {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// sortByOrderTag 按 order 标签稳定排序字段，无标签或取值非法的字段排在带标签字段之后。
+func sortByOrderTag(t reflect.Type, fields []fieldInfo) {
+	orders := make(map[string]int, len(fields))
+	for _, f := range fields {
+		if s, ok := t.FieldByIndex(f.index).Tag.Lookup(OrderTagKey); ok {
+			if n, err := strconv.Atoi(s); err == nil {
+				orders[f.jsonName] = n
+			}
+		}
+	}
+	if len(orders) == 0 {
+		return
+	}
+	slices.SortStableFunc(fields, func(a, b fieldInfo) int {
+		oa, aok := orders[a.jsonName]
+		ob, bok := orders[b.jsonName]
+		switch {
+		case aok && bok:
+			return oa - ob
+		case aok:
+			return -1
+		case bok:
+			return 1
+		}
+		return 0
+	})
+}
@@ -0,0 +1,162 @@
+package groupjson
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"sync/atomic"
+)
+
+// plan 某类型在固定 (TagKey, 分组, 模式) 下的字段计划：
+// 预先完成分组筛选，编码时只需按序写出预渲染的键片段并格式化值。
+type plan struct {
+	// fields 待输出字段，filtered 为 true 时已剔除分组不匹配与 never 字段
+	fields []*fieldInfo
+	// filtered 是否已按分组预筛选；为 false 时编码期仍需逐字段判断
+	filtered bool
+}
+
+// planKey 计划缓存键。
+type planKey struct {
+	schemaKey
+	groups string
+	mode   GroupMode
+	// all 计划保留全部字段（未请求分组或配置了 AllowFields）
+	all bool
+}
+
+// planCache 字段计划缓存，条目数以 MaxCachedPlans 为上限。
+var planCache cache[planKey, *plan]
+
+// planCount planCache 中的条目数（近似值，并发写入与清空交错时可能略有偏差）。
+var planCount atomic.Int64
+
+// MaxCachedPlans 字段计划缓存的条目上限。计划按（类型, 分组, 模式）缓存，分组来自请求参数时
+// 组合数可能无限增长；达到上限后整体清空、按需重建，<= 0 表示不限制。应在程序初始化时设置。
+var MaxCachedPlans = 4096
+
+// storePlan 缓存计划，达到上限时先清空缓存；并发编译同一计划时返回先存入的那一份。
+func storePlan(key planKey, p *plan) *plan {
+	if limit := MaxCachedPlans; limit > 0 && planCount.Load() >= int64(limit) {
+		planCache.Clear()
+		planCount.Store(0)
+		planBytes.Store(0)
+	}
+	actual, loaded := planCache.LoadOrStore(key, p)
+	if !loaded {
+		planCount.Add(1)
+		planBytes.Add(planFootprint(p))
+	}
+	return actual
+}
+
+// planGroupKey 将分组列表（及置顶字段、字段排序、合并视图）编码为计划缓存键的一部分，每次编码只计算一次。
+// 分组与合并视图的顺序不影响筛选结果，先排序去重，使 ["a","b"] 与 ["b","a","a"] 共用同一计划。
+func planGroupKey(o Options) string {
+	key := canonicalGroups(o.Groups)
+	if len(o.PinnedFields) > 0 {
+		key += "\x01" + strings.Join(o.PinnedFields, "\x00")
+	}
+	if o.SortFields {
+		key += "\x02"
+	}
+	if len(o.MergedViews) > 0 {
+		views := make([]string, len(o.MergedViews))
+		for i, view := range o.MergedViews {
+			views[i] = canonicalGroups(view)
+		}
+		slices.Sort(views)
+		for _, view := range slices.Compact(views) {
+			key += "\x03" + view
+		}
+	}
+	return key
+}
+
+// canonicalGroups 返回排序去重后的分组列表，以 \x00 连接。
+func canonicalGroups(groups []string) string {
+	for i := 1; i < len(groups); i++ {
+		if groups[i-1] >= groups[i] {
+			sorted := slices.Clone(groups)
+			slices.Sort(sorted)
+			return strings.Join(slices.Compact(sorted), "\x00")
+		}
+	}
+	return strings.Join(groups, "\x00")
+}
+
+// getPlan 返回 t 在当前分组下的字段计划，置顶字段排在最前，开启 SortFields 时其余字段按键名排序。
+// 配置了 AllowFields 时分组不匹配的字段仍可能被路径规则放行，此时计划保留全部字段（不标记为已筛选）；
+// 配置了 Trace 时同样保留全部字段，以便报告被分组排除的字段。
+func (e Encoder) getPlan(t reflect.Type, sch *schema, groupKey string) *plan {
+	all := len(e.opts.Groups) == 0 || len(e.opts.AllowFields) > 0 || e.opts.Trace != nil
+	if all && len(e.opts.PinnedFields) == 0 && !e.opts.SortFields {
+		return &sch.all
+	}
+	key := planKey{schemaKey: e.schemaKey(t), groups: groupKey, mode: e.opts.Mode, all: all}
+	if p, ok := planCache.Load(key); ok {
+		return p
+	}
+	p := &plan{filtered: !all}
+	q := compileGroups(e.opts)
+	for _, f := range sch.all.fields {
+		if all || (!f.never && e.fieldVisible(f, &q)) {
+			p.fields = append(p.fields, f)
+		}
+	}
+	if e.opts.SortFields {
+		slices.SortStableFunc(p.fields, func(a, b *fieldInfo) int { return strings.Compare(a.jsonName, b.jsonName) })
+	}
+	if len(e.opts.PinnedFields) > 0 {
+		p.fields = pinFields(p.fields, e.opts.PinnedFields)
+	}
+	return storePlan(key, p)
+}
+
+// Precompile 在程序启动时使用默认配置预热样本值类型（递归包含嵌套的结构体类型）的 schema、
+// 编码函数与未分组的字段计划，消除大量模型首次请求时的延迟尖峰。见 Encoder.Prerender。
+func Precompile(types ...any) {
+	NewEncoder().Prerender(types...)
+}
+
+// PrecompileGroups 与 Precompile 相同，另外预热 groups 下的字段计划，对每个常用分组组合各调用一次。
+// 使用自定义 TagKey 等配置时应调用对应 Encoder 的 Prerender。
+func PrecompileGroups(groups []string, types ...any) {
+	NewEncoder().WithGroups(groups...).Prerender(types...)
+}
+
+// Prerender 为样本值的类型（递归包含嵌套的结构体类型）提前编译当前分组下的字段计划，
+// 包括分组筛选结果与预渲染的键片段，以及各类型的编码函数，避免首个请求承担编译开销。
+func (e Encoder) Prerender(samples ...any) {
+	seen := map[reflect.Type]struct{}{}
+	for _, s := range samples {
+		if t := reflect.TypeOf(s); t != nil {
+			encoderFor(t)
+			e.prerenderType(t, seen)
+		}
+	}
+}
+
+func (e Encoder) prerenderType(t reflect.Type, seen map[reflect.Type]struct{}) {
+	for t != nil {
+		switch t.Kind() {
+		case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+			t = t.Elem()
+			continue
+		}
+		break
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return
+	}
+	if _, ok := seen[t]; ok {
+		return
+	}
+	seen[t] = struct{}{}
+
+	sch := e.schemaFor(t)
+	p := e.getPlan(t, sch, planGroupKey(e.opts))
+	for _, f := range p.fields {
+		e.prerenderType(t.FieldByIndex(f.index).Type, seen)
+	}
+}
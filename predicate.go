@@ -0,0 +1,74 @@
+package groupjson
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// FieldPredicate 按路径为字段附加运行时条件，parent 为字段所在的结构体值。
+type FieldPredicate struct {
+	// Pattern 字段路径模式，语法同 WithAllowFields
+	Pattern string
+	// Fn 返回 false 时该字段不输出
+	Fn func(parent reflect.Value) bool
+	// pattern Pattern 的编译结果
+	pattern *fieldPattern
+}
+
+// WithFieldPredicate 追加按路径匹配的字段条件，在分组筛选通过后求值。
+func (e Encoder) WithFieldPredicate(pattern string, fn func(parent reflect.Value) bool) Encoder {
+	e.opts.FieldPredicates = append(append([]FieldPredicate(nil), e.opts.FieldPredicates...), FieldPredicate{Pattern: pattern, Fn: fn, pattern: compilePattern(pattern)})
+	return e
+}
+
+// resolveConditions 为带 if=Name 修饰的字段定位条件字段：
+// 先按 Go 字段名（含提升字段，允许 json:"-"），再按 JSON 键名查找。
+func resolveConditions(t reflect.Type, out []fieldInfo) {
+	for i := range out {
+		f := &out[i]
+		if f.cond == "" {
+			continue
+		}
+		if sf, ok := t.FieldByName(f.cond); ok {
+			f.condIndex = sf.Index
+			continue
+		}
+		for _, o := range out {
+			if o.jsonName == f.cond {
+				f.condIndex = o.index
+				break
+			}
+		}
+	}
+}
+
+// parseCondition 解析 if 修饰，"!" 前缀表示取反。
+func parseCondition(s string) (name string, negate bool) {
+	if strings.HasPrefix(s, "!") {
+		return s[1:], true
+	}
+	return s, false
+}
+
+// conditionHolds 判断字段的标签条件与路径条件是否满足。
+func (e Encoder) conditionHolds(parent reflect.Value, f *fieldInfo, ctx *encodeContext) (bool, error) {
+	if f.cond != "" {
+		if f.condIndex == nil {
+			return false, fmt.Errorf("%w: %q", ErrUnknownCondition, f.cond)
+		}
+		cv := fieldByIndex(parent, f.condIndex)
+		if (!cv.IsValid() || cv.IsZero()) != f.condNegate {
+			return false, nil
+		}
+	}
+	if len(e.opts.FieldPredicates) > 0 {
+		path := append(ctx.path, PathSegment{Kind: SegmentField, Name: f.jsonName})
+		for _, p := range e.opts.FieldPredicates {
+			if p.pattern.match(path) && !p.Fn(parent) {
+				return false, nil
+			}
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,178 @@
+package groupjson
+
+import (
+	"errors"
+	"fmt"
+	"go/format"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// groupRegistry 通过 RegisterGroups 声明的合法分组名。
+var groupRegistry struct {
+	sync.RWMutex
+	names map[string]struct{}
+}
+
+// RegisterGroups 声明合法的分组名，通常在 init 中调用（gen-groups 生成的常量文件会自动注册）。
+// 注册后可用 ValidateGroups 检查结构体标签，或用 WithStrictGroups 拒绝请求未注册的分组，
+// 避免分组名在各服务间拼写漂移。
+func RegisterGroups(names ...string) {
+	groupRegistry.Lock()
+	defer groupRegistry.Unlock()
+	if groupRegistry.names == nil {
+		groupRegistry.names = map[string]struct{}{}
+	}
+	for _, n := range names {
+		groupRegistry.names[n] = struct{}{}
+	}
+}
+
+// RegisteredGroups 返回已注册的分组名（按字典序）。
+func RegisteredGroups() []string {
+	groupRegistry.RLock()
+	defer groupRegistry.RUnlock()
+	out := make([]string, 0, len(groupRegistry.names))
+	for n := range groupRegistry.names {
+		out = append(out, n)
+	}
+	slices.Sort(out)
+	return out
+}
+
+// groupRegistered 判断分组是否已注册。
+func groupRegistered(name string) bool {
+	groupRegistry.RLock()
+	defer groupRegistry.RUnlock()
+	_, ok := groupRegistry.names[name]
+	return ok
+}
+
+// WithStrictGroups 开启后，请求的分组（含合并视图）必须已通过 RegisterGroups 注册，
+// 否则编码返回 ErrUnknownGroup。
+func (e Encoder) WithStrictGroups(enabled bool) Encoder { e.opts.StrictGroups = enabled; return e }
+
+// checkGroups 校验本次请求的分组均已注册。
+func (e Encoder) checkGroups() error {
+	for _, g := range e.opts.Groups {
+		if !groupRegistered(g) {
+			return fmt.Errorf("%w: %q", ErrUnknownGroup, g)
+		}
+	}
+	for _, view := range e.opts.MergedViews {
+		for _, g := range view {
+			if !groupRegistered(g) {
+				return fmt.Errorf("%w: %q", ErrUnknownGroup, g)
+			}
+		}
+	}
+	return nil
+}
+
+// ValidateGroups 使用默认配置检查样本类型的分组标签，见 Encoder.ValidateGroups。
+func ValidateGroups(samples ...any) error {
+	return NewEncoder().ValidateGroups(samples...)
+}
+
+// ValidateGroups 检查样本值的类型（递归包含嵌套结构体）在分组标签与 unmask 中引用的分组均已注册，
+// 每个未注册的引用返回一个包装 ErrUnknownGroup 的错误（以 errors.Join 合并），全部合法时返回 nil。
+func (e Encoder) ValidateGroups(samples ...any) error {
+	var errs []error
+	e.walkTagGroups(samples, func(t reflect.Type, field, group string) {
+		if !groupRegistered(group) {
+			errs = append(errs, fmt.Errorf("%w: %q in %s.%s", ErrUnknownGroup, group, t, field))
+		}
+	})
+	return errors.Join(errs...)
+}
+
+// TagGroups 返回样本值的类型（递归包含嵌套结构体）在分组标签与 unmask 中引用的全部分组名（按字典序）。
+func (e Encoder) TagGroups(samples ...any) []string {
+	set := map[string]struct{}{}
+	e.walkTagGroups(samples, func(_ reflect.Type, _, group string) { set[group] = struct{}{} })
+	out := make([]string, 0, len(set))
+	for g := range set {
+		out = append(out, g)
+	}
+	slices.Sort(out)
+	return out
+}
+
+// walkTagGroups 遍历样本类型中每个字段引用的分组名（拆分 a+b 组合，忽略 never）。
+func (e Encoder) walkTagGroups(samples []any, fn func(t reflect.Type, field, group string)) {
+	e.walkSchemas(samples, func(t reflect.Type, sch *schema) {
+		for _, f := range sch.fields {
+			for _, entry := range append(slices.Clip(f.groups), f.unmask...) {
+				for _, g := range strings.Split(entry, "+") {
+					if g != "" && g != NeverGroup {
+						fn(t, f.jsonName, g)
+					}
+				}
+			}
+		}
+	})
+}
+
+// walkSchemas 对样本值的类型及其字段引用的嵌套结构体类型各调用一次 fn。
+func (e Encoder) walkSchemas(samples []any, fn func(t reflect.Type, sch *schema)) {
+	seen := map[reflect.Type]struct{}{}
+	var walk func(t reflect.Type)
+	walk = func(t reflect.Type) {
+		for t != nil && (t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map) {
+			t = t.Elem()
+		}
+		if t == nil || t.Kind() != reflect.Struct || t == timeType {
+			return
+		}
+		if _, ok := seen[t]; ok {
+			return
+		}
+		seen[t] = struct{}{}
+		sch := e.schemaFor(t)
+		fn(t, sch)
+		for _, f := range sch.fields {
+			walk(t.FieldByIndex(f.index).Type)
+		}
+	}
+	for _, s := range samples {
+		walk(reflect.TypeOf(s))
+	}
+}
+
+// GroupConstants 使用默认配置生成分组常量源文件，见 Encoder.GroupConstants。
+func GroupConstants(pkg string, samples ...any) ([]byte, error) {
+	return NewEncoder().GroupConstants(pkg, samples...)
+}
+
+// GroupConstants 生成名为 pkg 的 Go 源文件，为样本类型标签中出现的每个分组声明常量
+// （如 GroupPublic = "public"），并在 init 中调用 RegisterGroups 注册，
+// 让调用方以常量代替字符串字面量。cmd/groupjson 的 gen-groups 子命令基于此实现。
+func (e Encoder) GroupConstants(pkg string, samples ...any) ([]byte, error) {
+	groups := e.TagGroups(samples...)
+	var sb strings.Builder
+	sb.WriteString("// Code generated by groupjson gen-groups. DO NOT EDIT.\n\n")
+	sb.WriteString("package " + pkg + "\n\n")
+	sb.WriteString("import \"github.com/JieBaiYou/groupjson\"\n\n")
+	sb.WriteString("// 结构体标签中出现的分组名。\n")
+	sb.WriteString("const (\n")
+	used := map[string]bool{}
+	names := make([]string, len(groups))
+	for i, g := range groups {
+		base := "Group" + exportedName(g)
+		name := base
+		for n := 2; used[name]; n++ {
+			name = base + strconv.Itoa(n)
+		}
+		used[name] = true
+		names[i] = name
+		sb.WriteString("\t" + name + " = " + strconv.Quote(g) + "\n")
+	}
+	sb.WriteString(")\n\n")
+	sb.WriteString("func init() {\n")
+	sb.WriteString("\tgroupjson.RegisterGroups(" + strings.Join(names, ", ") + ")\n")
+	sb.WriteString("}\n")
+	return format.Source([]byte(sb.String()))
+}
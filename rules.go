@@ -0,0 +1,43 @@
+package groupjson
+
+// WithAllowFields 追加强制输出的字段路径规则，叠加在分组标签之上。
+// 路径以 JSON 键名用 "." 连接，如 "user.email"、"users[*].email"，
+// 支持 "*"（单段）、"**"（任意多段）、"[i]" 与 "[*]"（切片下标）。
+// 仅对父级已输出的字段生效，且不会覆盖 "-" 硬性脱敏。
+func (e Encoder) WithAllowFields(patterns ...string) Encoder {
+	e.opts.AllowFields = append(append([]string(nil), e.opts.AllowFields...), patterns...)
+	e.opts.allowPatterns = compilePatterns(e.opts.AllowFields)
+	return e
+}
+
+// WithDenyFields 追加强制隐藏的字段路径规则，优先级高于分组标签与 AllowFields。
+// 可作为紧急手段，在代码修复上线前通过配置隐藏泄露的字段，如 "**.password"。
+// 规则同样作用于 map、sync.Map 与 iter.Seq2 的键，动态载荷中的同名键一并隐藏。
+func (e Encoder) WithDenyFields(patterns ...string) Encoder {
+	e.opts.DenyFields = append(append([]string(nil), e.opts.DenyFields...), patterns...)
+	e.opts.denyPatterns = compilePatterns(e.opts.DenyFields)
+	return e
+}
+
+// keyVisible 判断动态对象（map、sync.Map、iter.Seq2）中键 key 的成员是否输出。
+// 这些键没有分组标签，总是可见，只有 Deny 规则能将其隐藏，Allow 规则不改变结果。
+func (e Encoder) keyVisible(key string, ctx *encodeContext) bool {
+	return !ctx.trackPath || !matchAnyPattern(e.opts.denyPatterns, append(ctx.path, PathSegment{Kind: SegmentKey, Name: key}))
+}
+
+// compileRules 编译直接构造的 Options 中的路径模式（With* 构建器已在追加规则时编译），供 MarshalWith 使用。
+func (o *Options) compileRules() {
+	o.allowPatterns = compilePatterns(o.AllowFields)
+	o.denyPatterns = compilePatterns(o.DenyFields)
+	preds := make([]FieldPredicate, len(o.FieldPredicates))
+	for i, p := range o.FieldPredicates {
+		p.pattern = compilePattern(p.Pattern)
+		preds[i] = p
+	}
+	o.FieldPredicates = preds
+}
+
+// hasFieldRules 是否配置了任何路径规则（决定是否需要追踪路径）。
+func (o Options) hasFieldRules() bool {
+	return len(o.AllowFields) > 0 || len(o.DenyFields) > 0
+}
@@ -4,9 +4,11 @@ import (
 	"bytes"
 	"encoding"
 	"encoding/json"
+	"fmt"
 	"io"
 	"math"
 	"reflect"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -29,6 +31,7 @@ func Marshal(v any, groups ...string) ([]byte, error) {
 }
 
 func MarshalWith(opts Options, v any, groups ...string) ([]byte, error) {
+	opts.compileRules()
 	return Encoder{opts: opts}.WithGroups(groups...).Marshal(v)
 }
 
@@ -40,6 +43,10 @@ func (e Encoder) WithGroups(groups ...string) Encoder {
 func (e Encoder) WithGroupMode(mode GroupMode) Encoder { e.opts.Mode = mode; return e }
 func (e Encoder) WithTagKey(key string) Encoder        { e.opts.TagKey = key; return e }
 func (e Encoder) WithTopLevelKey(key string) Encoder   { e.opts.TopLevelKey = key; return e }
+
+// WithTagKeyFallback 设置迁移期的旧标签键：字段缺少 TagKey 标签时改读该标签，
+// 两者同时存在时以 TagKey 为准，可用 VetTagMigration 找出取值不一致的字段。
+func (e Encoder) WithTagKeyFallback(key string) Encoder { e.opts.TagKeyFallback = key; return e }
 func (e Encoder) WithMaxDepth(n int) Encoder {
 	if n < 1 {
 		n = 1
@@ -47,37 +54,100 @@ func (e Encoder) WithMaxDepth(n int) Encoder {
 	e.opts.MaxDepth = n
 	return e
 }
-func (e Encoder) WithEscapeHTML(on bool) Encoder { e.opts.EscapeHTML = on; return e }
-func (e Encoder) WithSortKeys(on bool) Encoder   { e.opts.SortKeys = on; return e }
+func (e Encoder) WithDepthPolicy(p DepthPolicy) Encoder     { e.opts.DepthPolicy = p; return e }
+func (e Encoder) WithCycleHandling(h CycleHandling) Encoder { e.opts.CycleHandling = h; return e }
+func (e Encoder) WithEscapeHTML(on bool) Encoder            { e.opts.EscapeHTML = on; return e }
 
-var bufPool = sync.Pool{
-	New: func() any {
-		return new(bytes.Buffer)
-	},
+// WithFloatSpecials 设置 NaN 与 ±Inf 的输出方式，使含统计指标的结构体不因个别特殊值而编码失败。
+func (e Encoder) WithFloatSpecials(p FloatSpecialPolicy) Encoder { e.opts.FloatSpecials = p; return e }
+
+// writeFloatSpecial 按 FloatSpecials 写出 NaN 或 ±Inf。
+func (e Encoder) writeFloatSpecial(buf *bytes.Buffer, v reflect.Value, f float64) error {
+	switch e.opts.FloatSpecials {
+	case FloatSpecialAsNull:
+		buf.WriteString("null")
+	case FloatSpecialAsString:
+		switch {
+		case math.IsNaN(f):
+			buf.WriteString(`"NaN"`)
+		case f > 0:
+			buf.WriteString(`"Infinity"`)
+		default:
+			buf.WriteString(`"-Infinity"`)
+		}
+	default:
+		return &json.UnsupportedValueError{Value: v, Str: strconv.FormatFloat(f, 'g', -1, 64)}
+	}
+	return nil
 }
 
-// Marshal 输出 JSON 字节。
-func (e Encoder) Marshal(v any) ([]byte, error) {
-	buf := bufPool.Get().(*bytes.Buffer)
-	buf.Reset()
-	defer bufPool.Put(buf)
+// WithErrorPolicy 设置遇到无法编码的值时的处理方式，让个别坏字段不至于拖垮整个列表接口。
+func (e Encoder) WithErrorPolicy(p ErrorPolicy) Encoder { e.opts.ErrorPolicy = p; return e }
 
-	if e.opts.TopLevelKey != "" {
-		buf.WriteByte('{')
-		e.writeString(buf, e.opts.TopLevelKey)
-		buf.WriteByte(':')
-	}
+func (e Encoder) WithSortKeys(on bool) Encoder { e.opts.SortKeys = on; return e }
 
-	if err := e.encode(buf, reflect.ValueOf(v), newContext(e.opts)); err != nil {
-		return nil, err
-	}
+// WithSortFields 开启后结构体字段按 JSON 键名字典序输出（覆盖声明顺序与 order 标签，置顶字段仍最先输出），
+// 配合 WithSortKeys 可使输出与声明顺序完全无关，适合快照测试与缓存键。
+func (e Encoder) WithSortFields(on bool) Encoder { e.opts.SortFields = on; return e }
 
-	if e.opts.TopLevelKey != "" {
-		buf.WriteByte('}')
+// WithNilCollections 设置 nil 切片与 nil map 的输出方式，NilAsEmpty 时输出 [] 与 {}。
+// 字段级的 nullas 标签优先于该选项。
+func (e Encoder) WithNilCollections(p NilCollectionPolicy) Encoder {
+	e.opts.NilCollections = p
+	return e
+}
+
+// WithScratchArena 实验性开关：复用每次编码的临时数据（访问集、路径等），降低 GC 压力。
+func (e Encoder) WithScratchArena(on bool) Encoder { e.opts.ScratchArena = on; return e }
+
+// WithDeepOmitEmpty 开启后，结构体（及其指针）字段经分组筛选后若输出为 {}，连同键名一起省略，
+// 嵌套的空对象逐层向上省略，避免响应中充斥空对象。
+func (e Encoder) WithDeepOmitEmpty(on bool) Encoder { e.opts.DeepOmitEmpty = on; return e }
+
+// WithKeyTables 开启后，元素为结构体（或其指针）的切片/数组输出为键表：
+// {"keys":["id","name"],"rows":[[1,"a"],[2,"b"]]}，键名只出现一次，显著缩小大列表的体积。
+// 行内的值按元组规则输出（被省略的字段为 null），计算字段不参与。
+func (e Encoder) WithKeyTables(on bool) Encoder { e.opts.KeyTables = on; return e }
+
+// WithInt64AsString 开启后，绝对值超过 2^53-1 的整数输出为字符串（如 "9007199254740993"），
+// 避免 JavaScript 客户端静默丢失精度；安全范围内的整数仍输出为数字。
+func (e Encoder) WithInt64AsString(on bool) Encoder { e.opts.Int64AsString = on; return e }
+
+// WithMaxBytes 限制单次编码的内存输出大小：缓冲超过 n 字节时立即中止并返回 ErrMaxBytes，
+// 防止意外序列化巨大的对象图；n <= 0 表示不限制。
+// EncodeLines 与 EncodeSeq 按行/元素写出，限制作用于单行或单个元素。
+func (e Encoder) WithMaxBytes(n int) Encoder { e.opts.MaxBytes = n; return e }
+
+// WithChannelEncoding 开启后，可接收的 channel 被读取至关闭并输出为 JSON 数组，元素照常应用分组筛选。
+// 编码会阻塞直到生产者关闭 channel；nil channel 输出 null。
+func (e Encoder) WithChannelEncoding(on bool) Encoder { e.opts.ChannelEncoding = on; return e }
+
+// WithMaxChannelItems 设置单个 channel 最多读取的元素数，读满 n 个后不再接收并返回 ErrChannelLimit，
+// 其余元素留在 channel 中不被取走，因此 n 应大于预期的元素数；n <= 0 时使用 DefaultMaxChannelItems。
+func (e Encoder) WithMaxChannelItems(n int) Encoder { e.opts.MaxChannelItems = n; return e }
+
+// Marshal 输出 JSON 字节。非 ErrorFail 的 ErrorPolicy 下跳过或置空了部分值时，
+// 同时返回完整输出与汇总这些问题的 *MultiError。
+func (e Encoder) Marshal(v any) ([]byte, error) {
+	buf := getBuffer(e.opts.BufferSize)
+	defer putBuffer(buf)
+
+	err := e.encodeTop(buf, v)
+	if err != nil && !isMultiError(err) {
+		return nil, err
 	}
+	e.sample(v, buf.Bytes())
 
 	// 复制字节以避免复用 buffer 时的数据污染
-	return append([]byte(nil), buf.Bytes()...), nil
+	return append([]byte(nil), buf.Bytes()...), err
+}
+
+// MarshalGroups 使用当前配置、以本次调用指定的分组输出 JSON 字节。
+// 适合在多个请求间共享同一个预配置的 Encoder，仅按调用切换分组。
+func (e Encoder) MarshalGroups(v any, groups ...string) ([]byte, error) {
+	// e 为值接收者，此处修改不影响共享实例；groups 仅在本次调用内只读使用，无需复制
+	e.opts.Groups = groups
+	return e.Marshal(v)
 }
 
 // Encode 直接写入 io.Writer，避免中间 []byte 拷贝。
@@ -85,63 +155,255 @@ func (e Encoder) Encode(w io.Writer, v any) error {
 	// 为了复用 encode 逻辑，暂时先写入 buffer 再写入 writer
 	// 真正的流式优化可以在后续版本通过直接操作 writer 实现，
 	// 但考虑到很多 writer 是无缓冲的，先写入 buffer 也是一种优良实践。
-	buf := bufPool.Get().(*bytes.Buffer)
-	buf.Reset()
-	defer bufPool.Put(buf)
+	buf := getBuffer(e.opts.BufferSize)
+	defer putBuffer(buf)
 
-	if e.opts.TopLevelKey != "" {
-		buf.WriteByte('{')
-		e.writeString(buf, e.opts.TopLevelKey)
-		buf.WriteByte(':')
+	err := e.encodeTop(buf, v)
+	if err != nil && !isMultiError(err) {
+		return err
+	}
+	e.sample(v, buf.Bytes())
+
+	// 输出仍然写出，*MultiError 随后返回
+	if _, werr := w.Write(buf.Bytes()); werr != nil {
+		return werr
 	}
+	return err
+}
 
-	if err := e.encode(buf, reflect.ValueOf(v), newContext(e.opts)); err != nil {
+// encodeTop 写入完整的顶层输出（含 Envelope/TopLevelKey 包装），供 Marshal/Encode 共用。
+func (e Encoder) encodeTop(buf *bytes.Buffer, v any) error {
+	if err := e.checkTop(); err != nil {
 		return err
 	}
+	ctx := acquireContext(e.opts)
+	defer releaseContext(ctx)
 
-	if e.opts.TopLevelKey != "" {
-		buf.WriteByte('}')
+	rv := reflect.ValueOf(v)
+	e.openTop(buf)
+	return e.finishTop(buf, rv, ctx, e.encode(buf, rv, ctx))
+}
+
+// checkTop 编码前的顶层检查（StrictGroups），自行组织顶层输出的 MultiMarshal、MarshalColumnar、
+// EncodeLines 与 EncodeSeq 同样调用，与 finishTop、sample 一起保证各入口的顶层行为一致。
+func (e Encoder) checkTop() error {
+	if e.opts.StrictGroups {
+		return e.checkGroups()
 	}
+	return nil
+}
 
-	_, err := w.Write(buf.Bytes())
-	return err
+// finishTop 处理根值的编码结果 err 并写入顶层包装的结尾。
+func (e Encoder) finishTop(buf *bytes.Buffer, rv reflect.Value, ctx *encodeContext, err error) error {
+	if err == errOmit {
+		// ErrorSkipField 下根值本身无法编码，没有可回滚的容器
+		buf.WriteString("null")
+	} else if err != nil {
+		return err
+	}
+	if err := e.closeTop(buf, topCount(rv), ctx); err != nil {
+		return err
+	}
+	if err := ctx.checkSize(buf); err != nil {
+		return err
+	}
+	return multiError(ctx.errs)
 }
 
 // ----- 上下文与缓存 -----
 
-// context 维护单次编码过程的状态。
-type context struct {
+// encodeContext 维护单次编码过程的状态。
+type encodeContext struct {
 	// opts 编码配置快照
 	opts Options
 	// depth 当前递归深度
 	depth int
-	// visited 指针身份访问集，用于循环检测
-	visited map[uintptr]struct{}
+	// visited 正在编码的结构体身份集，值为进入时的路径长度（用于 CycleRef 生成引用）
+	visited map[visitKey]int
+	// trackPath 是否需要维护 path（仅在配置了路径规则时开启）
+	trackPath bool
+	// path 当前值的结构化路径，如 users[0].email
+	path Path
+	// groupKey 本次编码分组的计划缓存键
+	groupKey string
+	// groups 本次编码请求的分组编译成的位集合
+	groups groupQuery
+	// maxDepth 当前生效的深度上限，字段级 gjdepth 标签可临时收紧
+	maxDepth int
+	// typeDepth WithMaxDepthFor 限制的类型在当前路径上的实例数
+	typeDepth map[reflect.Type]int
+	// errs 非 ErrorFail 策略下被跳过或置空的值
+	errs []*EncodeError
+	// plans 最近使用的结构体字段计划，见 structPlan
+	plans [planMemoSize]planMemo
+	// nextPlan plans 中下一个被替换的位置
+	nextPlan int
+}
+
+// planMemoSize 每次编码就近复用的结构体计划数，覆盖常见的“列表元素 + 几个嵌套类型”。
+const planMemoSize = 4
+
+// planMemo 一个结构体类型在本次编码中的 schema 与字段计划。
+type planMemo struct {
+	t   reflect.Type
+	sch *schema
+	p   *plan
+}
+
+// structPlan 返回结构体类型 t 的 schema 与字段计划。同一次编码内 TagKey 与分组不变，
+// 编码大切片时同一类型反复出现，先在上下文内按类型指针比较查找，省去两次全局缓存查找
+// （缓存键含字符串，每次都需哈希）。
+func (c *encodeContext) structPlan(e Encoder, t reflect.Type) (*schema, *plan) {
+	for i := range c.plans {
+		if c.plans[i].t == t {
+			return c.plans[i].sch, c.plans[i].p
+		}
+	}
+	sch := e.schemaFor(t)
+	p := e.getPlan(t, sch, c.groupKey)
+	c.plans[c.nextPlan] = planMemo{t: t, sch: sch, p: p}
+	c.nextPlan = (c.nextPlan + 1) % planMemoSize
+	return sch, p
+}
+
+func newContext(opts Options) *encodeContext {
+	return newContextFor(opts, planGroupKey(opts), compileGroups(opts))
+}
+
+// newContextFor 与 newContext 相同，但使用预先计算的计划缓存键与分组位集合（见 Compiled）。
+func newContextFor(opts Options, groupKey string, groups groupQuery) *encodeContext {
+	return &encodeContext{opts: opts, depth: 0, visited: make(map[visitKey]int), trackPath: opts.needsPath(), groupKey: groupKey, groups: groups, maxDepth: opts.MaxDepth}
+}
+
+// visitKey 以地址加类型标识结构体实例：首字段为结构体时与父结构体地址相同，仅凭地址会误判为循环。
+type visitKey struct {
+	ptr uintptr
+	t   reflect.Type
+}
+
+// needsPath 是否需要在编码过程中维护路径。
+func (o Options) needsPath() bool {
+	return o.hasFieldRules() || o.CycleHandling == CycleRef || o.FieldTransformer != nil || len(o.FieldPredicates) > 0 ||
+		o.AssetHook != nil || len(o.Invariants) > 0 || o.Trace != nil || o.ErrorPolicy != ErrorFail
+}
+
+// contextPool 实验性的编码上下文复用池，见 Options.ScratchArena。
+var contextPool = sync.Pool{
+	New: func() any {
+		return newContext(Options{})
+	},
+}
+
+// acquireContext 获取本次编码的上下文；开启 ScratchArena 时从池中复用，
+// 连同 visited 集合与路径切片的底层存储一起复用。
+func acquireContext(opts Options) *encodeContext {
+	return acquireContextFor(opts, planGroupKey(opts), compileGroups(opts))
+}
+
+// acquireContextFor 与 acquireContext 相同，但使用预先计算的计划缓存键与分组位集合。
+func acquireContextFor(opts Options, groupKey string, groups groupQuery) *encodeContext {
+	if !opts.ScratchArena {
+		return newContextFor(opts, groupKey, groups)
+	}
+	c := contextPool.Get().(*encodeContext)
+	c.opts = opts
+	c.trackPath = opts.needsPath()
+	c.groupKey = groupKey
+	c.groups = groups
+	c.maxDepth = opts.MaxDepth
+	return c
+}
+
+// releaseContext 在编码结束时整体归还上下文内的临时数据。
+func releaseContext(c *encodeContext) {
+	if !c.opts.ScratchArena {
+		return
+	}
+	c.opts = Options{}
+	c.depth = 0
+	clear(c.visited)
+	clear(c.typeDepth)
+	c.path = c.path[:0]
+	c.errs = nil
+	c.plans, c.nextPlan = [planMemoSize]planMemo{}, 0
+	contextPool.Put(c)
+}
+
+func (c *encodeContext) pushPath(seg PathSegment) {
+	if c.trackPath {
+		c.path = append(c.path, seg)
+	}
+}
+
+func (c *encodeContext) popPath() {
+	if c.trackPath {
+		c.path = c.path[:len(c.path)-1]
+	}
 }
 
-func newContext(opts Options) *context {
-	return &context{opts: opts, depth: 0, visited: make(map[uintptr]struct{})}
+// checkSize 输出缓冲超过 MaxBytes 时返回 ErrMaxBytes。
+func (c *encodeContext) checkSize(buf *bytes.Buffer) error {
+	if c.opts.MaxBytes > 0 && buf.Len() > c.opts.MaxBytes {
+		return fmt.Errorf("%w: %d > %d", ErrMaxBytes, buf.Len(), c.opts.MaxBytes)
+	}
+	return nil
 }
 
-func (c *context) incDepth() error {
+// incDepth 进入下一层容器；超过 MaxDepth（或字段级收紧后的上限）时不改变深度，
+// 按 DepthPolicy 返回 ErrMaxDepth 或 errOmit。
+func (c *encodeContext) incDepth() error {
 	c.depth++
-	if c.depth > c.opts.MaxDepth {
-		return ErrMaxDepth
+	if c.depth > c.maxDepth {
+		c.depth--
+		if c.opts.DepthPolicy == DepthError {
+			return ErrMaxDepth
+		}
+		return errOmit
 	}
 	return nil
 }
 
-func (c *context) decDepth() {
+// valueFailed 按 ErrorPolicy 处理无法编码的值（不支持的类型、NaN/Inf、自定义 Marshaler 报错）：
+// ErrorFail 原样返回错误；ErrorEmitNull 写出 null；ErrorSkipField 返回 errOmit，由上层容器连同键名回滚。
+// 后两种情况把问题连同路径记录到 errs，编码结束后以 *MultiError 返回。
+func (c *encodeContext) valueFailed(buf *bytes.Buffer, v reflect.Value, err error) error {
+	if c.opts.ErrorPolicy == ErrorFail {
+		return err
+	}
+	c.errs = append(c.errs, &EncodeError{Path: c.path.Clone(), FieldType: v.Type(), Cause: err})
+	if c.opts.ErrorPolicy == ErrorEmitNull {
+		buf.WriteString("null")
+		return nil
+	}
+	return errOmit
+}
+
+// truncate 处理 incDepth 的错误：DepthTruncateNull 下写入 null 并吞掉错误，
+// 其余情况原样返回，errOmit 由上层容器回滚整个成员。
+func (c *encodeContext) truncate(buf *bytes.Buffer, err error) error {
+	if err == errOmit && c.opts.Trace != nil {
+		c.traceValue(c.opts.DepthPolicy == DepthTruncateNull, TraceDepth)
+	}
+	if err == errOmit && c.opts.DepthPolicy == DepthTruncateNull {
+		buf.WriteString("null")
+		return nil
+	}
+	return err
+}
+
+func (c *encodeContext) decDepth() {
 	if c.depth > 0 {
 		c.depth--
 	}
 }
 
-var schemaCache sync.Map // key: schemaKey
+var schemaCache cache[schemaKey, *schema]
 
 type schemaKey struct {
-	t      reflect.Type
-	tagKey string
+	t        reflect.Type
+	tagKey   string
+	fallback string
+	naming   string
 }
 
 type fieldInfo struct {
@@ -151,14 +413,49 @@ type fieldInfo struct {
 	jsonName string
 	// keyBytes 预计算的键名 JSON 字节，包含引号和冒号，如 "key":
 	keyBytes []byte
+	// leadBytes 非首字段时整体写出的片段，即逗号加 keyBytes，如 ,"key":
+	leadBytes []byte
 	// index 反射字段索引路径（支持匿名提升）
 	index []int
 	// omitEmpty 是否应用 omitempty 省略规则
 	omitEmpty bool
-	// omitZero 是否应用 omitzero 省略规则（仅标量零值）
+	// omitZero 是否应用 omitzero 省略规则（优先调用 IsZero 方法）
 	omitZero bool
+	// asString json 标签带 ,string，标量值以 JSON 字符串形式输出
+	asString bool
 	// groups 从 TagKey 标签解析出的分组列表
 	groups []string
+	// groupBits groups 中各分组项的位集合，groupUnion 为其并集
+	groupBits  []groupMask
+	groupUnion groupMask
+	// groupBitsOK 分组名均已分配位，可按位判断可见性
+	groupBitsOK bool
+	// never 分组标签含 "-"，无论请求何种分组均不输出（硬性脱敏）
+	never bool
+	// mask 分组标签修饰 mask=name 指定的脱敏函数名
+	mask string
+	// unmask 分组标签修饰 unmask=a|b 指定的可见原值的分组
+	unmask []string
+	// cond 分组标签修饰 if=Name 引用的条件字段名，条件字段为零值时不输出
+	cond string
+	// condNegate if=!Name，条件字段非零值时不输出
+	condNegate bool
+	// condIndex 条件字段在父结构体中的索引路径，未找到时为 nil
+	condIndex []int
+	// asset asset 标签取值（资源类型），非空时交给 AssetHook 处理
+	asset string
+	// nullAs nullas 标签给出的字面量，字段为 nil 时代替 null 输出
+	nullAs []byte
+	// nullAsErr nullas 标签非法时的错误，延迟到实际输出该字段时返回
+	nullAsErr error
+	// maxDepth gjdepth 标签给出的字段级深度限制，0 表示不限制
+	maxDepth int
+	// prec gjprec 标签给出的小数位数，-1 表示未设置
+	prec int
+	// typ 字段值的类型（非 nil 指针字段取值时已解引用，故为其元素类型）
+	typ reflect.Type
+	// enc typ 的预编译编码函数
+	enc encoderFunc
 	// anonymous 是否为匿名字段（仅用于构建期判断）
 	anonymous bool
 }
@@ -166,24 +463,43 @@ type fieldInfo struct {
 type schema struct {
 	// fields 该类型在当前 TagKey 下可见且可导出的字段信息
 	fields []fieldInfo
+	// all 未经分组筛选的完整计划，指向 fields 中的元素
+	all plan
 }
 
-func getSchema(t reflect.Type, tagKey string) *schema {
-	key := schemaKey{t: t, tagKey: tagKey}
-	if v, ok := schemaCache.Load(key); ok {
-		return v.(*schema)
+// schemaKey 返回 t 在当前配置下的 schema 缓存键。
+func (e Encoder) schemaKey(t reflect.Type) schemaKey {
+	return schemaKey{t: t, tagKey: e.opts.TagKey, fallback: e.opts.TagKeyFallback, naming: e.opts.Naming.Name}
+}
+
+// schemaFor 返回 t 在当前 TagKey（及迁移期回退标签、命名策略）下的 schema。
+func (e Encoder) schemaFor(t reflect.Type) *schema {
+	key := e.schemaKey(t)
+	if s, ok := schemaCache.Load(key); ok {
+		schemaStats.hits.Add(1)
+		return s
+	}
+	schemaStats.misses.Add(1)
+	if limit := MaxCachedSchemas; limit > 0 && schemaStats.entries.Load() >= int64(limit) {
+		ResetCaches()
+	}
+	s := buildSchema(t, e.opts.TagKey, e.opts.TagKeyFallback, e.opts.Naming)
+	if actual, loaded := schemaCache.LoadOrStore(key, s); loaded {
+		return actual
 	}
-	s := buildSchema(t, tagKey)
-	schemaCache.Store(key, s)
+	schemaStats.entries.Add(1)
+	schemaStats.bytes.Add(schemaFootprint(s))
 	return s
 }
 
-func buildSchema(t reflect.Type, tagKey string) *schema {
+func buildSchema(t reflect.Type, tagKey, fallback string, naming NamingStrategy) *schema {
 	// BFS 按标准库规则收集导出字段，处理匿名嵌入与冲突
 	type queueItem struct {
 		t     reflect.Type
 		index []int
 		depth int
+		// prefix 提升字段的键名前缀，来自 ,inline=prefix，嵌套时逐层累加
+		prefix string
 	}
 	q := []queueItem{{t: t, index: nil, depth: 0}}
 	out := make([]fieldInfo, 0, t.NumField())
@@ -202,16 +518,20 @@ func buildSchema(t reflect.Type, tagKey string) *schema {
 				continue
 			}
 			tag := sf.Tag.Get("json")
-			if tag == "-" {
+			if tag == "-" || isLockType(sf.Type) {
 				continue
 			}
 			parts := strings.Split(tag, ",")
-			jname := sf.Name
+			jname := naming.apply(sf.Name)
 			if len(parts[0]) > 0 {
 				jname = parts[0]
 			}
+			jname = it.prefix + jname
 			omitEmpty := false
 			omitZero := false
+			asString := false
+			inline := false
+			prefix := ""
 			for _, p := range parts[1:] {
 				if p == "omitempty" {
 					omitEmpty = true
@@ -219,36 +539,79 @@ func buildSchema(t reflect.Type, tagKey string) *schema {
 				if p == "omitzero" {
 					omitZero = true
 				}
+				if p == "string" {
+					asString = true
+				}
+				if p == "inline" {
+					inline = true
+				}
+				if rest, ok := strings.CutPrefix(p, "inline="); ok {
+					inline = true
+					prefix = rest
+				}
 			}
 
-			if sf.Anonymous && (sf.Type.Kind() == reflect.Struct || (sf.Type.Kind() == reflect.Pointer && sf.Type.Elem().Kind() == reflect.Struct)) && (len(parts[0]) == 0) {
-				// 匿名嵌入，按标准库进行字段提升
+			isStruct := sf.Type.Kind() == reflect.Struct || (sf.Type.Kind() == reflect.Pointer && sf.Type.Elem().Kind() == reflect.Struct)
+			if isStruct && ((sf.Anonymous && len(parts[0]) == 0) || inline) {
+				// 匿名嵌入或 ,inline 字段，按标准库进行字段提升
 				st := sf.Type
 				if st.Kind() == reflect.Pointer {
 					st = st.Elem()
 				}
 				base := append(append([]int(nil), it.index...), i)
-				q = append(q, queueItem{t: st, index: base, depth: it.depth + 1})
+				q = append(q, queueItem{t: st, index: base, depth: it.depth + 1, prefix: it.prefix + prefix})
 				continue
 			}
 
-			groups := strings.Split(sf.Tag.Get(tagKey), ",")
+			// 主标签优先；主标签缺失时读取迁移期的回退标签
+			groupTag, ok := sf.Tag.Lookup(tagKey)
+			if !ok && fallback != "" {
+				groupTag = sf.Tag.Get(fallback)
+			}
+			groups, mods := parseGroupTag(groupTag)
+			never := false
+			for _, g := range groups {
+				if g == NeverGroup {
+					never = true
+					break
+				}
+			}
 			idx := append(append([]int(nil), it.index...), i)
 
-			// 预计算 keyBytes: "jsonName":
+			// 预计算 leadBytes: ,"jsonName":，keyBytes 为其去掉逗号的子切片
 			kb, _ := json.Marshal(jname)
-			kb = append(kb, ':')
+			lead := append(append([]byte{','}, kb...), ':')
 
+			cond, condNegate := parseCondition(mods["if"])
+			nullAs, nullAsErr := parseNullAs(sf)
 			fi := fieldInfo{
-				name:      sf.Name,
-				jsonName:  jname,
-				keyBytes:  kb,
-				index:     idx,
-				omitEmpty: omitEmpty,
-				omitZero:  omitZero,
-				groups:    groups,
-				anonymous: sf.Anonymous,
+				name:       sf.Name,
+				jsonName:   jname,
+				keyBytes:   lead[1:],
+				leadBytes:  lead,
+				index:      idx,
+				omitEmpty:  omitEmpty,
+				omitZero:   omitZero,
+				asString:   asString,
+				groups:     groups,
+				never:      never,
+				mask:       mods["mask"],
+				unmask:     splitNonEmpty(mods["unmask"], "|"),
+				cond:       cond,
+				condNegate: condNegate,
+				asset:      sf.Tag.Get(AssetTagKey),
+				nullAs:     nullAs,
+				nullAsErr:  nullAsErr,
+				maxDepth:   parseDepthTag(sf),
+				prec:       parsePrecTag(sf),
+				anonymous:  sf.Anonymous,
+			}
+			fi.typ = sf.Type
+			if fi.typ.Kind() == reflect.Pointer {
+				fi.typ = fi.typ.Elem()
 			}
+			fi.enc = encoderFor(fi.typ)
+			fi.groupBits, fi.groupUnion, fi.groupBitsOK = internGroups(groups)
 			if prev, ok := seen[jname]; ok {
 				// 冲突：保留更浅层（先入队的），与 encoding/json 一致
 				_ = prev
@@ -259,39 +622,130 @@ func buildSchema(t reflect.Type, tagKey string) *schema {
 		}
 	}
 
-	return &schema{fields: out}
+	sortByOrderTag(t, out)
+	resolveConditions(t, out)
+	s := &schema{fields: out}
+	s.all.fields = make([]*fieldInfo, len(out))
+	for i := range out {
+		s.all.fields[i] = &out[i]
+	}
+	return s
+}
+
+// parseGroupTag 解析分组标签：";" 之前为逗号分隔的分组，之后为 key=value 修饰，
+// 如 "public,admin;mask=email;unmask=admin"。
+func parseGroupTag(tag string) ([]string, map[string]string) {
+	parts := strings.Split(tag, ";")
+	groups := strings.Split(parts[0], ",")
+	if len(parts) == 1 {
+		return groups, nil
+	}
+	mods := make(map[string]string, len(parts)-1)
+	for _, p := range parts[1:] {
+		k, v, _ := strings.Cut(strings.TrimSpace(p), "=")
+		mods[k] = v
+	}
+	return groups, mods
+}
+
+// splitNonEmpty 按 sep 切分 s，空串返回 nil。
+func splitNonEmpty(s, sep string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, sep)
 }
 
 // ----- 编码实现 -----
 
-func (e Encoder) encode(buf *bytes.Buffer, v reflect.Value, ctx *context) error {
+func (e Encoder) encode(buf *bytes.Buffer, v reflect.Value, ctx *encodeContext) error {
+	if err := ctx.checkSize(buf); err != nil {
+		return err
+	}
 	if !v.IsValid() {
 		buf.WriteString("null")
 		return nil
 	}
+	return encoderFor(v.Type())(e, buf, v, ctx)
+}
+
+// encodeWith 与 encode 相同，但使用调用方预先取得的编码函数（v 的类型须与之对应），
+// 供切片元素等同类型的批量值复用，省去逐值查找缓存。
+func (e Encoder) encodeWith(buf *bytes.Buffer, v reflect.Value, ctx *encodeContext, enc encoderFunc) error {
+	if err := ctx.checkSize(buf); err != nil {
+		return err
+	}
+	return enc(e, buf, v, ctx)
+}
 
+// encodeValue 逐值解释执行的通用编码路径，用于编码方式取决于具体值的类型（见 compileEncoder）。
+func (e Encoder) encodeValue(buf *bytes.Buffer, v reflect.Value, ctx *encodeContext) error {
 	// 处理 nil 指针/接口
 	if v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
 		if v.IsNil() {
 			buf.WriteString("null")
 			return nil
 		}
+		if v.Kind() == reflect.Interface && e.opts.TypeDiscriminator != "" {
+			if sv, name, ok := discriminated(v.Elem()); ok {
+				return e.encodeStructAs(buf, sv, ctx, false, name)
+			}
+		}
 		return e.encode(buf, v.Elem(), ctx)
 	}
 
-	// 优先使用 json.Marshaler / encoding.TextMarshaler
-	if m, ok := asJSONMarshaler(v); ok {
-		b, err := m.MarshalJSON()
+	if v.Type() == syncMapType {
+		if !v.CanAddr() {
+			// sync.Map 的方法位于指针上，不可寻址的值（按值传入）先复制一份（内容为空的零值同样安全）
+			cp := reflect.New(syncMapType)
+			cp.Elem().Set(v)
+			v = cp.Elem()
+		}
+		return e.encodeSyncMap(buf, v.Addr().Interface().(*sync.Map), ctx)
+	}
+	if v.Kind() == reflect.Func {
+		if kind := seqKind(v.Type()); kind != 0 {
+			return e.encodeSeq(buf, v, kind, ctx)
+		}
+	}
+	if v.Kind() == reflect.Map && v.Type().Implements(groupedMapType) {
+		if v.IsNil() {
+			e.writeNilCollection(buf, "{}")
+			return nil
+		}
+		return e.encodeGroupedMap(buf, v.Interface().(groupedMap), ctx)
+	}
+
+	// 生成的编码代码与反射路径输出一致，配置允许时直接调用
+	if ga, ok := asGroupAppender(v); ok && e.appenderAllowed(ctx, v.Type()) {
+		b, err := ga.AppendGroupJSON(buf.AvailableBuffer(), e.opts.Groups, e.opts.Mode)
 		if err != nil {
-			return err
+			return ctx.valueFailed(buf, v, err)
+		}
+		buf.Write(b)
+		return nil
+	}
+
+	// 优先使用 GroupMarshaler，其次 json.Marshaler / encoding.TextMarshaler
+	if gm, ok := asGroupMarshaler(v); ok {
+		b, err := gm.MarshalJSONGroups(e.opts.Groups, e.opts.Mode)
+		if err != nil {
+			return ctx.valueFailed(buf, v, err)
 		}
 		buf.Write(b)
 		return nil
 	}
+	if m, ok := asJSONMarshaler(v); ok {
+		b, err := m.MarshalJSON()
+		if err != nil {
+			return ctx.valueFailed(buf, v, err)
+		}
+		return e.writeMarshalerJSON(buf, b, v.Type())
+	}
 	if tm, ok := asTextMarshaler(v); ok {
 		txt, err := tm.MarshalText()
 		if err != nil {
-			return err
+			return ctx.valueFailed(buf, v, err)
 		}
 		e.writeString(buf, string(txt))
 		return nil
@@ -299,6 +753,10 @@ func (e Encoder) encode(buf *bytes.Buffer, v reflect.Value, ctx *context) error
 
 	// 特殊：[]byte 遵循标准库编码为 base64 字符串
 	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+		if v.IsNil() && e.opts.NilCollections == NilAsEmpty {
+			buf.WriteString(`""`)
+			return nil
+		}
 		b, err := json.Marshal(v.Interface())
 		if err != nil {
 			return err
@@ -314,73 +772,308 @@ func (e Encoder) encode(buf *bytes.Buffer, v reflect.Value, ctx *context) error
 		return e.encodeMap(buf, v, ctx)
 	case reflect.Slice, reflect.Array:
 		return e.encodeSlice(buf, v, ctx)
-	case reflect.Chan, reflect.Func, reflect.UnsafePointer:
-		return ErrUnsupportedType
+	case reflect.Chan:
+		if e.opts.ChannelEncoding && v.Type().ChanDir()&reflect.RecvDir != 0 {
+			return e.encodeChan(buf, v, ctx)
+		}
+		return ctx.valueFailed(buf, v, ErrUnsupportedType)
+	case reflect.Func, reflect.UnsafePointer:
+		return ctx.valueFailed(buf, v, ErrUnsupportedType)
 	default:
 		// 标量
-		return e.encodeScalar(buf, v)
+		if err := e.encodeScalar(buf, v); err != nil {
+			return ctx.valueFailed(buf, v, err)
+		}
+		return nil
 	}
 }
 
-func (e Encoder) encodeStruct(buf *bytes.Buffer, v reflect.Value, ctx *context) error {
+func (e Encoder) encodeStruct(buf *bytes.Buffer, v reflect.Value, ctx *encodeContext) error {
+	return e.encodeStructAs(buf, v, ctx, false, "")
+}
+
+// encodeStructAs 编码结构体；asRow 为 true 时按元组形式输出（键表的数据行），
+// typeName 非空时在对象开头写入类型判别键。
+func (e Encoder) encodeStructAs(buf *bytes.Buffer, v reflect.Value, ctx *encodeContext, asRow bool, typeName string) error {
 	if err := ctx.incDepth(); err != nil {
-		return err
+		return ctx.truncate(buf, err)
 	}
 	defer ctx.decDepth()
+	if entered, err := ctx.enterType(v.Type()); err != nil {
+		return ctx.truncate(buf, err)
+	} else if entered {
+		defer ctx.leaveType(v.Type())
+	}
 
 	// 循环检测（仅指针身份）
 	if v.CanAddr() {
 		addr := v.Addr().Pointer()
-		if _, ok := ctx.visited[addr]; ok {
-			return ErrCircularReference
+		key := visitKey{ptr: addr, t: v.Type()}
+		if n, ok := ctx.visited[key]; ok {
+			return e.handleCycle(buf, ctx, n)
+		}
+		ctx.visited[key] = len(ctx.path)
+		defer delete(ctx.visited, key)
+	}
+
+	if len(e.opts.Invariants) > 0 {
+		if err := e.checkInvariants(v, ctx); err != nil {
+			return err
 		}
-		ctx.visited[addr] = struct{}{}
-		defer delete(ctx.visited, addr)
 	}
 
 	t := v.Type()
-	sch := getSchema(t, e.opts.TagKey)
+	sch, p := ctx.structPlan(e, t)
+	if debugEnabled() {
+		e.debugType(t, sch)
+	}
+
+	if asRow || e.isTuple(t) {
+		return e.encodeTuple(buf, v, p, ctx)
+	}
 
 	buf.WriteByte('{')
 	first := true
+	if typeName != "" {
+		e.writeString(buf, e.opts.TypeDiscriminator)
+		buf.WriteByte(':')
+		e.writeString(buf, typeName)
+		first = false
+	}
 
-	for _, f := range sch.fields {
-		if len(e.opts.Groups) > 0 && !e.includeField(f.groups) {
+	for _, f := range p.fields {
+		if typeName != "" && f.jsonName == e.opts.TypeDiscriminator {
 			continue
 		}
-
-		fv := fieldByIndex(v, f.index)
-
-		// 检查 omit 规则
-		if f.omitEmpty && isEmptyValue(fv) {
-			continue
+		fv, ok, err := e.fieldValue(v, p, f, ctx)
+		if err != nil {
+			return withSegment(err, PathSegment{Kind: SegmentField, Name: f.jsonName}, t.FieldByIndex(f.index).Type)
 		}
-		if f.omitZero && isZeroScalar(fv) {
+		if !ok {
 			continue
 		}
 
-		if !first {
-			buf.WriteByte(',')
+		mark, wasFirst := buf.Len(), first
+		if first {
+			buf.Write(f.keyBytes)
+		} else {
+			buf.Write(f.leadBytes)
 		}
 		first = false
 
-		buf.Write(f.keyBytes)
+		ctx.pushPath(PathSegment{Kind: SegmentField, Name: f.jsonName})
+		err = e.encodeField(buf, fv, f, ctx)
+		if err != nil {
+			if err != errOmit {
+				return withSegment(err, PathSegment{Kind: SegmentField, Name: f.jsonName}, t.FieldByIndex(f.index).Type)
+			}
+			// errOmit：连同键名一起回滚
+			buf.Truncate(mark)
+			first = wasFirst
+		}
+		ctx.popPath()
+	}
+
+	if err := e.encodeVirtual(buf, v, ctx, &first); err != nil {
+		return err
+	}
+
+	buf.WriteByte('}')
+	return nil
+}
+
+// encodeField 写出字段值：nil 且配置了 nullas 时写替代字面量，否则按脱敏、,string 或常规路径编码。
+func (e Encoder) encodeField(buf *bytes.Buffer, fv reflect.Value, f *fieldInfo, ctx *encodeContext) error {
+	if f.maxDepth > 0 && ctx.maxDepth > ctx.depth+f.maxDepth {
+		return e.encodeFieldLimited(buf, fv, f, ctx)
+	}
+	if f.prec >= 0 {
+		// e 为值副本，固定小数位只作用于该字段的值
+		e.opts.FloatFormat, e.opts.FloatPrec = 'f', f.prec
+	}
+	if f.nullAs != nil || f.nullAsErr != nil {
+		if isNilValue(fv) {
+			if f.nullAsErr != nil {
+				return f.nullAsErr
+			}
+			buf.Write(f.nullAs)
+			return nil
+		}
+	}
+	if e.masked(f) {
+		return e.writeMasked(buf, fv, f.mask)
+	}
+	if f.asString {
+		if sv, ok := quotableScalar(fv); ok {
+			return e.writeQuoted(buf, sv)
+		}
+	}
+	if e.opts.DeepOmitEmpty && isStructValue(fv) {
+		start := buf.Len()
 		if err := e.encode(buf, fv, ctx); err != nil {
 			return err
 		}
+		// 分组筛选后为空对象：通知上层连同键名一起省略
+		if buf.Len()-start == 2 && buf.Bytes()[start] == '{' {
+			buf.Truncate(start)
+			return errOmit
+		}
+		return nil
+	}
+	if fv.IsValid() && fv.Type() == f.typ {
+		return e.encodeWith(buf, fv, ctx, f.enc)
 	}
+	return e.encode(buf, fv, ctx)
+}
 
-	buf.WriteByte('}')
+// quotableScalar 返回 ,string 选项可作用的标量值（解引用非 nil 指针），
+// 与 encoding/json 一致，自定义序列化的类型不受影响。
+func quotableScalar(v reflect.Value) (reflect.Value, bool) {
+	for v.IsValid() && (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return v, false
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return v, false
+	}
+	if _, ok := asJSONMarshaler(v); ok {
+		return v, false
+	}
+	if _, ok := asTextMarshaler(v); ok {
+		return v, false
+	}
+	switch v.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return v, true
+	}
+	return v, false
+}
+
+// writeQuoted 将标量的 JSON 文本再作为字符串写出，如 12.5 -> "12.5"、"a" -> "\"a\""。
+// Int64AsString 已输出为字符串的整数、FloatSpecials 输出的 null 或字符串不再重复加引号。
+func (e Encoder) writeQuoted(buf *bytes.Buffer, v reflect.Value) error {
+	start := buf.Len()
+	if err := e.encodeScalar(buf, v); err != nil {
+		return err
+	}
+	if c := buf.Bytes()[start]; v.Kind() != reflect.String && (c == '"' || c == 'n') {
+		return nil
+	}
+	lit := string(buf.Bytes()[start:])
+	buf.Truncate(start)
+	e.writeString(buf, lit)
 	return nil
 }
 
-func (e Encoder) encodeMap(buf *bytes.Buffer, v reflect.Value, ctx *context) error {
-	if v.IsNil() {
+// fieldValue 依次应用分组、路径规则、条件、omit 规则、资源钩子与转换钩子，
+// 返回字段最终要输出的值；ok 为 false 表示跳过该字段。配置了 Trace 时报告每个字段的取舍原因。
+func (e Encoder) fieldValue(v reflect.Value, p *plan, f *fieldInfo, ctx *encodeContext) (reflect.Value, bool, error) {
+	fv, ok, reason, err := e.decideField(v, p, f, ctx)
+	if e.opts.Trace != nil && err == nil {
+		e.traceField(v.Type(), f, ctx, ok, reason)
+	}
+	return fv, ok, err
+}
+
+// decideField 实现 fieldValue 的判断逻辑，并返回决定字段去留的原因。
+func (e Encoder) decideField(v reflect.Value, p *plan, f *fieldInfo, ctx *encodeContext) (reflect.Value, bool, TraceReason, error) {
+	if !p.filtered && f.never {
+		return reflect.Value{}, false, TraceNever, nil
+	}
+	include := p.filtered || len(e.opts.Groups) == 0 || e.fieldVisible(f, &ctx.groups)
+	reason := TraceGroupMatch
+	if len(e.opts.Groups) == 0 {
+		reason = TraceNoGroups
+	} else if !include {
+		reason = TraceGroupMismatch
+	}
+	if ctx.trackPath {
+		path := append(ctx.path, PathSegment{Kind: SegmentField, Name: f.jsonName})
+		if matchAnyPattern(e.opts.denyPatterns, path) {
+			include, reason = false, TraceDenyRule
+		} else if !include && matchAnyPattern(e.opts.allowPatterns, path) {
+			include, reason = true, TraceAllowRule
+		}
+	}
+	if !include {
+		return reflect.Value{}, false, reason, nil
+	}
+	if f.cond != "" || len(e.opts.FieldPredicates) > 0 {
+		ok, err := e.conditionHolds(v, f, ctx)
+		if err != nil {
+			return reflect.Value{}, false, "", err
+		}
+		if !ok {
+			return reflect.Value{}, false, TraceCondition, nil
+		}
+	}
+
+	fv := fieldByIndex(v, f.index)
+	if !fv.IsValid() {
+		// 经由 nil 嵌入指针提升的字段
+		return reflect.Value{}, false, TraceNilEmbedded, nil
+	}
+
+	// 检查 omit 规则
+	if f.omitEmpty && isEmptyValue(fv) {
+		return reflect.Value{}, false, TraceOmitEmpty, nil
+	}
+	if f.omitZero && isZeroValue(fv) {
+		return reflect.Value{}, false, TraceOmitZero, nil
+	}
+
+	if f.asset != "" && e.opts.AssetHook != nil {
+		var err error
+		if fv, err = e.applyAsset(f, fv, ctx); err != nil {
+			return reflect.Value{}, false, "", err
+		}
+	}
+
+	if e.opts.FieldTransformer != nil {
+		ctx.pushPath(PathSegment{Kind: SegmentField, Name: f.jsonName})
+		nv, keep := e.opts.FieldTransformer(ctx.path, f.public(), fv)
+		ctx.popPath()
+		if !keep {
+			return reflect.Value{}, false, TraceTransformer, nil
+		}
+		fv = reflect.ValueOf(nv)
+	}
+	return fv, true, reason, nil
+}
+
+// handleCycle 按 CycleHandling 处理循环引用，n 为首次进入该实例时的路径长度。
+func (e Encoder) handleCycle(buf *bytes.Buffer, ctx *encodeContext, n int) error {
+	if e.opts.Trace != nil && e.opts.CycleHandling != CycleError {
+		ctx.traceValue(e.opts.CycleHandling != CycleOmit, TraceCycle)
+	}
+	switch e.opts.CycleHandling {
+	case CycleNull:
 		buf.WriteString("null")
 		return nil
+	case CycleOmit:
+		return errOmit
+	case CycleRef:
+		buf.WriteString(`{"$ref":`)
+		e.writeString(buf, "#"+ctx.path[:n].JSONPointer())
+		buf.WriteByte('}')
+		return nil
+	default:
+		return ErrCircularReference
+	}
+}
+
+func (e Encoder) encodeMap(buf *bytes.Buffer, v reflect.Value, ctx *encodeContext) error {
+	if v.IsNil() {
+		e.writeNilCollection(buf, "{}")
+		return nil
 	}
 	if err := ctx.incDepth(); err != nil {
-		return err
+		return ctx.truncate(buf, err)
 	}
 	defer ctx.decDepth()
 
@@ -398,10 +1091,15 @@ func (e Encoder) encodeMap(buf *bytes.Buffer, v reflect.Value, ctx *context) err
 		})
 	}
 
+	elem := encoderFor(v.Type().Elem())
 	first := true
 	for _, key := range keys {
+		if !e.keyVisible(key.String(), ctx) {
+			continue
+		}
 		val := v.MapIndex(key)
 
+		mark, wasFirst := buf.Len(), first
 		if !first {
 			buf.WriteByte(',')
 		}
@@ -412,34 +1110,55 @@ func (e Encoder) encodeMap(buf *bytes.Buffer, v reflect.Value, ctx *context) err
 		buf.WriteByte(':')
 
 		// 写入 value
-		if err := e.encode(buf, val, ctx); err != nil {
-			return err
+		ctx.pushPath(PathSegment{Kind: SegmentKey, Name: key.String()})
+		if err := e.encodeWith(buf, val, ctx, elem); err != nil {
+			if err != errOmit {
+				return withSegment(err, PathSegment{Kind: SegmentKey, Name: key.String()}, v.Type().Elem())
+			}
+			buf.Truncate(mark)
+			first = wasFirst
 		}
+		ctx.popPath()
 	}
 
 	buf.WriteByte('}')
 	return nil
 }
 
-func (e Encoder) encodeSlice(buf *bytes.Buffer, v reflect.Value, ctx *context) error {
+func (e Encoder) encodeSlice(buf *bytes.Buffer, v reflect.Value, ctx *encodeContext) error {
+	if e.opts.KeyTables {
+		if et, ok := keyTableElem(v.Type()); ok {
+			return e.encodeKeyTable(buf, v, et, ctx)
+		}
+	}
 	if v.Kind() == reflect.Slice && v.IsNil() {
-		buf.WriteString("null")
+		e.writeNilCollection(buf, "[]")
 		return nil
 	}
 	if err := ctx.incDepth(); err != nil {
-		return err
+		return ctx.truncate(buf, err)
 	}
 	defer ctx.decDepth()
 
 	buf.WriteByte('[')
 	n := v.Len()
+	elem := encoderFor(v.Type().Elem())
+	first := true
 	for i := 0; i < n; i++ {
-		if i > 0 {
+		mark, wasFirst := buf.Len(), first
+		if !first {
 			buf.WriteByte(',')
 		}
-		if err := e.encode(buf, v.Index(i), ctx); err != nil {
-			return err
+		first = false
+		ctx.pushPath(PathSegment{Kind: SegmentIndex, Index: i})
+		if err := e.encodeWith(buf, v.Index(i), ctx, elem); err != nil {
+			if err != errOmit {
+				return withSegment(err, PathSegment{Kind: SegmentIndex, Index: i}, v.Type().Elem())
+			}
+			buf.Truncate(mark)
+			first = wasFirst
 		}
+		ctx.popPath()
 	}
 	buf.WriteByte(']')
 	return nil
@@ -450,19 +1169,29 @@ func (e Encoder) encodeScalar(buf *bytes.Buffer, v reflect.Value) error {
 	case reflect.String:
 		e.writeString(buf, v.String())
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		buf.WriteString(strconv.FormatInt(v.Int(), 10))
+		n := v.Int()
+		if e.opts.Int64AsString && (n > maxSafeInt || n < -maxSafeInt) {
+			buf.WriteByte('"')
+			buf.WriteString(strconv.FormatInt(n, 10))
+			buf.WriteByte('"')
+			break
+		}
+		buf.WriteString(strconv.FormatInt(n, 10))
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
-		buf.WriteString(strconv.FormatUint(v.Uint(), 10))
+		n := v.Uint()
+		if e.opts.Int64AsString && n > maxSafeInt {
+			buf.WriteByte('"')
+			buf.WriteString(strconv.FormatUint(n, 10))
+			buf.WriteByte('"')
+			break
+		}
+		buf.WriteString(strconv.FormatUint(n, 10))
 	case reflect.Float32, reflect.Float64:
 		// 模仿 json 标准库的 float 格式化
 		f := v.Float()
-		if json.Valid([]byte(strconv.FormatFloat(f, 'f', -1, 64))) {
-			// 简单的校验不够，标准库有更复杂的逻辑处理 NaN/Inf
-			// 直接用 strconv 即可，但 NaN/Inf 会生成无效 JSON。
-			// 标准库 json 会报错：UnsupportedValueError
-			if math.IsNaN(f) || math.IsInf(f, 0) {
-				return &json.UnsupportedValueError{Value: v, Str: strconv.FormatFloat(f, 'g', -1, 64)}
-			}
+		// NaN/Inf 没有合法的 JSON 表示，默认与标准库一致返回 UnsupportedValueError
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return e.writeFloatSpecial(buf, v, f)
 		}
 		// 使用 -1 让 strconv 自动选择最简格式
 		// 标准 json 库对 float64 使用 'g', -1, 64，对 float32 使用 32
@@ -470,7 +1199,7 @@ func (e Encoder) encodeScalar(buf *bytes.Buffer, v reflect.Value) error {
 		if v.Kind() == reflect.Float32 {
 			bitSize = 32
 		}
-		buf.WriteString(strconv.FormatFloat(f, 'g', -1, bitSize))
+		e.writeFloat(buf, f, bitSize)
 	case reflect.Bool:
 		if v.Bool() {
 			buf.WriteString("true")
@@ -483,29 +1212,30 @@ func (e Encoder) encodeScalar(buf *bytes.Buffer, v reflect.Value) error {
 	return nil
 }
 
+// writeNilCollection 按 NilCollections 策略写出 nil 集合，empty 为空集合字面量。
+func (e Encoder) writeNilCollection(buf *bytes.Buffer, empty string) {
+	if e.opts.NilCollections == NilAsEmpty {
+		buf.WriteString(empty)
+		return
+	}
+	buf.WriteString("null")
+}
+
+// maxSafeInt JavaScript Number 可精确表示的最大整数 2^53-1。
+const maxSafeInt = 1<<53 - 1
+
 // writeString 写入字符串，根据 EscapeHTML 选项决定转义策略
 func (e Encoder) writeString(buf *bytes.Buffer, s string) {
-	if e.opts.EscapeHTML {
-		b, _ := json.Marshal(s)
-		buf.Write(b)
-	} else {
-		// 使用 Encoder 关闭 HTML 转义
-		// 这种方式略慢，但为了正确性。
-		// 可以考虑优化：手动检查是否含有 HTML 字符，没有则直接 json.Marshal
-		// 既然是 debloat，先用正确的方法。
-		start := buf.Len()
-		enc := json.NewEncoder(buf)
-		enc.SetEscapeHTML(false)
-		enc.Encode(s)
-		// Encode 增加了一个换行符，需要移除
-		if buf.Len() > start {
-			buf.Truncate(buf.Len() - 1)
-		}
-	}
+	appendString(buf, s, e.opts.EscapeHTML)
 }
 
+// fieldByIndex 沿索引路径取字段值，途经的非 nil 指针自动解引用；
+// 途经 nil 指针（如未初始化的嵌入/内联指针）时返回无效值，调用方应跳过该字段。
 func fieldByIndex(v reflect.Value, index []int) reflect.Value {
 	for _, i := range index {
+		if v.Kind() == reflect.Pointer { // 非 nil 指针已在上一步解引用
+			return reflect.Value{}
+		}
 		v = v.Field(i)
 		if v.Kind() == reflect.Pointer && !v.IsNil() {
 			v = v.Elem()
@@ -514,16 +1244,31 @@ func fieldByIndex(v reflect.Value, index []int) reflect.Value {
 	return v
 }
 
+// 字段分组项可写作 "a+b"，表示只有同时请求 a 与 b 时该项才成立（字段级 AND）。
+// 配置了 MergedViews 时，字段在任一视图中可见即包含。
 func (e Encoder) includeField(fieldGroups []string) bool {
-	if len(e.opts.Groups) == 0 {
+	if includeIn(e.opts.Groups, e.opts.Mode, fieldGroups) {
+		return true
+	}
+	for _, view := range e.opts.MergedViews {
+		if includeIn(view, e.opts.Mode, fieldGroups) {
+			return true
+		}
+	}
+	return false
+}
+
+// includeIn 判断字段在以 groups 请求的单个视图中是否可见。
+func includeIn(groups []string, mode GroupMode, fieldGroups []string) bool {
+	if len(groups) == 0 {
 		return false
 	}
-	switch e.opts.Mode {
+	switch mode {
 	case ModeAnd:
-		for _, g := range e.opts.Groups {
+		for _, g := range groups {
 			found := false
 			for _, fg := range fieldGroups {
-				if fg == g {
+				if fg == g || (strings.IndexByte(fg, '+') >= 0 && entryHas(fg, g) && entryHolds(groups, fg)) {
 					found = true
 					break
 				}
@@ -534,32 +1279,69 @@ func (e Encoder) includeField(fieldGroups []string) bool {
 		}
 		return true
 	default: // OR
-		for _, g := range e.opts.Groups {
-			for _, fg := range fieldGroups {
-				if fg == g {
-					return true
-				}
+		for _, fg := range fieldGroups {
+			if entryHolds(groups, fg) {
+				return true
 			}
 		}
 		return false
 	}
 }
 
-func isZeroScalar(v reflect.Value) bool {
-	switch v.Kind() {
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		return v.Int() == 0
-	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
-		return v.Uint() == 0
-	case reflect.Float32, reflect.Float64:
-		return v.Float() == 0
-	case reflect.Bool:
-		return !v.Bool()
-	case reflect.String:
-		return v.Len() == 0
-	default:
+// entryHolds 判断分组项是否成立：其中 "+" 连接的每个分组都在 groups 之列。
+func entryHolds(groups []string, entry string) bool {
+	if entry == "" {
 		return false
 	}
+	for entry != "" {
+		var g string
+		g, entry, _ = strings.Cut(entry, "+")
+		if !slices.Contains(groups, g) {
+			return false
+		}
+	}
+	return true
+}
+
+// entryHas 判断 "+" 连接的分组项是否包含分组 g。
+func entryHas(entry, g string) bool {
+	for entry != "" {
+		var part string
+		part, entry, _ = strings.Cut(entry, "+")
+		if part == g {
+			return true
+		}
+	}
+	return false
+}
+
+// isZeroer 与 encoding/json 一致，omitzero 优先使用类型自身的 IsZero 方法。
+type isZeroer interface{ IsZero() bool }
+
+var isZeroerType = reflect.TypeFor[isZeroer]()
+
+// isZeroValue 实现 Go 1.24 encoding/json 的 omitzero 语义：
+// 类型（或其指针）实现 IsZero() bool 时调用它，否则使用 reflect.Value.IsZero。
+func isZeroValue(v reflect.Value) bool {
+	t := v.Type()
+	switch {
+	case t.Implements(isZeroerType):
+		if t.Kind() == reflect.Pointer && v.IsNil() {
+			return true
+		}
+		if t.Kind() == reflect.Interface && v.IsNil() {
+			return true
+		}
+		return v.Interface().(isZeroer).IsZero()
+	case reflect.PointerTo(t).Implements(isZeroerType):
+		if !v.CanAddr() {
+			cp := reflect.New(t).Elem()
+			cp.Set(v)
+			v = cp
+		}
+		return v.Addr().Interface().(isZeroer).IsZero()
+	}
+	return v.IsZero()
 }
 
 // isEmptyValue 模仿 encoding/json 的实现，用于 omitempty
@@ -581,16 +1363,34 @@ func isEmptyValue(v reflect.Value) bool {
 	return false
 }
 
-// asJSONMarshaler 尝试提取 json.Marshaler 接口
-func asJSONMarshaler(v reflect.Value) (json.Marshaler, bool) {
+// asGroupMarshaler 尝试提取 GroupMarshaler 接口
+func asGroupMarshaler(v reflect.Value) (GroupMarshaler, bool) {
 	if !v.IsValid() {
 		return nil, false
 	}
+	// 可寻址时先取指针：指针装入接口不分配，且其方法集包含值接收者的方法
+	if v.CanAddr() {
+		pv := v.Addr()
+		if pv.CanInterface() {
+			if m, ok := pv.Interface().(GroupMarshaler); ok {
+				return m, true
+			}
+		}
+	}
 	if v.CanInterface() {
-		if m, ok := v.Interface().(json.Marshaler); ok {
+		if m, ok := v.Interface().(GroupMarshaler); ok {
 			return m, true
 		}
 	}
+	return nil, false
+}
+
+// asJSONMarshaler 尝试提取 json.Marshaler 接口
+func asJSONMarshaler(v reflect.Value) (json.Marshaler, bool) {
+	if !v.IsValid() {
+		return nil, false
+	}
+	// 可寻址时先取指针：指针装入接口不分配，且其方法集包含值接收者的方法
 	if v.CanAddr() {
 		pv := v.Addr()
 		if pv.CanInterface() {
@@ -599,6 +1399,11 @@ func asJSONMarshaler(v reflect.Value) (json.Marshaler, bool) {
 			}
 		}
 	}
+	if v.CanInterface() {
+		if m, ok := v.Interface().(json.Marshaler); ok {
+			return m, true
+		}
+	}
 	return nil, false
 }
 
@@ -607,11 +1412,7 @@ func asTextMarshaler(v reflect.Value) (encoding.TextMarshaler, bool) {
 	if !v.IsValid() {
 		return nil, false
 	}
-	if v.CanInterface() {
-		if m, ok := v.Interface().(encoding.TextMarshaler); ok {
-			return m, true
-		}
-	}
+	// 可寻址时先取指针：指针装入接口不分配，且其方法集包含值接收者的方法
 	if v.CanAddr() {
 		pv := v.Addr()
 		if pv.CanInterface() {
@@ -620,5 +1421,10 @@ func asTextMarshaler(v reflect.Value) (encoding.TextMarshaler, bool) {
 			}
 		}
 	}
+	if v.CanInterface() {
+		if m, ok := v.Interface().(encoding.TextMarshaler); ok {
+			return m, true
+		}
+	}
 	return nil, false
 }
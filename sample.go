@@ -0,0 +1,47 @@
+package groupjson
+
+import (
+	"math/rand/v2"
+	"reflect"
+)
+
+// SampleMeta 描述一次被采样的输出。
+type SampleMeta struct {
+	// Type 被编码值的类型，如 *model.User
+	Type string
+	// Groups 本次编码使用的分组
+	Groups []string
+	// Mode 分组匹配模式
+	Mode GroupMode
+	// Size 输出字节数
+	Size int
+}
+
+// WithSampler 按比例 rate（0~1）采样 Marshal/Encode 的最终输出交给 sink，用于离线排查“实际发送了什么”，
+// 无需记录每个响应。body 为输出的副本，sink 可以保留；sink 在编码所在的 goroutine 中同步调用，应尽快返回。
+// MultiMarshal 与 MarshalColumnar 采样完整输出，EncodeLines 与 EncodeSeq 逐行、逐元素采样。
+// rate <= 0 或 sink 为 nil 时关闭采样。
+func (e Encoder) WithSampler(rate float64, sink func(meta SampleMeta, body []byte)) Encoder {
+	e.opts.SampleRate = rate
+	e.opts.SampleSink = sink
+	return e
+}
+
+// sample 按采样率决定是否将 body 交给 sink。
+func (e Encoder) sample(v any, body []byte) {
+	if e.opts.SampleSink == nil || e.opts.SampleRate <= 0 {
+		return
+	}
+	if e.opts.SampleRate < 1 && rand.Float64() >= e.opts.SampleRate {
+		return
+	}
+	meta := SampleMeta{
+		Groups: append([]string(nil), e.opts.Groups...),
+		Mode:   e.opts.Mode,
+		Size:   len(body),
+	}
+	if t := reflect.TypeOf(v); t != nil {
+		meta.Type = t.String()
+	}
+	e.opts.SampleSink(meta, append([]byte(nil), body...))
+}
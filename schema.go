@@ -0,0 +1,360 @@
+package groupjson
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// SchemaVersion 导出 schema 的格式版本，LoadSchema 拒绝不同版本的数据。
+const SchemaVersion = 1
+
+// Schema 是可序列化的字段可见性描述，包含根类型及其引用到的全部嵌套结构体类型。
+// 可随插件分发或交给 sidecar，通过 Schema.Filter 在不依赖 Go 反射的情况下执行相同的分组筛选。
+type Schema struct {
+	// Root 根类型名
+	Root string
+	// Types 类型名 -> 字段列表，按声明顺序
+	Types map[string][]SchemaField
+}
+
+// SchemaField 描述一个输出字段。
+type SchemaField struct {
+	// Name 输出使用的 JSON 键名
+	Name string `json:"name"`
+	// Groups 分组标签中声明的分组，"a+b" 表示字段级 AND
+	Groups []string `json:"groups,omitempty"`
+	// Never 是否永不输出（分组标签含 "-"）
+	Never bool `json:"never,omitempty"`
+	// OmitEmpty 是否带有 omitempty
+	OmitEmpty bool `json:"omitempty,omitempty"`
+	// OmitZero 是否带有 omitzero
+	OmitZero bool `json:"omitzero,omitempty"`
+	// Mask 脱敏函数名，见 RegisterMask
+	Mask string `json:"mask,omitempty"`
+	// Unmask 可见原值的分组
+	Unmask []string `json:"unmask,omitempty"`
+	// If 条件字段（能解析时为其 JSON 键名），"!" 前缀表示取反
+	If string `json:"if,omitempty"`
+	// Ref 字段值（或其元素）为结构体时引用的类型名
+	Ref string `json:"ref,omitempty"`
+	// Container Ref 所在的容器："array" 表示数组元素、"map" 表示对象的值，空表示字段本身；
+	// 嵌套容器由外向内以 "/" 连接，如 [][]T 为 "array/array"、map[string][]T 为 "map/array"
+	Container string `json:"container,omitempty"`
+	// Example example 标签声明的示例值（规范化后的 JSON），见 ExampleTagKey
+	Example json.RawMessage `json:"example,omitempty"`
+}
+
+// schemaJSON Schema 的序列化形式。
+type schemaJSON struct {
+	Version int                      `json:"version"`
+	Root    string                   `json:"root"`
+	Types   map[string][]SchemaField `json:"types"`
+}
+
+// MarshalJSON 输出带版本号的 schema，类型按名称排序，结果稳定可用于比对。
+func (s *Schema) MarshalJSON() ([]byte, error) {
+	return json.Marshal(schemaJSON{Version: SchemaVersion, Root: s.Root, Types: s.Types})
+}
+
+// LoadSchema 解析 Schema.MarshalJSON 的输出，版本不符或根类型缺失时返回 ErrInvalidSchema。
+func LoadSchema(data []byte) (*Schema, error) {
+	var sj schemaJSON
+	if err := json.Unmarshal(data, &sj); err != nil {
+		return nil, err
+	}
+	if sj.Version != SchemaVersion {
+		return nil, fmt.Errorf("%w: version %d, want %d", ErrInvalidSchema, sj.Version, SchemaVersion)
+	}
+	if _, ok := sj.Types[sj.Root]; !ok {
+		return nil, fmt.Errorf("%w: root type %q not defined", ErrInvalidSchema, sj.Root)
+	}
+	for name, fields := range sj.Types {
+		for _, f := range fields {
+			if _, ok := sj.Types[f.Ref]; f.Ref != "" && !ok {
+				return nil, fmt.Errorf("%w: %s.%s references undefined type %q", ErrInvalidSchema, name, f.Name, f.Ref)
+			}
+		}
+	}
+	return &Schema{Root: sj.Root, Types: sj.Types}, nil
+}
+
+// Schema 导出 v 的类型在当前 TagKey 配置下的 schema，包含所有可达的嵌套结构体类型。
+// 实现了 json.Marshaler、encoding.TextMarshaler 或 GroupMarshaler 的类型视为不透明值，不再展开。
+// 字段的 example 标签无法解析为字段类型时返回 ErrInvalidExample。
+func (e Encoder) Schema(v any) (*Schema, error) {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, ErrInvalidType
+	}
+	s := &Schema{Root: schemaTypeName(t), Types: map[string][]SchemaField{}}
+	if _, err := e.exportType(s, t); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// exportType 将 t 及其引用的结构体类型写入 s.Types。
+func (e Encoder) exportType(s *Schema, t reflect.Type) (string, error) {
+	name := schemaTypeName(t)
+	if _, ok := s.Types[name]; ok {
+		return name, nil
+	}
+	sch := e.schemaFor(t)
+	fields := make([]SchemaField, 0, len(sch.fields))
+	s.Types[name] = fields // 先占位，处理自引用类型
+	for i := range sch.fields {
+		f := &sch.fields[i]
+		sf := SchemaField{
+			Name:      f.jsonName,
+			Groups:    f.groups,
+			Never:     f.never,
+			OmitEmpty: f.omitEmpty,
+			OmitZero:  f.omitZero,
+			Mask:      f.mask,
+			Unmask:    f.unmask,
+		}
+		if len(sf.Groups) == 1 && sf.Groups[0] == "" {
+			sf.Groups = nil
+		}
+		if f.cond != "" {
+			sf.If = f.cond
+			for _, o := range sch.fields {
+				if f.condIndex != nil && slices.Equal(o.index, f.condIndex) {
+					sf.If = o.jsonName
+					break
+				}
+			}
+			if f.condNegate {
+				sf.If = "!" + sf.If
+			}
+		}
+		field := t.FieldByIndex(f.index)
+		ex, err := exampleJSON(field)
+		if err != nil {
+			return "", fmt.Errorf("%s.%w", name, err)
+		}
+		sf.Example = ex
+		if elem, container, ok := schemaElem(field.Type); ok {
+			if sf.Ref, err = e.exportType(s, elem); err != nil {
+				return "", err
+			}
+			sf.Container = container
+		}
+		fields = append(fields, sf)
+	}
+	s.Types[name] = fields
+	return name, nil
+}
+
+// schemaElem 剥离指针、切片与 map，返回最终需要展开的结构体类型及其由外向内的容器层级。
+func schemaElem(t reflect.Type) (reflect.Type, string, bool) {
+	var containers []string
+	for {
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if opaqueSchemaType(t) {
+			return nil, "", false
+		}
+		switch t.Kind() {
+		case reflect.Struct:
+			return t, strings.Join(containers, "/"), true
+		case reflect.Slice, reflect.Array:
+			if t.Elem().Kind() == reflect.Uint8 {
+				return nil, "", false
+			}
+			containers, t = append(containers, "array"), t.Elem()
+		case reflect.Map:
+			if t.Key().Kind() != reflect.String {
+				return nil, "", false
+			}
+			containers, t = append(containers, "map"), t.Elem()
+		default:
+			return nil, "", false
+		}
+	}
+}
+
+var (
+	textMarshalerType  = reflect.TypeFor[encoding.TextMarshaler]()
+	groupMarshalerType = reflect.TypeFor[GroupMarshaler]()
+)
+
+// opaqueSchemaType 自定义序列化的类型不展开字段。
+func opaqueSchemaType(t reflect.Type) bool {
+	if t == syncMapType {
+		return true
+	}
+	pt := reflect.PointerTo(t)
+	for _, it := range []reflect.Type{jsonMarshalerType, textMarshalerType, groupMarshalerType} {
+		if t.Implements(it) || pt.Implements(it) {
+			return true
+		}
+	}
+	return false
+}
+
+// schemaTypeName 返回跨包唯一的类型名，如 example.com/app/model.User。
+func schemaTypeName(t reflect.Type) string {
+	if t.Name() != "" && t.PkgPath() != "" {
+		return t.PkgPath() + "." + t.Name()
+	}
+	return t.String()
+}
+
+// Filter 按 schema 对根类型的 JSON 数据执行与 Encoder 相同的分组筛选与脱敏。
+// data 可以是对象或对象数组；if 条件以同一对象中的键值判断，键缺失视为零值。
+// 与 Encoder 一致，groups 为空时保留除 "-" 以外的全部字段。
+func (s *Schema) Filter(data []byte, groups []string, mode GroupMode) ([]byte, error) {
+	e := NewEncoder().WithGroups(groups...).WithGroupMode(mode)
+	var buf bytes.Buffer
+	if err := e.filterSchemaValue(&buf, s, bytes.TrimSpace(data), s.Root, "array", false); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// filterSchemaValue 过滤 raw：container 逐层剥离，"array" 层逐元素处理，"map" 层逐个处理对象的值，
+// 剥离完毕的对象视为 ref 类型。inbound 为 true 时按写入权限过滤请求数据：只看分组，不执行脱敏与 if 条件。
+func (e Encoder) filterSchemaValue(buf *bytes.Buffer, s *Schema, raw []byte, ref, container string, inbound bool) error {
+	if len(raw) == 0 {
+		return nil
+	}
+	layer, inner, _ := strings.Cut(container, "/")
+	switch {
+	case raw[0] == '[' && layer == "array":
+		var items []json.RawMessage
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return err
+		}
+		buf.WriteByte('[')
+		for i, it := range items {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := e.filterSchemaValue(buf, s, bytes.TrimSpace(it), ref, inner, inbound); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+		return nil
+	case raw[0] == '{' && layer == "map":
+		var keys []string
+		var vals []json.RawMessage
+		if err := decodeObject(raw, func(k string, v json.RawMessage) { keys = append(keys, k); vals = append(vals, v) }); err != nil {
+			return err
+		}
+		buf.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			e.writeString(buf, k)
+			buf.WriteByte(':')
+			if err := e.filterSchemaValue(buf, s, bytes.TrimSpace(vals[i]), ref, inner, inbound); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+		return nil
+	case raw[0] == '{' && layer != "map":
+		return e.filterSchemaObject(buf, s, raw, ref, inbound)
+	}
+	buf.Write(raw)
+	return nil
+}
+
+// filterSchemaObject 按类型 ref 的字段列表过滤对象，保持原有键顺序；schema 中不存在的键一律丢弃。
+func (e Encoder) filterSchemaObject(buf *bytes.Buffer, s *Schema, raw []byte, ref string, inbound bool) error {
+	fields := s.Types[ref]
+	byName := make(map[string]*SchemaField, len(fields))
+	for i := range fields {
+		byName[fields[i].Name] = &fields[i]
+	}
+	values := map[string]json.RawMessage{}
+	var keys []string
+	if err := decodeObject(raw, func(k string, v json.RawMessage) { keys = append(keys, k); values[k] = v }); err != nil {
+		return err
+	}
+
+	buf.WriteByte('{')
+	first := true
+	for _, k := range keys {
+		f, ok := byName[k]
+		if !ok || f.Never || (len(e.opts.Groups) > 0 && !e.includeField(f.Groups)) {
+			continue
+		}
+		if f.If != "" && !inbound {
+			name, negate := parseCondition(f.If)
+			if zeroJSON(values[name]) != negate {
+				continue
+			}
+		}
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		e.writeString(buf, k)
+		buf.WriteByte(':')
+		v := bytes.TrimSpace(values[k])
+		if !inbound && e.masked(&fieldInfo{mask: f.Mask, unmask: f.Unmask}) && !bytes.Equal(v, []byte("null")) {
+			fn, ok := lookupMask(f.Mask)
+			if !ok {
+				return fmt.Errorf("%w: %q", ErrUnknownMask, f.Mask)
+			}
+			var str string
+			if json.Unmarshal(v, &str) != nil {
+				str = string(v)
+			}
+			e.writeString(buf, fn(str))
+			continue
+		}
+		if f.Ref != "" {
+			if err := e.filterSchemaValue(buf, s, v, f.Ref, f.Container, inbound); err != nil {
+				return err
+			}
+			continue
+		}
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+// decodeObject 按出现顺序遍历 JSON 对象的键值。
+func decodeObject(raw []byte, fn func(k string, v json.RawMessage)) error {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		var v json.RawMessage
+		if err := dec.Decode(&v); err != nil {
+			return err
+		}
+		fn(tok.(string), v)
+	}
+	return nil
+}
+
+// zeroJSON 判断 JSON 值是否为零值（缺失、null、false、0、""、[]、{}）。
+func zeroJSON(v json.RawMessage) bool {
+	s := strings.TrimSpace(string(v))
+	switch s {
+	case "", "null", "false", "0", `""`, "[]", "{}":
+		return true
+	}
+	return false
+}
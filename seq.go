@@ -0,0 +1,194 @@
+package groupjson
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+var syncMapType = reflect.TypeFor[sync.Map]()
+
+// encodeSyncMap 将 sync.Map 输出为 JSON 对象，键必须为字符串（或底层为 string 的类型）。
+// 值按常规规则递归编码并应用分组筛选。
+func (e Encoder) encodeSyncMap(buf *bytes.Buffer, m *sync.Map, ctx *encodeContext) error {
+	if err := ctx.incDepth(); err != nil {
+		return ctx.truncate(buf, err)
+	}
+	defer ctx.decDepth()
+
+	var keys []string
+	vals := map[string]any{}
+	var keyErr error
+	m.Range(func(k, v any) bool {
+		kv := reflect.ValueOf(k)
+		if kv.Kind() != reflect.String {
+			keyErr = ErrNonStringMapKey
+			return false
+		}
+		keys = append(keys, kv.String())
+		vals[kv.String()] = v
+		return true
+	})
+	if keyErr != nil {
+		return keyErr
+	}
+	if e.opts.SortKeys {
+		sort.Strings(keys)
+	}
+
+	buf.WriteByte('{')
+	first := true
+	for _, k := range keys {
+		if !e.keyVisible(k, ctx) {
+			continue
+		}
+		mark, wasFirst := buf.Len(), first
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		e.writeString(buf, k)
+		buf.WriteByte(':')
+		ctx.pushPath(PathSegment{Kind: SegmentKey, Name: k})
+		err := e.encode(buf, reflect.ValueOf(vals[k]), ctx)
+		ctx.popPath()
+		if err != nil {
+			if err != errOmit {
+				return withSegment(err, PathSegment{Kind: SegmentKey, Name: k}, reflect.TypeOf(vals[k]))
+			}
+			buf.Truncate(mark)
+			first = wasFirst
+		}
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+// seqKind 判断函数类型是否为 iter.Seq[T]（返回 1）或键为字符串的 iter.Seq2[K, V]（返回 2）。
+func seqKind(t reflect.Type) int {
+	if t.Kind() != reflect.Func || t.NumIn() != 1 || t.NumOut() != 0 {
+		return 0
+	}
+	yield := t.In(0)
+	if yield.Kind() != reflect.Func || yield.NumOut() != 1 || yield.Out(0).Kind() != reflect.Bool {
+		return 0
+	}
+	switch {
+	case yield.NumIn() == 1:
+		return 1
+	case yield.NumIn() == 2 && yield.In(0).Kind() == reflect.String:
+		return 2
+	}
+	return 0
+}
+
+// encodeSeq 迭代 iter.Seq 输出数组、迭代 iter.Seq2 输出对象，元素逐个编码而不先物化为切片。
+// 编码出错时停止迭代并返回错误；迭代器 panic 会被转换为错误。
+func (e Encoder) encodeSeq(buf *bytes.Buffer, v reflect.Value, kind int, ctx *encodeContext) (err error) {
+	if v.IsNil() {
+		buf.WriteString("null")
+		return nil
+	}
+	if err := ctx.incDepth(); err != nil {
+		return ctx.truncate(buf, err)
+	}
+	defer ctx.decDepth()
+
+	open, close := byte('['), byte(']')
+	if kind == 2 {
+		open, close = '{', '}'
+	}
+	buf.WriteByte(open)
+	first, i := true, 0
+	var encErr error
+	yield := reflect.MakeFunc(v.Type().In(0), func(args []reflect.Value) []reflect.Value {
+		if kind == 2 && !e.keyVisible(args[0].String(), ctx) {
+			return []reflect.Value{reflect.ValueOf(true)}
+		}
+		mark, wasFirst := buf.Len(), first
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		val, seg := args[0], PathSegment{Kind: SegmentIndex, Index: i}
+		if kind == 2 {
+			e.writeString(buf, args[0].String())
+			buf.WriteByte(':')
+			val, seg = args[1], PathSegment{Kind: SegmentKey, Name: args[0].String()}
+		}
+		i++
+		ctx.pushPath(seg)
+		err := e.encode(buf, val, ctx)
+		ctx.popPath()
+		if err != nil {
+			if err != errOmit {
+				encErr = withSegment(err, seg, val.Type())
+				return []reflect.Value{reflect.ValueOf(false)}
+			}
+			buf.Truncate(mark)
+			first = wasFirst
+		}
+		return []reflect.Value{reflect.ValueOf(true)}
+	})
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("groupjson: iterator panicked: %v", r)
+		}
+	}()
+	v.Call([]reflect.Value{yield})
+	if encErr != nil {
+		return encErr
+	}
+	buf.WriteByte(close)
+	return nil
+}
+
+// encodeChan 读取 channel 直至关闭，逐个编码元素输出为数组。
+// 超过 MaxChannelItems 时返回 ErrChannelLimit，此时 channel 中剩余的元素不再读取。
+func (e Encoder) encodeChan(buf *bytes.Buffer, v reflect.Value, ctx *encodeContext) error {
+	if v.IsNil() {
+		buf.WriteString("null")
+		return nil
+	}
+	if err := ctx.incDepth(); err != nil {
+		return ctx.truncate(buf, err)
+	}
+	defer ctx.decDepth()
+
+	limit := e.opts.MaxChannelItems
+	if limit <= 0 {
+		limit = DefaultMaxChannelItems
+	}
+	buf.WriteByte('[')
+	first := true
+	for i := 0; ; i++ {
+		// 先检查上限再接收，超限时不多取走生产者的元素
+		if i >= limit {
+			return fmt.Errorf("%w: limit %d", ErrChannelLimit, limit)
+		}
+		x, ok := v.Recv()
+		if !ok {
+			break
+		}
+		mark, wasFirst := buf.Len(), first
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		ctx.pushPath(PathSegment{Kind: SegmentIndex, Index: i})
+		err := e.encode(buf, x, ctx)
+		ctx.popPath()
+		if err != nil {
+			if err != errOmit {
+				return withSegment(err, PathSegment{Kind: SegmentIndex, Index: i}, v.Type().Elem())
+			}
+			buf.Truncate(mark)
+			first = wasFirst
+		}
+	}
+	buf.WriteByte(']')
+	return nil
+}
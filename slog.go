@@ -0,0 +1,124 @@
+package groupjson
+
+import (
+	"context"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"reflect"
+)
+
+// DefaultLogGroup 日志场景默认使用的分组名。
+const DefaultLogGroup = "log"
+
+// LogValue 返回按 "log" 分组序列化 v 的 slog.LogValuer。
+// 序列化延迟到日志真正输出时才执行，未开启的日志级别不产生开销。
+func LogValue(v any) slog.LogValuer {
+	return NewEncoder().WithGroups(DefaultLogGroup).LogValue(v)
+}
+
+// LogValue 返回使用当前 Encoder 配置序列化 v 的 slog.LogValuer。
+func (e Encoder) LogValue(v any) slog.LogValuer { return logValuer{enc: e, v: v} }
+
+// logValuer 将任意值包装为分组过滤后的原始 JSON。
+type logValuer struct {
+	// enc 序列化所用的编码器
+	enc Encoder
+	// v 待序列化的原始值
+	v any
+}
+
+func (l logValuer) LogValue() slog.Value {
+	b, err := l.enc.Marshal(l.v)
+	if err != nil {
+		return slog.StringValue("!ERROR:" + err.Error())
+	}
+	return slog.AnyValue(json.RawMessage(b))
+}
+
+// LogHandler 包装另一个 slog.Handler，
+// 将结构体及含有结构体的切片、数组、map、指针、接口类型的属性经 groupjson 过滤后再交给下游输出，
+// 保证敏感字段不会进入日志。
+type LogHandler struct {
+	// next 下游 Handler
+	next slog.Handler
+	// enc 属性序列化所用的编码器
+	enc Encoder
+}
+
+// NewLogHandler 创建 LogHandler；enc 未设置分组时默认使用 "log" 分组。
+func NewLogHandler(next slog.Handler, enc Encoder) *LogHandler {
+	if len(enc.opts.Groups) == 0 {
+		enc = enc.WithGroups(DefaultLogGroup)
+	}
+	return &LogHandler{next: next, enc: enc}
+}
+
+func (h *LogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *LogHandler) Handle(ctx context.Context, r slog.Record) error {
+	out := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		out.AddAttrs(h.filterAttr(a))
+		return true
+	})
+	return h.next.Handle(ctx, out)
+}
+
+func (h *LogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	filtered := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		filtered[i] = h.filterAttr(a)
+	}
+	return &LogHandler{next: h.next.WithAttrs(filtered), enc: h.enc}
+}
+
+func (h *LogHandler) WithGroup(name string) slog.Handler {
+	return &LogHandler{next: h.next.WithGroup(name), enc: h.enc}
+}
+
+// filterAttr 对含有结构体的属性值应用分组过滤；实现了 error、fmt.Stringer、slog.LogValuer、
+// json.Marshaler 或 encoding.TextMarshaler 的值与其余属性原样返回。
+func (h *LogHandler) filterAttr(a slog.Attr) slog.Attr {
+	switch a.Value.Kind() {
+	case slog.KindGroup:
+		group := a.Value.Group()
+		attrs := make([]any, len(group))
+		for i, ga := range group {
+			attrs[i] = h.filterAttr(ga)
+		}
+		return slog.Group(a.Key, attrs...)
+	case slog.KindAny:
+		v := a.Value.Any()
+		switch v.(type) {
+		case slog.LogValuer, error, fmt.Stringer, json.Marshaler, encoding.TextMarshaler:
+			// 自带文本或序列化形式的值（如 errors.New 返回的 *errorString）原样交给下游
+			return a
+		}
+		if t := reflect.TypeOf(v); t != nil && containsStruct(t, map[reflect.Type]bool{}) {
+			return slog.Any(a.Key, h.enc.LogValue(v))
+		}
+	}
+	return a
+}
+
+// containsStruct 判断 t 的值是否可能含有结构体：结构体本身，或元素、键含有结构体的切片、数组、map、指针；
+// 接口的动态类型无法静态确定，按可能含有处理。seen 记录已访问的类型，避免递归类型死循环。
+func containsStruct(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if seen[t] {
+		return false
+	}
+	seen[t] = true
+	switch t.Kind() {
+	case reflect.Struct, reflect.Interface:
+		return true
+	case reflect.Pointer, reflect.Slice, reflect.Array:
+		return containsStruct(t.Elem(), seen)
+	case reflect.Map:
+		return containsStruct(t.Key(), seen) || containsStruct(t.Elem(), seen)
+	}
+	return false
+}
@@ -0,0 +1,42 @@
+//go:build !tinygo
+
+// text/template 依赖 TinyGo 尚未完整支持的反射方法调用，受限构建中不提供模板函数。
+
+package groupjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"text/template"
+)
+
+// TemplateFuncs 返回模板函数，便于在服务端模板中直接输出分组过滤后的数据：
+//
+//	groupjsonMarshal v  -> 分组过滤后的 JSON 字符串
+//	groupjsonMap v      -> 分组过滤后的 map[string]any（数组为 []any），可在模板中取值或 range
+//
+// 返回值同时适用于 text/template 与 html/template（后者的 FuncMap 为同一类型别名）。
+func TemplateFuncs(groups ...string) template.FuncMap {
+	return NewEncoder().WithGroups(groups...).TemplateFuncs()
+}
+
+// TemplateFuncs 返回使用当前 Encoder 配置的模板函数，见包级 TemplateFuncs。
+func (e Encoder) TemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"groupjsonMarshal": func(v any) (string, error) {
+			b, err := e.Marshal(v)
+			return string(b), err
+		},
+		"groupjsonMap": func(v any) (any, error) {
+			b, err := e.Marshal(v)
+			if err != nil {
+				return nil, err
+			}
+			dec := json.NewDecoder(bytes.NewReader(b))
+			dec.UseNumber()
+			var out any
+			err = dec.Decode(&out)
+			return out, err
+		},
+	}
+}
@@ -0,0 +1,102 @@
+package groupjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+)
+
+// ToMap 使用默认配置按 groups 将结构体转换为分组过滤后的 map，见 Encoder.ToMap。
+func ToMap(v any, groups ...string) (map[string]any, error) {
+	return NewEncoder().WithGroups(groups...).ToMap(v)
+}
+
+// ToSlice 使用默认配置按 groups 将切片或数组转换为分组过滤后的 []any，见 Encoder.ToSlice。
+func ToSlice(v any, groups ...string) ([]any, error) {
+	return NewEncoder().WithGroups(groups...).ToSlice(v)
+}
+
+// ToMap 将结构体（或其指针）转换为分组过滤后的 map，便于在序列化前做模板渲染、合并或比对。
+// 结果由 Marshal 的输出解码而来，值的对应关系与错误处理同 SliceToMaps；TopLevelKey 与 Envelope 不参与 map 输出。
+func (e Encoder) ToMap(v any) (map[string]any, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil, ErrNilValue
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, ErrInvalidType
+	}
+	var m map[string]any
+	err := e.decodeMarshaled(v, &m)
+	return m, err
+}
+
+// ToSlice 将切片或数组（元素可为任意类型）转换为分组过滤后的 []any，
+// 结果由 Marshal 的输出解码而来，值的对应关系与错误处理同 SliceToMaps；nil 切片返回 nil。
+func (e Encoder) ToSlice(v any) ([]any, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil, ErrNilValue
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, ErrInvalidType
+	}
+	var list []any
+	err := e.decodeMarshaled(v, &list)
+	return list, err
+}
+
+// SliceToMaps 将结构体切片（或数组，元素可为指针）转换为分组过滤后的 map 切片，
+// 适合需要 map 而非 JSON 字节的下游（如分析管道）；nil 元素对应 nil map。
+//
+// 值按 Marshal 的完整规则编码后再解码，与 JSON 输出逐值一致：对象为 map[string]any，数组为 []any，
+// 数字为 json.Number，其余为 string、bool 或 nil（如 time.Time 为 RFC 3339 字符串）。
+// 非 ErrorFail 的 ErrorPolicy 下跳过或置空了部分值时，同时返回结果与 *MultiError。
+func (e Encoder) SliceToMaps(slice any) ([]map[string]any, error) {
+	rv := reflect.ValueOf(slice)
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil, ErrNilValue
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, ErrInvalidType
+	}
+	for i := 0; i < rv.Len(); i++ {
+		el := rv.Index(i)
+		for (el.Kind() == reflect.Pointer || el.Kind() == reflect.Interface) && !el.IsNil() {
+			el = el.Elem()
+		}
+		if el.Kind() != reflect.Struct && el.Kind() != reflect.Pointer && el.Kind() != reflect.Interface {
+			return nil, ErrInvalidType
+		}
+	}
+	var out []map[string]any
+	err := e.decodeMarshaled(slice, &out)
+	return out, err
+}
+
+// decodeMarshaled 按 Marshal 的规则编码 v（不含 TopLevelKey 与 Envelope 包装），
+// 再以 json.Number 保留数字精度解码到 dst。编码返回 *MultiError 时照常解码并返回该错误。
+func (e Encoder) decodeMarshaled(v any, dst any) error {
+	e.opts.TopLevelKey, e.opts.Envelope = "", nil
+	buf := getBuffer(e.opts.BufferSize)
+	defer putBuffer(buf)
+	err := e.encodeTop(buf, v)
+	if err != nil && !isMultiError(err) {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(buf.Bytes()))
+	dec.UseNumber()
+	if derr := dec.Decode(dst); derr != nil {
+		return derr
+	}
+	return err
+}
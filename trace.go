@@ -0,0 +1,66 @@
+package groupjson
+
+import "reflect"
+
+// TraceReason 字段被输出或跳过的原因。
+type TraceReason string
+
+const (
+	// TraceGroupMatch 字段分组与请求的分组匹配。
+	TraceGroupMatch TraceReason = "group-match"
+	// TraceNoGroups 未请求分组，输出全部字段。
+	TraceNoGroups TraceReason = "no-groups"
+	// TraceGroupMismatch 字段分组与请求的分组不匹配（含没有分组标签的字段）。
+	TraceGroupMismatch TraceReason = "group-mismatch"
+	// TraceNever 分组标签为 "-"，永不输出。
+	TraceNever TraceReason = "never"
+	// TraceDenyRule 命中 WithDenyFields 规则。
+	TraceDenyRule TraceReason = "deny-rule"
+	// TraceAllowRule 分组不匹配，但命中 WithAllowFields 规则。
+	TraceAllowRule TraceReason = "allow-rule"
+	// TraceCondition if 条件或字段谓词不成立。
+	TraceCondition TraceReason = "condition"
+	// TraceNilEmbedded 字段经由 nil 嵌入指针提升，无值可取。
+	TraceNilEmbedded TraceReason = "nil-embedded"
+	// TraceOmitEmpty omitempty 且值为空。
+	TraceOmitEmpty TraceReason = "omitempty"
+	// TraceOmitZero omitzero 且值为零值。
+	TraceOmitZero TraceReason = "omitzero"
+	// TraceTransformer 字段转换钩子要求丢弃。
+	TraceTransformer TraceReason = "transformer"
+	// TraceDepth 值超过深度限制，按 DepthPolicy 省略或输出为 null。
+	TraceDepth TraceReason = "depth"
+	// TraceCycle 值构成循环引用，按 CycleHandling 省略或替换。
+	TraceCycle TraceReason = "cycle"
+)
+
+// TraceEvent 描述一次字段取舍。每个被考察的字段产生一个事件；字段值随后因深度或循环被截断时，
+// 在同一路径上追加一个 TraceDepth/TraceCycle 事件（截断也可能发生在数组元素或 map 值上）。
+type TraceEvent struct {
+	// Path 字段（或被截断的值）的路径，仅在回调期间有效，需长期持有时调用 Clone
+	Path Path
+	// Type 字段所属结构体类型，深度与循环事件为空
+	Type string
+	// Field Go 字段名，深度与循环事件为空
+	Field string
+	// Included 字段是否输出；深度与循环事件中表示是否以 null 或引用代替原值保留了该成员
+	Included bool
+	// Reason 决定取舍的原因
+	Reason TraceReason
+}
+
+// WithTrace 注册字段取舍追踪回调：编码时对每个字段报告是否输出以及原因（分组匹配、omitempty、
+// 条件、路径规则、深度、循环等），用于排查“响应里为什么没有这个字段”。回调在编码路径上同步调用，
+// 只应在调试时开启。
+func (e Encoder) WithTrace(fn func(TraceEvent)) Encoder { e.opts.Trace = fn; return e }
+
+// traceField 报告字段的取舍。
+func (e Encoder) traceField(t reflect.Type, f *fieldInfo, ctx *encodeContext, included bool, reason TraceReason) {
+	path := append(ctx.path, PathSegment{Kind: SegmentField, Name: f.jsonName})
+	e.opts.Trace(TraceEvent{Path: path, Type: t.String(), Field: f.name, Included: included, Reason: reason})
+}
+
+// traceValue 报告当前路径上的值被深度限制或循环处理截断。
+func (c *encodeContext) traceValue(included bool, reason TraceReason) {
+	c.opts.Trace(TraceEvent{Path: c.path, Included: included, Reason: reason})
+}
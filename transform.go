@@ -0,0 +1,39 @@
+package groupjson
+
+import "reflect"
+
+// FieldInfo 钩子中暴露的字段元信息，切片字段与 schema 共享，调用方不应修改。
+type FieldInfo struct {
+	// Name Go 字段名
+	Name string
+	// JSONName 输出使用的 JSON 键名
+	JSONName string
+	// Groups 分组标签中声明的分组
+	Groups []string
+	// OmitEmpty 是否带有 omitempty
+	OmitEmpty bool
+	// OmitZero 是否带有 omitzero
+	OmitZero bool
+}
+
+// FieldTransformer 对每个将要输出的字段调用，path 含该字段自身。
+// 返回 (新值, true) 以替换输出值，返回 (任意, false) 以丢弃该字段。
+type FieldTransformer func(path Path, field FieldInfo, v reflect.Value) (any, bool)
+
+// WithFieldTransformer 设置字段转换钩子，可在编码时改写、脱敏或丢弃字段值，
+// 如为分析导出对用户 ID 做哈希。path 仅在回调期间有效，需长期持有请调用 Path.Clone。
+func (e Encoder) WithFieldTransformer(fn FieldTransformer) Encoder {
+	e.opts.FieldTransformer = fn
+	return e
+}
+
+// public 转换为对外暴露的 FieldInfo。
+func (f *fieldInfo) public() FieldInfo {
+	return FieldInfo{
+		Name:      f.name,
+		JSONName:  f.jsonName,
+		Groups:    f.groups,
+		OmitEmpty: f.omitEmpty,
+		OmitZero:  f.omitZero,
+	}
+}
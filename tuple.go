@@ -0,0 +1,63 @@
+package groupjson
+
+import (
+	"bytes"
+	"reflect"
+	"slices"
+)
+
+// WithStructAsArray 将指定结构体类型编码为 JSON 数组（元组风格）：
+// 按声明顺序输出通过分组筛选的字段值，不输出键名，适合时序/分析类紧凑传输格式。
+// 因 omitempty、条件、路径规则等被省略的字段输出 null 以保持位置稳定；计算字段不参与。
+func (e Encoder) WithStructAsArray(types ...reflect.Type) Encoder {
+	e.opts.TupleTypes = append(append([]reflect.Type(nil), e.opts.TupleTypes...), types...)
+	return e
+}
+
+// isTuple 判断 t 是否以元组形式编码。
+func (e Encoder) isTuple(t reflect.Type) bool {
+	return len(e.opts.TupleTypes) > 0 && slices.Contains(e.opts.TupleTypes, t)
+}
+
+// tupleColumn 判断字段是否占据元组中的一个位置：分组筛选通过即占位，与具体取值无关。
+func (e Encoder) tupleColumn(p *plan, f *fieldInfo) bool {
+	return p.filtered || !(f.never || (len(e.opts.Groups) > 0 && !e.includeField(f.groups)))
+}
+
+// encodeTuple 以数组形式写出结构体字段值。
+func (e Encoder) encodeTuple(buf *bytes.Buffer, v reflect.Value, p *plan, ctx *encodeContext) error {
+	buf.WriteByte('[')
+	first := true
+	for _, f := range p.fields {
+		if !e.tupleColumn(p, f) {
+			continue
+		}
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+
+		seg := PathSegment{Kind: SegmentField, Name: f.jsonName}
+		fv, ok, err := e.fieldValue(v, p, f, ctx)
+		if err != nil {
+			return withSegment(err, seg, v.Type().FieldByIndex(f.index).Type)
+		}
+		if !ok {
+			buf.WriteString("null")
+			continue
+		}
+		mark := buf.Len()
+		ctx.pushPath(seg)
+		err = e.encodeField(buf, fv, f, ctx)
+		ctx.popPath()
+		if err != nil {
+			if err != errOmit {
+				return withSegment(err, seg, v.Type().FieldByIndex(f.index).Type)
+			}
+			buf.Truncate(mark)
+			buf.WriteString("null")
+		}
+	}
+	buf.WriteByte(']')
+	return nil
+}
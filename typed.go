@@ -0,0 +1,61 @@
+package groupjson
+
+import (
+	"io"
+	"reflect"
+)
+
+// TypedEncoder 绑定到类型 T 的编码器，由 NewTypedEncoder 生成，只读、可并发使用。
+// 根类型、字段计划与编码函数在构造时确定，编码时不再调用 reflect.TypeOf 查找缓存，
+// 参数类型也由编译器检查，不会出现 Compiled 在运行时才发现的类型不符。
+type TypedEncoder[T any] struct {
+	c *Compiled
+}
+
+// NewTypedEncoder 按 e 的当前配置为类型 T 生成编码器，语义与 Encoder.Compile 相同。
+// T 可以是接口类型，此时编码函数按每次传入值的动态类型查找。
+//
+//	users, err := groupjson.NewTypedEncoder[[]User](groupjson.NewEncoder().WithGroups("public"))
+//	b, err := users.Marshal(list)
+func NewTypedEncoder[T any](e Encoder) (*TypedEncoder[T], error) {
+	c, err := e.compileRoot(reflect.TypeFor[T]())
+	if err != nil {
+		return nil, err
+	}
+	return &TypedEncoder[T]{c: c}, nil
+}
+
+// MarshalT 是 Marshal 的泛型版本，参数类型在编译期确定。
+// 热点路径上应复用 NewTypedEncoder 生成的编码器，以省去每次调用的配置与缓存查找。
+func MarshalT[T any](v T, groups ...string) ([]byte, error) {
+	return NewEncoder().WithGroups(groups...).Marshal(v)
+}
+
+// Marshal 与 Encoder.Marshal 相同。
+func (te *TypedEncoder[T]) Marshal(v T) ([]byte, error) {
+	buf := getBuffer(te.c.e.opts.BufferSize)
+	defer putBuffer(buf)
+
+	err := te.c.encodeRoot(buf, reflect.ValueOf(any(v)))
+	if err != nil && !isMultiError(err) {
+		return nil, err
+	}
+	te.c.e.sample(v, buf.Bytes())
+	return append([]byte(nil), buf.Bytes()...), err
+}
+
+// Encode 与 Encoder.Encode 相同。
+func (te *TypedEncoder[T]) Encode(w io.Writer, v T) error {
+	buf := getBuffer(te.c.e.opts.BufferSize)
+	defer putBuffer(buf)
+
+	err := te.c.encodeRoot(buf, reflect.ValueOf(any(v)))
+	if err != nil && !isMultiError(err) {
+		return err
+	}
+	te.c.e.sample(v, buf.Bytes())
+	if _, werr := w.Write(buf.Bytes()); werr != nil {
+		return werr
+	}
+	return err
+}
@@ -0,0 +1,217 @@
+package groupjson
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// TypeScript 使用默认配置生成 TypeScript 接口定义，见 Encoder.TypeScript。
+func TypeScript(types []any, views ...View) ([]byte, error) {
+	return NewEncoder().TypeScript(types, views...)
+}
+
+// TypeScript 为每个（类型, 视图）组合生成导出的 TypeScript 接口，如
+// export interface UserPublic { id: number; name: string; }，使前端类型与 Go 标签保持同步。
+// 嵌套结构体同样按视图生成独立接口；带 omitempty/omitzero 或 if 条件的字段标记为可选，
+// 指针与可能为 nil 的集合附加 | null。视图规则与 OpenAPIComponents 相同。
+func (e Encoder) TypeScript(types []any, views ...View) ([]byte, error) {
+	ts, views, err := e.resolveViews(types, views)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &tsGen{used: map[string]bool{}}
+	for _, v := range views {
+		g.e, g.suffix = e.WithGroups(v.Groups...), exportedName(v.Name)
+		g.names = map[reflect.Type]string{}
+		for _, t := range ts {
+			g.structDecl(t)
+		}
+	}
+	var sb strings.Builder
+	for i, d := range g.decls {
+		if i > 0 {
+			sb.WriteByte('\n')
+		}
+		sb.WriteString(d)
+	}
+	return []byte(sb.String()), nil
+}
+
+// tsGen 按分组视图把 Go 类型转换为 TypeScript 类型表达式。
+type tsGen struct {
+	e Encoder
+	// suffix 追加在接口名之后，区分同一类型的不同视图
+	suffix string
+	// decls 已生成的接口声明，按首次引用顺序排列
+	decls []string
+	// names 类型 -> 接口名
+	names map[reflect.Type]string
+	// used 已占用的接口名
+	used map[string]bool
+}
+
+// typeExpr 返回类型 t 的 TypeScript 类型表达式。
+func (g *tsGen) typeExpr(t reflect.Type) string {
+	if t.Kind() == reflect.Pointer {
+		return tsNullable(g.typeExpr(t.Elem()))
+	}
+	switch {
+	case t == timeType:
+		return "string"
+	case t == syncMapType:
+		return "Record<string, unknown>"
+	case t.Implements(groupMarshalerType) || reflect.PointerTo(t).Implements(groupMarshalerType),
+		t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType):
+		return "unknown"
+	case t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType):
+		return "string"
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int64, reflect.Uint64:
+		if g.e.opts.Int64AsString {
+			// 超出安全整数范围的取值以字符串输出
+			return "number | string"
+		}
+		return "number"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 && t.Kind() == reflect.Slice {
+			return "string"
+		}
+		s := tsArray(g.typeExpr(t.Elem()))
+		if t.Kind() == reflect.Slice && g.e.opts.NilCollections != NilAsEmpty {
+			s = tsNullable(s)
+		}
+		return s
+	case reflect.Map:
+		s := "Record<string, " + g.typeExpr(t.Elem()) + ">"
+		if g.e.opts.NilCollections != NilAsEmpty {
+			s = tsNullable(s)
+		}
+		return s
+	case reflect.Func:
+		switch seqKind(t) {
+		case 1:
+			return tsNullable(tsArray(g.typeExpr(t.In(0).In(0))))
+		case 2:
+			return tsNullable("Record<string, " + g.typeExpr(t.In(0).In(1)) + ">")
+		}
+	case reflect.Chan:
+		return tsArray(g.typeExpr(t.Elem()))
+	case reflect.Struct:
+		return g.structDecl(t)
+	}
+	return "unknown"
+}
+
+// structDecl 生成结构体 t 的接口声明并返回接口名，自引用类型只生成一次。
+func (g *tsGen) structDecl(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+	name := g.declName(t)
+	g.names[t] = name
+	// 先占位，使外层接口排在其引用的嵌套接口之前
+	slot := len(g.decls)
+	g.decls = append(g.decls, "")
+
+	e := g.e
+	sch := e.schemaFor(t)
+	p := e.getPlan(t, sch, planGroupKey(e.opts))
+	var sb strings.Builder
+	sb.WriteString("export interface " + name + " {\n")
+	for _, f := range p.fields {
+		if !e.tupleColumn(p, f) {
+			continue
+		}
+		ft := t.FieldByIndex(f.index).Type
+		typ := "string"
+		if !e.masked(f) && !(f.asString && isQuotable(ft)) {
+			typ = g.typeExpr(ft)
+		}
+		optional := f.omitEmpty || f.omitZero || f.cond != ""
+		writeTSField(&sb, f.jsonName, optional, typ)
+	}
+	if list, ok := virtualFields.Load(t); ok {
+		for _, vf := range list {
+			if len(e.opts.Groups) == 0 || e.includeField(vf.groups) {
+				writeTSField(&sb, vf.name, false, "unknown")
+			}
+		}
+	}
+	sb.WriteString("}\n")
+	g.decls[slot] = sb.String()
+	return name
+}
+
+// declName 返回接口名（类型名加视图后缀），不同包的同名类型追加序号区分。
+func (g *tsGen) declName(t reflect.Type) string {
+	base := t.Name()
+	if base == "" {
+		base = "Anonymous"
+	}
+	base += g.suffix
+	name := base
+	for i := 2; g.used[name]; i++ {
+		name = base + strconv.Itoa(i)
+	}
+	g.used[name] = true
+	return name
+}
+
+// writeTSField 写出一行接口成员，键名不是合法标识符时加引号。
+func writeTSField(sb *strings.Builder, key string, optional bool, typ string) {
+	sb.WriteString("  ")
+	if isTSIdent(key) {
+		sb.WriteString(key)
+	} else {
+		kb, _ := json.Marshal(key)
+		sb.Write(kb)
+	}
+	if optional {
+		sb.WriteByte('?')
+	}
+	sb.WriteString(": " + typ + ";\n")
+}
+
+// isTSIdent 判断 s 能否不加引号作为属性名。
+func isTSIdent(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		switch {
+		case r == '_' || r == '$' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z':
+		case i > 0 && r >= '0' && r <= '9':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// tsNullable 允许类型取 null。
+func tsNullable(s string) string {
+	if strings.HasSuffix(s, " | null") {
+		return s
+	}
+	return s + " | null"
+}
+
+// tsArray 返回元素类型为 elem 的数组类型，联合类型加括号。
+func tsArray(elem string) string {
+	if strings.Contains(elem, " | ") {
+		elem = "(" + elem + ")"
+	}
+	return elem + "[]"
+}
@@ -5,8 +5,10 @@ import (
 	"encoding"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"reflect"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 )
@@ -24,11 +26,72 @@ const (
 	ModeAnd
 )
 
+// String 返回模式名称 "or" 或 "and"。
+func (m Mode) String() string {
+	if m == ModeAnd {
+		return "and"
+	}
+	return "or"
+}
+
+// ParseMode 解析模式名称（不区分大小写）："or"/"any" 与空串为 ModeOr，"and"/"all" 为 ModeAnd。
+func ParseMode(s string) (Mode, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "or", "any":
+		return ModeOr, nil
+	case "and", "all":
+		return ModeAnd, nil
+	}
+	return ModeOr, fmt.Errorf("%w: %q", ErrInvalidMode, s)
+}
+
+// 错误常量，编码过程中的错误以 *EncodeError 包装并附带出错位置，可用 errors.Is 判断。
+var (
+	ErrInvalidMode       = errors.New("groupjson: invalid mode")
+	ErrCircularReference = errors.New("groupjson: circular reference detected")
+	ErrUnsupportedType   = errors.New("groupjson: unsupported type")
+	ErrNonStringMapKey   = errors.New("groupjson: map key must be string")
+)
+
+// EncodeError 描述编码失败的位置：出错值的路径（如 article.comments[2].time）与声明类型，
+// Cause 为原始错误（上面的错误常量或自定义 Marshaler 返回的错误）。根值本身的错误不包装。
+type EncodeError struct {
+	// Path 出错值的路径
+	Path string
+	// FieldType 出错值（字段、元素或 map 值）声明的 Go 类型
+	FieldType reflect.Type
+	// Cause 原始错误
+	Cause error
+}
+
+func (e *EncodeError) Error() string {
+	return fmt.Sprintf("%v (at %s, type %v)", e.Cause, e.Path, e.FieldType)
+}
+
+func (e *EncodeError) Unwrap() error { return e.Cause }
+
+// withSegment 为向上传递的错误在路径前插入当前层的段（键名或 "[i]"），首次包装时记录类型 t。
+// 路径只在出错时构建，不影响正常编码的性能。
+func withSegment(err error, seg string, t reflect.Type) error {
+	if ee, ok := err.(*EncodeError); ok {
+		if !strings.HasPrefix(ee.Path, "[") {
+			seg += "."
+		}
+		ee.Path = seg + ee.Path
+		return ee
+	}
+	return &EncodeError{Path: seg, FieldType: t, Cause: err}
+}
+
 // Encoder 是一个支持分组筛选的 JSON 编码器。
-// 它是线程安全的（因为配置一旦设定即不可变），但通常作为临时对象使用。
+//
+// 并发约定：Encoder 创建后不可变。With* 方法不修改接收者，而是返回一份带新配置的副本，
+// 因此可以把预配置的 Encoder 放在全局变量中，在各请求里继续 With* 定制而不会相互影响；
+// Marshal 只读取配置，可被多个 goroutine 同时调用。
 type Encoder struct {
-	groups []string // 需要保留的分组列表
-	mode   Mode     // 分组匹配模式 (OR 或 AND)
+	groups     []string // 需要保留的分组列表
+	mode       Mode     // 分组匹配模式 (OR 或 AND)
+	sortFields bool     // 结构体字段是否按键名字典序输出
 }
 
 // New 创建一个新的编码器，使用默认配置（ModeOr）。
@@ -38,19 +101,30 @@ func New() *Encoder {
 	}
 }
 
-// WithGroups 设置需要保留的分组。
+// WithGroups 返回设置了分组的新编码器，e 本身不变。
 // 支持链式调用。
 func (e *Encoder) WithGroups(groups ...string) *Encoder {
+	c := *e
 	// 复制切片防止外部修改
-	e.groups = append([]string(nil), groups...)
-	return e
+	c.groups = append([]string(nil), groups...)
+	return &c
 }
 
-// WithMode 设置分组匹配模式 (ModeOr 或 ModeAnd)。
+// WithMode 返回设置了分组匹配模式 (ModeOr 或 ModeAnd) 的新编码器，e 本身不变。
 // 支持链式调用。
 func (e *Encoder) WithMode(mode Mode) *Encoder {
-	e.mode = mode
-	return e
+	c := *e
+	c.mode = mode
+	return &c
+}
+
+// WithSortFields 返回设置了结构体字段排序的新编码器，e 本身不变。
+// 开启后结构体字段按 JSON 键名字典序输出（map 键始终排序），输出与字段声明顺序无关，
+// 适合快照测试与缓存键。支持链式调用。
+func (e *Encoder) WithSortFields(on bool) *Encoder {
+	c := *e
+	c.sortFields = on
+	return &c
 }
 
 // Marshal 将 v 序列化为 JSON，仅保留符合分组条件的字段。
@@ -176,7 +250,7 @@ func (ctx *encodeContext) encode(buf *bytes.Buffer, v reflect.Value) error {
 		if v.Kind() == reflect.Pointer {
 			ptr := v.Pointer()
 			if _, ok := ctx.visited[ptr]; ok {
-				return errors.New("groupjson: circular reference detected")
+				return ErrCircularReference
 			}
 			// 标记当前指针已访问
 			ctx.visited[ptr] = struct{}{}
@@ -221,7 +295,7 @@ func (ctx *encodeContext) encode(buf *bytes.Buffer, v reflect.Value) error {
 		}
 		return nil
 	default:
-		return errors.New("groupjson: unsupported type: " + v.Kind().String())
+		return fmt.Errorf("%w: %s", ErrUnsupportedType, v.Kind())
 	}
 }
 
@@ -231,6 +305,9 @@ func (ctx *encodeContext) encodeStruct(buf *bytes.Buffer, v reflect.Value) error
 	// 获取缓存的结构体元数据 (Schema Cache)
 	// 这一步通过缓存避免了重复的反射解析开销
 	fields := getCachedFields(t)
+	if ctx.encoder.sortFields {
+		fields = getSortedFields(t)
+	}
 
 	buf.WriteByte('{')
 	first := true
@@ -261,6 +338,10 @@ func (ctx *encodeContext) encodeStruct(buf *bytes.Buffer, v reflect.Value) error
 		if f.omitEmpty && isEmptyValue(fv) {
 			continue
 		}
+		// 3. omitzero 处理 (Go 1.24 语义，优先调用 IsZero 方法)
+		if f.omitZero && isZeroValue(fv) {
+			continue
+		}
 
 		if !first {
 			buf.WriteByte(',')
@@ -279,13 +360,13 @@ func (ctx *encodeContext) encodeStruct(buf *bytes.Buffer, v reflect.Value) error
 			var tmp bytes.Buffer
 			// 复用 ctx 进行递归编码，以便正确处理深层逻辑
 			if err := ctx.encode(&tmp, fv); err != nil {
-				return err
+				return withSegment(err, f.name, fv.Type())
 			}
 			writeString(buf, tmp.String())
 		} else {
 			// 正常递归编码
 			if err := ctx.encode(buf, fv); err != nil {
-				return err
+				return withSegment(err, f.name, fv.Type())
 			}
 		}
 
@@ -304,7 +385,7 @@ func (ctx *encodeContext) encodeMap(buf *bytes.Buffer, v reflect.Value) error {
 	}
 	// 检查 Key 是否为字符串
 	if v.Type().Key().Kind() != reflect.String {
-		return errors.New("groupjson: map key must be string")
+		return ErrNonStringMapKey
 	}
 
 	buf.WriteByte('{')
@@ -330,7 +411,7 @@ func (ctx *encodeContext) encodeMap(buf *bytes.Buffer, v reflect.Value) error {
 
 		// 递归写入 Value
 		if err := ctx.encode(buf, val); err != nil {
-			return err
+			return withSegment(err, k.String(), v.Type().Elem())
 		}
 	}
 	buf.WriteByte('}')
@@ -360,7 +441,7 @@ func (ctx *encodeContext) encodeSlice(buf *bytes.Buffer, v reflect.Value) error
 			buf.WriteByte(',')
 		}
 		if err := ctx.encode(buf, v.Index(i)); err != nil {
-			return err
+			return withSegment(err, "["+strconv.Itoa(i)+"]", v.Type().Elem())
 		}
 	}
 	buf.WriteByte(']')
@@ -423,6 +504,7 @@ type fieldInfo struct {
 	name       string   // 字段名 (Go Struct Field Name)
 	quotedName string   // 预处理后的键名，包含冒号，如 "name":
 	omitEmpty  bool     // 是否有 omitempty 标签
+	omitZero   bool     // 是否有 omitzero 标签
 	asString   bool     // 是否有 string 标签
 	groups     []string // 所属分组列表
 }
@@ -486,11 +568,15 @@ func getCachedFields(t reflect.Type) []fieldInfo {
 			}
 
 			omitEmpty := false
+			omitZero := false
 			asString := false
 			for _, opt := range parts[1:] {
 				if opt == "omitempty" {
 					omitEmpty = true
 				}
+				if opt == "omitzero" {
+					omitZero = true
+				}
 				if opt == "string" {
 					asString = true
 				}
@@ -533,6 +619,7 @@ func getCachedFields(t reflect.Type) []fieldInfo {
 				name:       name,
 				quotedName: quotedName,
 				omitEmpty:  omitEmpty,
+				omitZero:   omitZero,
 				asString:   asString,
 				groups:     groups,
 			})
@@ -543,6 +630,50 @@ func getCachedFields(t reflect.Type) []fieldInfo {
 	return fields
 }
 
+var sortedFieldCache sync.Map // 全局缓存: map[reflect.Type][]fieldInfo，按键名排序
+
+// getSortedFields 获取按 JSON 键名排序的字段信息副本。
+func getSortedFields(t reflect.Type) []fieldInfo {
+	if v, ok := sortedFieldCache.Load(t); ok {
+		return v.([]fieldInfo)
+	}
+	fields := append([]fieldInfo(nil), getCachedFields(t)...)
+	sort.SliceStable(fields, func(i, j int) bool {
+		return fields[i].name < fields[j].name
+	})
+	sortedFieldCache.Store(t, fields)
+	return fields
+}
+
+// isZeroer 定义了 IsZero 方法的类型，omitzero 优先调用它。
+type isZeroer interface{ IsZero() bool }
+
+var isZeroerType = reflect.TypeFor[isZeroer]()
+
+// isZeroValue 实现 Go 1.24 encoding/json 的 omitzero 语义：
+// 类型（或其指针）实现 IsZero() bool 时调用它，否则使用 reflect.Value.IsZero。
+func isZeroValue(v reflect.Value) bool {
+	t := v.Type()
+	switch {
+	case t.Implements(isZeroerType):
+		if t.Kind() == reflect.Pointer && v.IsNil() {
+			return true
+		}
+		if t.Kind() == reflect.Interface && v.IsNil() {
+			return true
+		}
+		return v.Interface().(isZeroer).IsZero()
+	case reflect.PointerTo(t).Implements(isZeroerType):
+		if !v.CanAddr() {
+			cp := reflect.New(t).Elem()
+			cp.Set(v)
+			v = cp
+		}
+		return v.Addr().Interface().(isZeroer).IsZero()
+	}
+	return v.IsZero()
+}
+
 // isEmptyValue 判断值是否为空 (用于 omitempty 逻辑)。
 // 遵循 encoding/json 的定义。
 func isEmptyValue(v reflect.Value) bool {
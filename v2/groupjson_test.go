@@ -2,9 +2,11 @@ package groupjson
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"reflect"
 	"strings"
+	"sync"
 	"testing"
 )
 
@@ -376,6 +378,54 @@ func TestNestedPermissions(t *testing.T) {
 	})
 }
 
+// evenZero 以偶数为零值，用于验证 omitzero 调用 IsZero 方法
+type evenZero int
+
+func (z evenZero) IsZero() bool { return z%2 == 0 }
+
+func TestOmitZero(t *testing.T) {
+	type Window struct {
+		ID    evenZero `json:"id,omitzero" groups:"public"`
+		Meta  Meta     `json:"meta,omitzero" groups:"public"`
+		Count int      `json:"count,omitzero" groups:"public"`
+	}
+	b, err := New().WithGroups("public").Marshal(Window{ID: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "{}" {
+		t.Errorf("got %s, want {}", b)
+	}
+	b, _ = New().WithGroups("public").Marshal(Window{ID: 3, Meta: Meta{Version: "1"}})
+	if !jsonEqual(string(b), `{"id":3,"meta":{"version":"1"}}`) {
+		t.Errorf("got %s", b)
+	}
+}
+
+func TestEncoderImmutable(t *testing.T) {
+	base := New().WithGroups("public")
+	u := User{ID: 1, Name: "A", Email: "e"}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// 按请求定制共享编码器，不得影响 base
+			b, _ := base.WithGroups("admin").WithMode(ModeAnd).Marshal(u)
+			if !strings.Contains(string(b), "email") {
+				t.Errorf("admin view missing email: %s", b)
+			}
+		}()
+	}
+	wg.Wait()
+
+	b, _ := base.Marshal(u)
+	if strings.Contains(string(b), "email") {
+		t.Errorf("base encoder was mutated: %s", b)
+	}
+}
+
 // jsonEqual 比较两个 JSON 字符串语义是否相等
 func jsonEqual(a, b string) bool {
 	var j1, j2 interface{}
@@ -387,3 +437,45 @@ func jsonEqual(a, b string) bool {
 	}
 	return reflect.DeepEqual(j1, j2)
 }
+
+func TestSortFields(t *testing.T) {
+	type Doc struct {
+		Zeta  int    `json:"zeta" groups:"public"`
+		Alpha string `json:"alpha" groups:"public"`
+		Mid   bool   `json:"mid"`
+	}
+	enc := New().WithGroups("public")
+	got, err := enc.WithSortFields(true).Marshal(Doc{Zeta: 1, Alpha: "a", Mid: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"alpha":"a","zeta":1}`; string(got) != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	got, _ = enc.Marshal(Doc{Zeta: 1, Alpha: "a"})
+	if want := `{"zeta":1,"alpha":"a"}`; string(got) != want {
+		t.Errorf("declaration order: got %s, want %s", got, want)
+	}
+}
+
+func TestEncodeErrorPath(t *testing.T) {
+	type Comment struct {
+		Body  string `json:"body" groups:"public"`
+		Extra any    `json:"extra" groups:"public"`
+	}
+	type Article struct {
+		Comments []Comment `json:"comments" groups:"public"`
+	}
+	a := Article{Comments: []Comment{{Body: "ok"}, {Body: "bad", Extra: func() {}}}}
+	_, err := Marshal(a, "public")
+	var ee *EncodeError
+	if !errors.As(err, &ee) {
+		t.Fatalf("err = %v, want *EncodeError", err)
+	}
+	if ee.Path != "comments[1].extra" || ee.FieldType != reflect.TypeFor[any]() {
+		t.Errorf("path = %q, type = %v", ee.Path, ee.FieldType)
+	}
+	if !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("err does not wrap ErrUnsupportedType: %v", err)
+	}
+}
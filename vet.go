@@ -0,0 +1,57 @@
+package groupjson
+
+import (
+	"reflect"
+)
+
+// TagConflict 描述同一字段在主标签与回退标签上取值不一致。
+type TagConflict struct {
+	// Type 字段所属结构体类型
+	Type string
+	// Field Go 字段名
+	Field string
+	// Primary 主标签（TagKey）取值
+	Primary string
+	// Fallback 回退标签（TagKeyFallback）取值
+	Fallback string
+}
+
+func (c TagConflict) String() string {
+	return c.Type + "." + c.Field + ": primary " + c.Primary + " differs from fallback " + c.Fallback + "; primary wins"
+}
+
+// VetTagMigration 检查样本值的类型（递归包含嵌套结构体）中同时声明了
+// TagKey 与 TagKeyFallback 且取值不同的字段，用于标签迁移期间的告警。
+func (e Encoder) VetTagMigration(samples ...any) []TagConflict {
+	if e.opts.TagKeyFallback == "" {
+		return nil
+	}
+	var out []TagConflict
+	seen := map[reflect.Type]struct{}{}
+	var walk func(t reflect.Type)
+	walk = func(t reflect.Type) {
+		for t != nil && (t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map) {
+			t = t.Elem()
+		}
+		if t == nil || t.Kind() != reflect.Struct {
+			return
+		}
+		if _, ok := seen[t]; ok {
+			return
+		}
+		seen[t] = struct{}{}
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			p, ok1 := sf.Tag.Lookup(e.opts.TagKey)
+			f, ok2 := sf.Tag.Lookup(e.opts.TagKeyFallback)
+			if ok1 && ok2 && p != f {
+				out = append(out, TagConflict{Type: t.String(), Field: sf.Name, Primary: p, Fallback: f})
+			}
+			walk(sf.Type)
+		}
+	}
+	for _, s := range samples {
+		walk(reflect.TypeOf(s))
+	}
+	return out
+}
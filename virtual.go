@@ -0,0 +1,103 @@
+package groupjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sync"
+)
+
+// virtualField 注册在某类型上的计算字段。
+type virtualField struct {
+	// name 输出键名
+	name string
+	// keyBytes 预计算的 "name":
+	keyBytes []byte
+	// groups 计算字段所属分组
+	groups []string
+	// fn 计算函数，入参为结构体值
+	fn func(v reflect.Value) any
+}
+
+// virtualFields 类型 -> []virtualField，写时复制，读路径无锁。
+var (
+	virtualFields cache[reflect.Type, []virtualField]
+	virtualMu     sync.Mutex
+)
+
+// RegisterVirtualField 为类型 T 注册计算字段，编码 T 时在真实字段之后输出，
+// 与真实字段一样受分组与 Allow/Deny 路径规则约束，不经过 FieldTransformer。
+//
+//	groupjson.RegisterVirtualField("full_name", []string{"public"}, func(u User) any {
+//		return u.First + " " + u.Last
+//	})
+func RegisterVirtualField[T any](name string, groups []string, fn func(T) any) {
+	t := reflect.TypeFor[T]()
+	kb, _ := json.Marshal(name)
+	vf := virtualField{
+		name:     name,
+		keyBytes: append(kb, ':'),
+		groups:   append([]string(nil), groups...),
+		fn:       func(v reflect.Value) any { return fn(v.Interface().(T)) },
+	}
+
+	virtualMu.Lock()
+	defer virtualMu.Unlock()
+	var list []virtualField
+	if prev, ok := virtualFields.Load(t); ok {
+		list = prev
+	}
+	// 同名重复注册时覆盖
+	next := make([]virtualField, 0, len(list)+1)
+	for _, f := range list {
+		if f.name != name {
+			next = append(next, f)
+		}
+	}
+	virtualFields.Store(t, append(next, vf))
+}
+
+// virtualFieldsFor 返回 v 的类型上注册的计算字段。
+func virtualFieldsFor(v reflect.Value) []virtualField {
+	list, ok := virtualFields.Load(v.Type())
+	if !ok || !v.CanInterface() {
+		return nil
+	}
+	return list
+}
+
+// virtualIncluded 判断计算字段是否通过分组与 Deny 规则。
+func (e Encoder) virtualIncluded(f *virtualField, ctx *encodeContext) bool {
+	if len(e.opts.Groups) > 0 && !e.includeField(f.groups) {
+		return false
+	}
+	return !ctx.trackPath || !matchAnyPattern(e.opts.denyPatterns, append(ctx.path, PathSegment{Kind: SegmentField, Name: f.name}))
+}
+
+// encodeVirtual 输出 v 的类型上注册的计算字段，first 与 encodeStruct 共享逗号状态。
+func (e Encoder) encodeVirtual(buf *bytes.Buffer, v reflect.Value, ctx *encodeContext, first *bool) error {
+	for _, f := range virtualFieldsFor(v) {
+		if !e.virtualIncluded(&f, ctx) {
+			continue
+		}
+		seg := PathSegment{Kind: SegmentField, Name: f.name}
+
+		mark, wasFirst := buf.Len(), *first
+		if !*first {
+			buf.WriteByte(',')
+		}
+		*first = false
+		buf.Write(f.keyBytes)
+		ctx.pushPath(seg)
+		fv := reflect.ValueOf(f.fn(v))
+		if err := e.encode(buf, fv, ctx); err != nil {
+			if err != errOmit {
+				return withSegment(err, seg, valueType(fv))
+			}
+			buf.Truncate(mark)
+			*first = wasFirst
+		}
+		ctx.popPath()
+	}
+	return nil
+}